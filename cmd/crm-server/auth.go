@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
+	"gopkg.in/yaml.v3"
+)
+
+// authFile is the layout of the file given to `-auth-file`:
+//
+//	api_key_header: X-Api-Key
+//	api_keys:
+//	  <key>: {subject: erp, roles: [crm:write]}
+//	jwt:
+//	  issuer: https://sso.example.com
+//	  audience: crm
+//	  keys:
+//	    <kid>: <base64 encoded HMAC secret>
+type authFile struct {
+	APIKeyHeader string `yaml:"api_key_header"`
+	APIKeys      map[string]struct {
+		Subject string   `yaml:"subject"`
+		Roles   []string `yaml:"roles"`
+	} `yaml:"api_keys"`
+	JWT struct {
+		Issuer   string            `yaml:"issuer"`
+		Audience string            `yaml:"audience"`
+		Keys     map[string]string `yaml:"keys"`
+	} `yaml:"jwt"`
+}
+
+// auth is the authentication of the API, as read from an auth file.
+type auth struct {
+	// apiKeyHeader is the header carrying the API keys, also used to limit the
+	// requests of each key. Empty means rest.DefaultAPIKeyHeader.
+	apiKeyHeader string
+
+	// middlewares authenticate the callers and enforce the access policy.
+	middlewares []rest.Middleware
+}
+
+// loadAuth reads the credentials in the file at `path` and returns the
+// middlewares authenticating callers with them and enforcing the default
+// access policy.
+func loadAuth(path string) (*auth, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth file: %w", err)
+	}
+
+	var af authFile
+	if err := yaml.Unmarshal(data, &af); err != nil {
+		return nil, fmt.Errorf("failed to parse auth file: %w", err)
+	}
+
+	var authenticators rest.Authenticators
+
+	if len(af.APIKeys) > 0 {
+		keys := make(map[string]rest.Identity, len(af.APIKeys))
+		for key, id := range af.APIKeys {
+			keys[key] = rest.Identity{Subject: id.Subject, Roles: id.Roles}
+		}
+
+		authenticators = append(authenticators, &rest.StaticAPIKeys{Header: af.APIKeyHeader, Keys: keys})
+	}
+
+	if len(af.JWT.Keys) > 0 {
+		keys := make(map[string][]byte, len(af.JWT.Keys))
+		for kid, secret := range af.JWT.Keys {
+			key, err := base64.StdEncoding.DecodeString(secret)
+			if err != nil {
+				return nil, fmt.Errorf("invalid secret for JWT key '%s': %w", kid, err)
+			}
+			keys[kid] = key
+		}
+
+		authenticators = append(authenticators, &rest.JWTAuthenticator{
+			Keys:     keys,
+			Issuer:   af.JWT.Issuer,
+			Audience: af.JWT.Audience,
+		})
+	}
+
+	if len(authenticators) == 0 {
+		return nil, fmt.Errorf("auth file defines neither API keys nor JWT keys")
+	}
+
+	return &auth{
+		apiKeyHeader: af.APIKeyHeader,
+		middlewares: []rest.Middleware{
+			rest.Authenticate(authenticators),
+			rest.Authorize(rest.DefaultAccessPolicy()),
+		},
+	}, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository"
+)
+
+// Environment variables that can be used instead of command-line flags. When
+// both are given, the flag wins.
+const (
+	envAddr            = "CRM_ADDR"
+	envRepository      = "CRM_REPOSITORY"
+	envReadTimeout     = "CRM_READ_TIMEOUT"
+	envWriteTimeout    = "CRM_WRITE_TIMEOUT"
+	envIdleTimeout     = "CRM_IDLE_TIMEOUT"
+	envShutdownTimeout = "CRM_SHUTDOWN_TIMEOUT"
+	envRateLimitIP     = "CRM_RATE_LIMIT_IP"
+	envRateLimitAPIKey = "CRM_RATE_LIMIT_API_KEY"
+	envAuthFile        = "CRM_AUTH_FILE"
+)
+
+// config carries the settings of the CRM server.
+type config struct {
+	Addr       string
+	Repository string
+
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+
+	RateLimitPerIP     rest.Rate
+	RateLimitPerAPIKey rest.Rate
+
+	AuthFile string
+}
+
+// parseConfig builds the server configuration from the command-line arguments,
+// falling back to environment variables and then to the defaults.
+func parseConfig(args []string, getenv func(string) string) (*config, error) {
+	cfg := &config{}
+
+	fs := flag.NewFlagSet("crm-server", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	fs.StringVar(&cfg.Addr, "addr", envString(getenv, envAddr, ":8080"),
+		"address to listen on (env "+envAddr+")")
+	fs.StringVar(&cfg.Repository, "repository", envString(getenv, envRepository, repository.Reference),
+		"repository backend: "+strings.Join(repository.Backends, ", ")+" (env "+envRepository+")")
+
+	readTimeout, err := envDuration(getenv, envReadTimeout, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", readTimeout,
+		"maximum duration for reading an entire request (env "+envReadTimeout+")")
+
+	writeTimeout, err := envDuration(getenv, envWriteTimeout, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", writeTimeout,
+		"maximum duration before timing out writes of the response (env "+envWriteTimeout+")")
+
+	idleTimeout, err := envDuration(getenv, envIdleTimeout, 60*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", idleTimeout,
+		"maximum amount of time to wait for the next keep-alive request (env "+envIdleTimeout+")")
+
+	shutdownTimeout, err := envDuration(getenv, envShutdownTimeout, 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", shutdownTimeout,
+		"maximum duration to wait for in-flight requests on shutdown (env "+envShutdownTimeout+")")
+
+	var rateLimitIP, rateLimitAPIKey string
+	fs.StringVar(&rateLimitIP, "rate-limit-ip", getenv(envRateLimitIP),
+		"requests allowed per client IP, as <limit>/<period>, e.g. 10/1m; empty disables it (env "+envRateLimitIP+")")
+	fs.StringVar(&rateLimitAPIKey, "rate-limit-api-key", getenv(envRateLimitAPIKey),
+		"requests allowed per API key, as <limit>/<period>, e.g. 100/1m; empty disables it (env "+envRateLimitAPIKey+")")
+
+	fs.StringVar(&cfg.AuthFile, "auth-file", getenv(envAuthFile),
+		"YAML file with the accepted API keys and JWT keys; empty disables authentication (env "+envAuthFile+")")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if cfg.RateLimitPerIP, err = parseOptionalRate(rateLimitIP); err != nil {
+		return nil, err
+	}
+
+	if cfg.RateLimitPerAPIKey, err = parseOptionalRate(rateLimitAPIKey); err != nil {
+		return nil, err
+	}
+
+	if !repository.IsKnown(cfg.Repository) {
+		return nil, fmt.Errorf("unknown repository backend: '%s'", cfg.Repository)
+	}
+
+	return cfg, nil
+}
+
+// parseOptionalRate parses a rate limit, with an empty string meaning no
+// limit.
+func parseOptionalRate(s string) (rest.Rate, error) {
+	if s == "" {
+		return rest.Rate{}, nil
+	}
+
+	return rest.ParseRate(s)
+}
+
+// envString returns the value of the environment variable `key`, or `def` when
+// it is not set.
+func envString(getenv func(string) string, key, def string) string {
+	if val := getenv(key); val != "" {
+		return val
+	}
+
+	return def
+}
+
+// envDuration returns the duration held by the environment variable `key`, or
+// `def` when it is not set.
+func envDuration(getenv func(string) string, key string, def time.Duration) (time.Duration, error) {
+	val := getenv(key)
+	if val == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %w", key, err)
+	}
+
+	return d, nil
+}
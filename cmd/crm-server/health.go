@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository"
+)
+
+// readinessTimeout bounds the time spent checking the repository on each
+// readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// healthHandler answers liveness probes. It only tells that the process is up
+// and serving HTTP.
+func healthHandler(w http.ResponseWriter, _ *http.Request) {
+	writeStatus(w, http.StatusOK, "ok", "")
+}
+
+// readinessHandler answers readiness probes by checking that the repository
+// backend is able to serve requests.
+func readinessHandler(repo repository.Repository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		if err := repo.Ping(ctx); err != nil {
+			writeStatus(w, http.StatusServiceUnavailable, "unavailable", err.Error())
+			return
+		}
+
+		writeStatus(w, http.StatusOK, "ready", "")
+	}
+}
+
+// writeStatus is a helper function to write a JSON probe response.
+func writeStatus(w http.ResponseWriter, statusCode int, status, message string) {
+	body := map[string]string{"status": status}
+	if message != "" {
+		body["error"] = message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
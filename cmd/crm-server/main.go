@@ -0,0 +1,144 @@
+// Command crm-server serves the customer REST API over HTTP.
+//
+// The repository backend and the server timeouts are chosen through flags or
+// environment variables (see `crm-server -h`). Besides the customer routes,
+// the server exposes:
+//   - GET /healthz: liveness probe, always 200 while the process is serving.
+//   - GET /readyz : readiness probe, 200 only when the repository is usable.
+//
+// On SIGINT or SIGTERM the server stops accepting connections and waits for
+// in-flight requests to finish, up to the configured shutdown timeout.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if err := run(ctx, os.Args[1:], os.Getenv, logger); err != nil {
+		logger.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server and blocks until `ctx` is cancelled and the server is
+// shut down, or until the server fails.
+func run(ctx context.Context, args []string, getenv func(string) string, logger *slog.Logger) error {
+	cfg, err := parseConfig(args, getenv)
+	if err != nil {
+		return err
+	}
+
+	// The whole configuration is loaded before the listener is opened, so
+	// that a failure leaves the address free.
+	var apiAuth *auth
+	if cfg.AuthFile != "" {
+		if apiAuth, err = loadAuth(cfg.AuthFile); err != nil {
+			return err
+		}
+	} else {
+		logger.Warn("authentication disabled: every caller may register customers")
+	}
+
+	repo, closeRepo, err := repository.New(cfg.Repository)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := closeRepo(); err != nil {
+			logger.Error("failed to close repository", "error", err)
+		}
+	}()
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
+	server := newServer(cfg, repo, logger, apiAuth)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server started", "addr", listener.Addr().String(), "repository", cfg.Repository)
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	logger.Info("server stopped")
+
+	return nil
+}
+
+// newServer wires the customer REST API and the probe endpoints into an HTTP
+// server configured with the timeouts from `cfg`. The API routes are guarded by
+// `apiAuth`, unless nil; the probes are always public.
+func newServer(cfg *config, repo repository.Repository, logger *slog.Logger, apiAuth *auth) *http.Server {
+	if apiAuth == nil {
+		apiAuth = &auth{}
+	}
+
+	service := customer.NewCustomerService(repo)
+	middlewares := rest.DefaultMiddlewares(logger)
+	if cfg.RateLimitPerIP.Limit > 0 || cfg.RateLimitPerAPIKey.Limit > 0 {
+		middlewares = append(middlewares, rest.RateLimit(rest.RateLimitConfig{
+			PerIP:        cfg.RateLimitPerIP,
+			PerAPIKey:    cfg.RateLimitPerAPIKey,
+			APIKeyHeader: apiAuth.apiKeyHeader,
+		}))
+	}
+
+	middlewares = append(middlewares, apiAuth.middlewares...)
+
+	api := rest.NewCustomerRESTAPIHandler(service, middlewares...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", healthHandler)
+	mux.Handle("GET /readyz", readinessHandler(repo))
+	mux.Handle("/", api)
+
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	env := map[string]string{
+		envRepository:      "sqlite",
+		envReadTimeout:     "3s",
+		envRateLimitIP:     "10/1m",
+		envRateLimitAPIKey: "5/1s",
+	}
+
+	cfg, err := parseConfig([]string{"-addr", ":9090", "-rate-limit-api-key", "100/1m"}, func(key string) string { return env[key] })
+	require.NoError(t, err)
+	require.Equal(t, ":9090", cfg.Addr)
+	require.Equal(t, "sqlite", cfg.Repository)
+	require.Equal(t, "3s", cfg.ReadTimeout.String())
+	require.Equal(t, "10/1m0s", cfg.RateLimitPerIP.String())
+	require.Equal(t, "100/1m0s", cfg.RateLimitPerAPIKey.String())
+
+	_, err = parseConfig([]string{"-repository", "mongo"}, func(string) string { return "" })
+	require.ErrorContains(t, err, "unknown repository backend")
+
+	_, err = parseConfig([]string{"-rate-limit-ip", "lots"}, func(string) string { return "" })
+	require.ErrorContains(t, err, "invalid rate")
+}
+
+func TestProbes(t *testing.T) {
+	for _, backend := range repository.Backends {
+		t.Run(backend, func(t *testing.T) {
+			r := require.New(t)
+
+			repo, closeRepo, err := repository.New(backend)
+			r.NoError(err)
+
+			server := newServer(&config{}, repo, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+			for _, path := range []string{"/healthz", "/readyz"} {
+				recorder := httptest.NewRecorder()
+				server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+				r.Equal(http.StatusOK, recorder.Code, path)
+			}
+
+			recorder := httptest.NewRecorder()
+			body := strings.NewReader(`{"Name":"John Due","Email":"john.due@somecompany.com","Phone":"+1 234 567 890"}`)
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/customers", body))
+			r.Equal(http.StatusCreated, recorder.Code)
+
+			r.NoError(closeRepo())
+
+			// The reference repository has nothing to close.
+			if backend == repository.Reference {
+				return
+			}
+
+			recorder = httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			r.Equal(http.StatusServiceUnavailable, recorder.Code)
+
+			recorder = httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			r.Equal(http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func TestAuthFile(t *testing.T) {
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	r.NoError(os.WriteFile(path, []byte(`
+api_keys:
+  writer-key: {subject: erp, roles: [crm:write]}
+  reader-key: {subject: bi, roles: [crm:read]}
+`), 0o600))
+
+	apiAuth, err := loadAuth(path)
+	r.NoError(err)
+
+	repo, _, err := repository.New(repository.Reference)
+	r.NoError(err)
+
+	server := newServer(&config{}, repo, slog.New(slog.NewTextHandler(io.Discard, nil)), apiAuth)
+
+	for apiKey, status := range map[string]int{
+		"":           http.StatusUnauthorized,
+		"bogus":      http.StatusUnauthorized,
+		"reader-key": http.StatusForbidden,
+		"writer-key": http.StatusCreated,
+	} {
+		body := strings.NewReader(`{"Name":"John Due","Email":"john.due@somecompany.com","Phone":"+1 234 567 890"}`)
+		req := httptest.NewRequest(http.MethodPost, "/customers", body)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, req)
+		r.Equal(status, recorder.Code, apiKey)
+	}
+
+	// Probes stay public.
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	r.Equal(http.StatusOK, recorder.Code)
+}
+
+func TestAuthFileAPIKeyHeader(t *testing.T) {
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "auth.yaml")
+	r.NoError(os.WriteFile(path, []byte(`
+api_key_header: X-Client-Key
+api_keys:
+  erp-key: {subject: erp, roles: [crm:write]}
+  crm-key: {subject: crm, roles: [crm:write]}
+`), 0o600))
+
+	apiAuth, err := loadAuth(path)
+	r.NoError(err)
+
+	repo, _, err := repository.New(repository.Reference)
+	r.NoError(err)
+
+	cfg := &config{RateLimitPerAPIKey: rest.Rate{Limit: 1, Period: time.Hour}}
+	server := newServer(cfg, repo, slog.New(slog.NewTextHandler(io.Discard, nil)), apiAuth)
+
+	// The keys are both authenticated and rate limited from the custom header.
+	for _, call := range []struct {
+		apiKey string
+		status int
+	}{
+		{"erp-key", http.StatusCreated},
+		{"erp-key", http.StatusTooManyRequests},
+		{"crm-key", http.StatusConflict},
+	} {
+		body := strings.NewReader(`{"Name":"John Due","Email":"john.due@somecompany.com","Phone":"+1 234 567 890"}`)
+		req := httptest.NewRequest(http.MethodPost, "/customers", body)
+		req.Header.Set("X-Client-Key", call.apiKey)
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, req)
+		r.Equal(call.status, recorder.Code, call.apiKey)
+	}
+}
+
+// eventHandler is a slog.Handler passing the messages it logs, with their
+// `addr` attribute if any, to `events`.
+type eventHandler struct {
+	events chan event
+}
+
+// event is a message logged by the server.
+type event struct {
+	msg  string
+	addr string
+}
+
+func (h eventHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h eventHandler) Handle(_ context.Context, rec slog.Record) error {
+	e := event{msg: rec.Message}
+	rec.Attrs(func(a slog.Attr) bool {
+		if a.Key == "addr" {
+			e.addr = a.Value.String()
+		}
+		return true
+	})
+
+	h.events <- e
+
+	return nil
+}
+
+func (h eventHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h eventHandler) WithGroup(string) slog.Handler { return h }
+
+// waitFor waits for the server to log `msg`, and returns the event.
+func (h eventHandler) waitFor(t *testing.T, msg string) event {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-h.events:
+			if e.msg == msg {
+				return e
+			}
+
+		case <-timeout:
+			t.Fatalf("the server did not log '%s'", msg)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	t.Run("should finish the in-flight requests on shutdown", func(t *testing.T) {
+		r := require.New(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		handler := eventHandler{events: make(chan event, 100)}
+
+		stopped := make(chan error, 1)
+		go func() { stopped <- run(ctx, []string{"-addr", "127.0.0.1:0"}, noEnv, slog.New(handler)) }()
+
+		addr := handler.waitFor(t, "server started").addr
+
+		// The request body is sent in two parts, the second one once the
+		// shutdown has begun.
+		body, bodyWriter := io.Pipe()
+
+		responses := make(chan *http.Response, 1)
+		go func() {
+			resp, err := http.Post("http://"+addr+"/customers", "application/json", body)
+			if err != nil {
+				t.Errorf("the in-flight request failed: %v", err)
+				close(responses)
+				return
+			}
+			responses <- resp
+		}()
+
+		_, err := bodyWriter.Write([]byte(`{"Name":"John Due","Email":"john.due@somecompany.com",`))
+		r.NoError(err)
+
+		// Gives the server the time to accept the connection.
+		time.Sleep(100 * time.Millisecond)
+
+		cancel()
+		handler.waitFor(t, "shutting down")
+
+		_, err = bodyWriter.Write([]byte(`"Phone":"+1 234 567 890"}`))
+		r.NoError(err)
+		r.NoError(bodyWriter.Close())
+
+		resp, ok := <-responses
+		r.True(ok)
+		defer resp.Body.Close()
+		r.Equal(http.StatusCreated, resp.StatusCode)
+
+		select {
+		case err := <-stopped:
+			r.NoError(err, "the server should exit with status 0")
+
+		case <-time.After(5 * time.Second):
+			t.Fatal("the server did not stop")
+		}
+
+		_, err = http.Get("http://" + addr + "/healthz")
+		r.Error(err, "the server should not accept connections anymore")
+	})
+
+	t.Run("should fail when it can't listen", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		err = run(context.Background(), []string{"-addr", listener.Addr().String()}, noEnv,
+			slog.New(slog.NewTextHandler(io.Discard, nil)))
+		require.ErrorContains(t, err, "failed to listen on "+listener.Addr().String())
+	})
+
+	t.Run("should leave the address free when the auth file is invalid", func(t *testing.T) {
+		r := require.New(t)
+
+		path := filepath.Join(t.TempDir(), "auth.yaml")
+		r.NoError(os.WriteFile(path, []byte("api_keys: [not, a, map]\n"), 0o600))
+
+		// Reserves a free port, released for run to listen on it.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		r.NoError(err)
+		addr := listener.Addr().String()
+		r.NoError(listener.Close())
+
+		err = run(context.Background(), []string{"-addr", addr, "-auth-file", path}, noEnv,
+			slog.New(slog.NewTextHandler(io.Discard, nil)))
+		r.Error(err)
+		r.NotContains(err.Error(), "failed to listen")
+
+		listener, err = net.Listen("tcp", addr)
+		r.NoError(err, "the address should be free again")
+		r.NoError(listener.Close())
+	})
+}
@@ -0,0 +1,98 @@
+// Command diff-repositories feeds the same stream of operations to several
+// repository backends, and reports where they diverge from the first one:
+//
+//	diff-repositories -backends reference,sqlite,badger operations.yaml
+//	diff-repositories -backends reference,sqlite -random 100 -seed 42
+//
+// The operations are read from the given YAML or JSON files, or drawn at
+// random. Every divergence is printed on its own line. The command exits with
+// status 1 when the backends diverge and 2 on usage errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository"
+	"github.com/maniosgrivei/go-test-drivers/test/differential"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run compares the backends on the operations selected by `args`, and returns
+// the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff-repositories", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: diff-repositories [-backends <name,...>] (-random <n> [-seed <seed>] | <operations.yaml>...)")
+		fs.PrintDefaults()
+	}
+
+	backends := fs.String("backends", "reference,sqlite,badger", "comma-separated backends, the first one being the baseline")
+	random := fs.Int("random", 0, "number of random operations to draw instead of reading files")
+	seed := fs.Uint64("seed", 0, "seed of the random operations, random by default")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if (*random > 0) == (fs.NArg() > 0) {
+		fs.Usage()
+		return 2
+	}
+
+	var ops []differential.Operation
+	if *random > 0 {
+		if *seed == 0 {
+			*seed = uint64(time.Now().UnixNano())
+		}
+		fmt.Fprintf(stdout, "drawing %d operations with seed %d\n", *random, *seed)
+		ops = differential.GenerateOperations(rand.New(rand.NewPCG(*seed, 0)), *random)
+	}
+
+	for _, path := range fs.Args() {
+		loaded, err := differential.LoadOperations(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		ops = append(ops, loaded...)
+	}
+
+	var targets []differential.Target
+	for _, name := range strings.Split(*backends, ",") {
+		repo, closeFn, err := repository.New(name)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		defer closeFn()
+
+		targets = append(targets, differential.Target{Name: name, Repository: repo})
+	}
+
+	report, err := differential.Run(targets, ops)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	for _, d := range report.Divergences {
+		fmt.Fprintln(stdout, d)
+	}
+	fmt.Fprintf(stdout, "%d operations, %d divergences from %s\n", report.Steps, len(report.Divergences), report.Baseline)
+
+	if len(report.Divergences) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("should find no divergence between the backends", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"-random", "50", "-seed", "7"}, &stdout, &stderr)
+
+		r.Equal(0, status, stdout.String()+stderr.String())
+		r.Equal("drawing 50 operations with seed 7\n50 operations, 0 divergences from reference\n", stdout.String())
+	})
+
+	t.Run("should read the operations from files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "operations.yaml")
+		r.NoError(os.WriteFile(path, []byte(
+			"- save: {id: JHND-1, name: John Doe, email: john@doe.com, phone: \"+1 555 0001\"}\n"+
+				"- save: {id: JHND-1, name: John Doe, email: john@doe.com, phone: \"+1 555 0001\"}\n",
+		), 0o600))
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"-backends", "sqlite,reference", path}, &stdout, &stderr)
+
+		r.Equal(0, status, stdout.String()+stderr.String())
+		r.Equal("2 operations, 0 divergences from sqlite\n", stdout.String())
+	})
+
+	t.Run("should fail on usage errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		r.Equal(2, run(nil, &stdout, &stderr))
+		r.Contains(stderr.String(), "usage: diff-repositories")
+
+		stderr.Reset()
+		r.Equal(2, run([]string{"-backends", "reference,mongo", "-random", "1"}, &stdout, &stderr))
+		r.Equal("unknown repository backend: 'mongo'\n", stderr.String())
+	})
+}
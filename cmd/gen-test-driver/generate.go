@@ -0,0 +1,753 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Generation modes.
+const (
+	modeSkeleton = "skeleton"
+	modeDelegate = "delegate"
+	modeUpper    = "upper"
+)
+
+// config describes the code to generate.
+type config struct {
+	src        string
+	iface      string
+	typ        string
+	mode       string
+	field      string
+	receiver   string
+	pkg        string
+	importPath string
+	skip       []string
+}
+
+// generate returns the formatted code described by `cfg`.
+func generate(cfg config) ([]byte, error) {
+	switch cfg.mode {
+	case modeSkeleton:
+	case modeDelegate, modeUpper:
+		if cfg.field == "" {
+			return nil, fmt.Errorf("the %s mode needs a -field", cfg.mode)
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode '%s'", cfg.mode)
+	}
+
+	src, err := parsePackage(cfg.src)
+	if err != nil {
+		return nil, err
+	}
+
+	iface, err := src.findInterface(cfg.iface)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.pkg == "" {
+		cfg.pkg = src.name
+	}
+
+	g := &generator{cfg: cfg, src: src, file: iface.file, imports: map[string]string{}}
+
+	if g.qualified() && cfg.importPath == "" {
+		if g.cfg.importPath, err = importPathOf(cfg.src); err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+
+	if cfg.mode == modeSkeleton {
+		g.writeSkeletonType(&body)
+	}
+
+	for _, m := range iface.methods {
+		if slices.Contains(cfg.skip, m.name) {
+			continue
+		}
+
+		g.writeMethod(&body, m)
+	}
+
+	var out bytes.Buffer
+
+	if cfg.mode == modeDelegate {
+		fmt.Fprintln(&out, "// Code generated by gen-test-driver; DO NOT EDIT.")
+		fmt.Fprintln(&out)
+	}
+
+	fmt.Fprintln(&out, "//go:build test")
+	fmt.Fprintln(&out)
+	fmt.Fprintf(&out, "package %s\n\n", cfg.pkg)
+	g.writeImports(&out)
+	out.Write(body.Bytes())
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return out.Bytes(), fmt.Errorf("invalid generated code: %w", err)
+	}
+
+	return code, nil
+}
+
+//
+// Source Package
+
+// sourcePackage is the package declaring the interface.
+type sourcePackage struct {
+	name  string
+	fset  *token.FileSet
+	files []*ast.File
+	types map[string]bool
+}
+
+// parsePackage parses the Go files of the package in `dir`, whatever their
+// build tags, except the tests.
+func parsePackage(dir string) (*sourcePackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &sourcePackage{fset: token.NewFileSet(), types: map[string]bool{}}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(p.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		if p.name == "" {
+			p.name = f.Name.Name
+		}
+
+		p.files = append(p.files, f)
+
+		for _, decl := range f.Decls {
+			if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+				for _, spec := range gd.Specs {
+					p.types[spec.(*ast.TypeSpec).Name.Name] = true
+				}
+			}
+		}
+	}
+
+	if len(p.files) == 0 {
+		return nil, fmt.Errorf("no Go file in '%s'", dir)
+	}
+
+	return p, nil
+}
+
+// sourceInterface is the interface to generate the code from.
+type sourceInterface struct {
+	file    *ast.File
+	methods []sourceMethod
+}
+
+// sourceMethod is a method of the interface.
+type sourceMethod struct {
+	name string
+	typ  *ast.FuncType
+
+	// section is the text of the free comment preceding the method, such as
+	// `Arrange`, if any.
+	section string
+	doc     *ast.CommentGroup
+}
+
+// findInterface finds the interface `name` in the package.
+func (p *sourcePackage) findInterface(name string) (*sourceInterface, error) {
+	for _, f := range p.files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+
+				it, ok := ts.Type.(*ast.InterfaceType)
+				if !ok {
+					return nil, fmt.Errorf("'%s' is not an interface", name)
+				}
+
+				return interfaceMethods(f, it)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("interface '%s' not found in package '%s'", name, p.name)
+}
+
+// interfaceMethods lists the methods of `it`, declared in `f`.
+func interfaceMethods(f *ast.File, it *ast.InterfaceType) (*sourceInterface, error) {
+	si := &sourceInterface{file: f}
+
+	// Free comments of the interface body, i.e. not method docs.
+	var free []*ast.CommentGroup
+	for _, cg := range f.Comments {
+		if cg.Pos() < it.Methods.Opening || cg.End() > it.Methods.Closing {
+			continue
+		}
+
+		if !slices.ContainsFunc(it.Methods.List, func(m *ast.Field) bool { return m.Doc == cg || m.Comment == cg }) {
+			free = append(free, cg)
+		}
+	}
+
+	for _, m := range it.Methods.List {
+		ft, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return nil, fmt.Errorf("embedded interfaces are not supported")
+		}
+
+		sm := sourceMethod{name: m.Names[0].Name, typ: ft, doc: m.Doc}
+
+		// The section is the last free comment before the method.
+		for len(free) > 0 && free[0].End() < m.Pos() {
+			sm.section = strings.TrimSpace(free[0].Text())
+			free = free[1:]
+		}
+
+		si.methods = append(si.methods, sm)
+	}
+
+	return si, nil
+}
+
+// importPathOf computes the import path of the package in `dir` from the
+// go.mod file of its module.
+func importPathOf(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for root := abs; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			module := modulePath(data)
+			if module == "" {
+				return "", fmt.Errorf("no module path in %s", filepath.Join(root, "go.mod"))
+			}
+
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", err
+			}
+
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(root) == root {
+			return "", fmt.Errorf("no go.mod found above '%s'; use -import", dir)
+		}
+	}
+}
+
+// modulePath extracts the module path of a go.mod file.
+func modulePath(gomod []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(gomod))
+	for s.Scan() {
+		if module, found := strings.CutPrefix(strings.TrimSpace(s.Text()), "module "); found {
+			return strings.Trim(strings.TrimSpace(module), `"`)
+		}
+	}
+
+	return ""
+}
+
+//
+// Generator
+
+// generator renders the code of the methods, collecting the imports they need.
+type generator struct {
+	cfg  config
+	src  *sourcePackage
+	file *ast.File
+
+	// imports maps the names of the packages used by the generated code to
+	// their import paths.
+	imports map[string]string
+}
+
+// qualified checks if the types of the interface package must be qualified,
+// i.e. if the code is generated for another package.
+func (g *generator) qualified() bool {
+	return g.cfg.pkg != g.src.name
+}
+
+// writeSkeletonType writes the type implementing the interface.
+func (g *generator) writeSkeletonType(w *bytes.Buffer) {
+	iface := g.cfg.iface
+	if g.qualified() {
+		iface = g.src.name + "." + iface
+		g.imports[g.src.name] = g.cfg.importPath
+	}
+
+	fmt.Fprintf(w, "// %s implements %s.\n", g.cfg.typ, iface)
+	fmt.Fprintf(w, "type %s struct{}\n\n", g.cfg.typ)
+	fmt.Fprintf(w, "// Ensure %s implements the %s interface.\n", g.cfg.typ, g.cfg.iface)
+	fmt.Fprintf(w, "var _ %s = (*%s)(nil)\n\n", iface, g.cfg.typ)
+	fmt.Fprintf(w, "// New%s creates a new instance of %s.\n", g.cfg.typ, g.cfg.typ)
+	fmt.Fprintf(w, "func New%s() *%s {\nreturn &%s{}\n}\n\n", g.cfg.typ, g.cfg.typ, g.cfg.typ)
+}
+
+// param is a parameter of a generated method.
+type param struct {
+	name, typ string
+	variadic  bool
+}
+
+// testingTypeRegexp matches the types of the `t` parameters.
+var testingTypeRegexp = regexp.MustCompile(`^(\*testing\.T|testing\.TB)$`)
+
+// writeMethod writes the method `m`.
+func (g *generator) writeMethod(w *bytes.Buffer, m sourceMethod) {
+	params := g.params(m.typ)
+
+	results := g.results(m.typ)
+
+	if m.section != "" {
+		fmt.Fprintf(w, "//\n// %s\n\n", m.section)
+	}
+
+	if m.doc != nil {
+		for _, c := range m.doc.List {
+			fmt.Fprintln(w, c.Text)
+		}
+	}
+
+	decls := make([]string, len(params))
+	args := make([]string, len(params))
+	tName := ""
+
+	for i, p := range params {
+		decls[i] = p.name + " " + p.typ
+		args[i] = p.name
+
+		if p.variadic {
+			args[i] += "..."
+		}
+
+		if tName == "" && testingTypeRegexp.MatchString(p.typ) {
+			tName = p.name
+		}
+	}
+
+	fmt.Fprintf(w, "func (%s *%s) %s(%s) %s {\n", g.cfg.receiver, g.cfg.typ, m.name, strings.Join(decls, ", "), results)
+
+	if tName != "" {
+		fmt.Fprintf(w, "%s.Helper()\n\n", tName)
+	}
+
+	call := fmt.Sprintf("%s.%s.%s(%s)", g.cfg.receiver, g.cfg.field, m.name, strings.Join(args, ", "))
+	notImplemented := g.notImplemented(m, tName)
+
+	switch g.cfg.mode {
+	case modeSkeleton:
+		fmt.Fprintln(w, notImplemented)
+
+	case modeDelegate:
+		if results != "" {
+			fmt.Fprintf(w, "return %s\n", call)
+		} else {
+			fmt.Fprintln(w, call)
+		}
+
+	case modeUpper:
+		fmt.Fprintf(w, "if %s.%s != nil {\n", g.cfg.receiver, g.cfg.field)
+		if results != "" {
+			fmt.Fprintf(w, "return %s\n", call)
+		} else {
+			fmt.Fprintf(w, "%s\n\nreturn\n", call)
+		}
+		fmt.Fprintf(w, "}\n\n")
+		fmt.Fprintln(w, notImplemented)
+	}
+
+	fmt.Fprintf(w, "}\n\n")
+}
+
+// notImplemented returns the body of a method `m` not implemented by the
+// type: a failure of the test for the skeletons, or a skip for the layers not
+// supporting the method without an upper layer, followed by a return of the
+// zero values. The methods without a `t` parameter panic instead.
+func (g *generator) notImplemented(m sourceMethod, tName string) string {
+	msg := g.cfg.typ + "." + m.name + " is not implemented"
+	if g.cfg.mode == modeUpper {
+		msg += " without an upper layer"
+	}
+
+	if tName == "" {
+		return fmt.Sprintf("panic(%s)", strconv.Quote(msg))
+	}
+
+	stop := "Fatal"
+	if g.cfg.mode == modeUpper {
+		stop = "Skip"
+	}
+
+	body := fmt.Sprintf("%s.%s(%s)", tName, stop, strconv.Quote(msg))
+
+	if ret, ok := g.zeroReturn(m.typ); ok {
+		body += "\n\n" + ret
+	}
+
+	return body
+}
+
+// zeroReturn returns the statement returning the zero values of the results
+// of `ft`, if it has results.
+func (g *generator) zeroReturn(ft *ast.FuncType) (string, bool) {
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return "", false
+	}
+
+	var zeros []string
+
+	for _, f := range ft.Results.List {
+		if len(f.Names) > 0 {
+			return "return", true
+		}
+
+		zeros = append(zeros, g.zero(g.qualify(f.Type).(ast.Expr)))
+	}
+
+	return "return " + strings.Join(zeros, ", "), true
+}
+
+// zero returns the zero value of the type `typ`.
+func (g *generator) zero(typ ast.Expr) string {
+	switch t := typ.(type) {
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
+		return "nil"
+
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return "nil"
+		}
+
+		return g.render(t) + "{}"
+
+	case *ast.StructType:
+		return g.render(t) + "{}"
+
+	case *ast.Ident:
+		switch t.Name {
+		case "error", "any":
+			return "nil"
+		case "string":
+			return `""`
+		case "bool":
+			return "false"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+			"float32", "float64", "complex64", "complex128", "byte", "rune":
+			return "0"
+		}
+	}
+
+	return "*new(" + g.render(typ) + ")"
+}
+
+// params returns the parameters of `ft`, keeping the names of the source and
+// naming the unnamed ones after their types.
+func (g *generator) params(ft *ast.FuncType) []param {
+	var params []param
+
+	taken := map[string]bool{g.cfg.receiver: true}
+	if g.qualified() {
+		taken[g.src.name] = true
+	}
+	for _, field := range ft.Params.List {
+		for _, n := range field.Names {
+			taken[n.Name] = true
+		}
+	}
+
+	for _, field := range ft.Params.List {
+		typ := field.Type
+		variadic := false
+
+		if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+			typ = ellipsis.Elt
+			variadic = true
+		}
+
+		rendered := g.render(g.qualify(typ))
+		if variadic {
+			rendered = "..." + rendered
+		}
+
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+
+		for _, n := range names {
+			name := n.Name
+			if name == "_" {
+				name = paramName(typ, testingTypeRegexp.MatchString(rendered), variadic, taken)
+			}
+
+			params = append(params, param{name: name, typ: rendered, variadic: variadic})
+		}
+	}
+
+	return params
+}
+
+// paramName returns a name for an unnamed parameter of type `typ`, not in
+// `taken`, and marks it as taken: `t` for the testing parameters, and a name
+// after the type otherwise.
+func paramName(typ ast.Expr, testing, variadic bool, taken map[string]bool) string {
+	base := "t"
+	if !testing {
+		base = typeParamName(typ, variadic)
+	}
+
+	name := base
+	for i := 2; taken[name] || token.IsKeyword(name); i++ {
+		name = fmt.Sprintf("%s%d", base, i)
+	}
+
+	taken[name] = true
+
+	return name
+}
+
+// typeParamName names a parameter of type `typ` after the type: in lower camel
+// case for the named types, e.g. `widget` for `*Widget`, pluralized for the
+// slices, after the first letter of the predeclared types, e.g. `s` for
+// `string`, and `m` for the maps.
+func typeParamName(typ ast.Expr, plural bool) string {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+
+		case *ast.ArrayType:
+			typ, plural = t.Elt, true
+
+		case *ast.SelectorExpr:
+			typ = t.Sel
+
+		case *ast.Ident:
+			if !ast.IsExported(t.Name) {
+				return t.Name[:1]
+			}
+
+			name := strings.ToLower(t.Name[:1]) + t.Name[1:]
+			if plural {
+				name += "s"
+			}
+
+			return name
+
+		case *ast.MapType:
+			return "m"
+
+		default:
+			return "p"
+		}
+	}
+}
+
+// results returns the result list of `ft`, as written in a signature.
+func (g *generator) results(ft *ast.FuncType) string {
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return ""
+	}
+
+	var (
+		fields []string
+		named  bool
+	)
+
+	for _, f := range ft.Results.List {
+		typ := g.render(g.qualify(f.Type))
+		if len(f.Names) == 0 {
+			fields = append(fields, typ)
+			continue
+		}
+
+		named = true
+		for _, n := range f.Names {
+			fields = append(fields, n.Name+" "+typ)
+		}
+	}
+
+	if len(fields) == 1 && !named {
+		return fields[0]
+	}
+
+	return "(" + strings.Join(fields, ", ") + ")"
+}
+
+// qualify returns a copy of the type expression `node` with the types of the
+// interface package qualified when needed, recording the imports it uses.
+func (g *generator) qualify(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.Ident:
+		if g.qualified() && g.src.types[n.Name] && ast.IsExported(n.Name) {
+			g.imports[g.src.name] = g.cfg.importPath
+			return &ast.SelectorExpr{X: ast.NewIdent(g.src.name), Sel: ast.NewIdent(n.Name)}
+		}
+
+	case *ast.SelectorExpr:
+		if x, ok := n.X.(*ast.Ident); ok {
+			if p := g.fileImport(x.Name); p != "" {
+				g.imports[x.Name] = p
+			}
+		}
+
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: g.qualify(n.X).(ast.Expr)}
+
+	case *ast.ArrayType:
+		c := *n
+		c.Elt = g.qualify(n.Elt).(ast.Expr)
+		return &c
+
+	case *ast.MapType:
+		return &ast.MapType{Key: g.qualify(n.Key).(ast.Expr), Value: g.qualify(n.Value).(ast.Expr)}
+
+	case *ast.ChanType:
+		c := *n
+		c.Value = g.qualify(n.Value).(ast.Expr)
+		return &c
+
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: g.qualify(n.Elt).(ast.Expr)}
+
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: g.qualify(n.X).(ast.Expr), Index: g.qualify(n.Index).(ast.Expr)}
+
+	case *ast.FuncType:
+		return &ast.FuncType{Params: g.qualifyFieldList(n.Params), Results: g.qualifyFieldList(n.Results)}
+	}
+
+	return node
+}
+
+// qualifyFieldList qualifies the types of a parameter or result list.
+func (g *generator) qualifyFieldList(fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+
+	c := &ast.FieldList{}
+	for _, f := range fl.List {
+		c.List = append(c.List, &ast.Field{Names: f.Names, Type: g.qualify(f.Type).(ast.Expr)})
+	}
+
+	return c
+}
+
+// fileImport returns the import path of the package named `name` in the file
+// declaring the interface, or "".
+func (g *generator) fileImport(name string) string {
+	for _, imp := range g.file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		if imp.Name != nil && imp.Name.Name == name || imp.Name == nil && defaultImportName(p) == name {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// render prints a type expression.
+func (g *generator) render(node ast.Node) string {
+	var b bytes.Buffer
+	_ = printer.Fprint(&b, token.NewFileSet(), node)
+
+	return b.String()
+}
+
+// writeImports writes the import declaration of the generated file, the
+// standard library first.
+func (g *generator) writeImports(w *bytes.Buffer) {
+	if len(g.imports) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(a, b string) int { return strings.Compare(g.imports[a], g.imports[b]) })
+
+	fmt.Fprintln(w, "import (")
+
+	for _, std := range []bool{true, false} {
+		group := slices.DeleteFunc(slices.Clone(names), func(name string) bool { return isStd(g.imports[name]) != std })
+		if len(group) == 0 {
+			continue
+		}
+
+		if !std && len(group) < len(names) {
+			fmt.Fprintln(w)
+		}
+
+		for _, name := range group {
+			p := g.imports[name]
+			if defaultImportName(p) == name {
+				fmt.Fprintf(w, "%q\n", p)
+			} else {
+				fmt.Fprintf(w, "%s %q\n", name, p)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, ")\n\n")
+}
+
+// isStd checks if `p` is the import path of a standard library package, i.e.
+// if its first element has no dot.
+func isStd(p string) bool {
+	first, _, _ := strings.Cut(p, "/")
+	return !strings.Contains(first, ".")
+}
+
+// majorVersionRegexp matches the major version suffixes of module paths.
+var majorVersionRegexp = regexp.MustCompile(`^v[0-9]+$`)
+
+// defaultImportName guesses the name of the package imported from `p`: its
+// last element, skipping major version suffixes such as `/v4`.
+func defaultImportName(p string) string {
+	base := path.Base(p)
+	if majorVersionRegexp.MatchString(base) {
+		base = path.Base(path.Dir(p))
+	}
+
+	return base
+}
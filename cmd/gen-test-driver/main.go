@@ -0,0 +1,91 @@
+// Command gen-test-driver writes the boilerplate of the test drivers from the
+// test driver interfaces, such as customer.CustomerRepositoryTestDriver. It is
+// meant to be run by `go generate`:
+//
+//	//go:generate go run github.com/maniosgrivei/go-test-drivers/cmd/gen-test-driver -src ../../.. -interface CustomerRepositoryTestDriver -type FooCustomerRepositoryTestDriver -o customer_repository_test_driver.go
+//
+// It has three modes:
+//   - skeleton: a new implementation of the interface, with the `var _`
+//     assertion and a method failing the test as not implemented for each
+//     method of the interface. This is the default.
+//   - delegate: methods forwarding every call to the `-field` of the `-type`,
+//     as the service test drivers do with the repository test drivers.
+//   - upper: methods short-circuiting to the `-field` of the `-type` when it is
+//     set, as the service test drivers do with the upper layer test drivers,
+//     and skipping the test as not implemented otherwise.
+//
+// The methods keep the parameter names of the interface, the unnamed ones
+// being named after their types. Every method taking a `*testing.T` or a
+// `testing.TB` calls `t.Helper()` first; the other ones panic when not
+// implemented. The files are written with the `test` build tag. Only the delegate
+// mode marks them as generated: the other modes are starting points.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Getenv, os.Stdout, os.Stderr))
+}
+
+// run generates the code described by `args` and returns the exit status.
+func run(args []string, getenv func(string) string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("gen-test-driver", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gen-test-driver -interface <name> -type <name> [flags]")
+		fs.PrintDefaults()
+	}
+
+	var cfg config
+
+	fs.StringVar(&cfg.src, "src", ".", "directory of the package declaring the interface")
+	fs.StringVar(&cfg.iface, "interface", "", "name of the test driver interface")
+	fs.StringVar(&cfg.typ, "type", "", "name of the type implementing the methods")
+	fs.StringVar(&cfg.mode, "mode", modeSkeleton, "skeleton, delegate or upper")
+	fs.StringVar(&cfg.field, "field", "", "field of the type holding the test driver to forward to (delegate and upper modes)")
+	fs.StringVar(&cfg.receiver, "receiver", "td", "name of the method receivers")
+	fs.StringVar(&cfg.pkg, "package", getenv("GOPACKAGE"), "package of the generated file (default $GOPACKAGE, or the one of the interface)")
+	fs.StringVar(&cfg.importPath, "import", "", "import path of the interface package (default from go.mod)")
+	skip := fs.String("skip", "", "comma separated methods not to generate, e.g. the hand-written ones")
+	output := fs.String("o", "", "output file (default stdout)")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if cfg.iface == "" || cfg.typ == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	for _, m := range strings.Split(*skip, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			cfg.skip = append(cfg.skip, m)
+		}
+	}
+
+	code, err := generate(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, "gen-test-driver:", err)
+		return 1
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(code)
+	} else {
+		err = os.WriteFile(*output, code, 0o644)
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, "gen-test-driver:", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestRun(t *testing.T) {
+	noEnv := func(string) string { return "" }
+
+	for _, tc := range []struct {
+		golden string
+		args   []string
+	}{
+		{
+			golden: "skeleton.golden",
+			args:   []string{"-interface", "WidgetStoreTestDriver", "-type", "MemoryWidgetStoreTestDriver", "-package", "memory"},
+		},
+		{
+			golden: "delegate.golden",
+			args: []string{
+				"-interface", "WidgetStoreTestDriver", "-type", "WidgetServiceTestDriver",
+				"-mode", "delegate", "-field", "storeTD", "-skip", "ActNow",
+			},
+		},
+		{
+			golden: "upper.golden",
+			args: []string{
+				"-interface", "WidgetStoreTestDriver", "-type", "WidgetServiceTestDriver",
+				"-mode", "upper", "-field", "upperLayerTD", "-receiver", "d",
+			},
+		},
+	} {
+		t.Run("should generate "+tc.golden, func(t *testing.T) {
+			r := require.New(t)
+
+			var stdout, stderr bytes.Buffer
+			status := run(append([]string{"-src", "testdata/widget", "-import", "example.com/widget"}, tc.args...), noEnv, &stdout, &stderr)
+			r.Equal(0, status, stderr.String())
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				r.NoError(os.WriteFile(golden, stdout.Bytes(), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			r.NoError(err)
+			r.Equal(string(expected), stdout.String())
+		})
+	}
+
+	t.Run("should generate the customer test drivers", func(t *testing.T) {
+		r := require.New(t)
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{
+			"-src", "../../customer", "-interface", "CustomerRepositoryTestDriver", "-type", "FooCustomerRepositoryTestDriver",
+		}, func(string) string { return "foo" }, &stdout, &stderr)
+
+		r.Equal(0, status, stderr.String())
+		r.Contains(stdout.String(), `"github.com/maniosgrivei/go-test-drivers/customer"`)
+		r.Contains(stdout.String(), "var _ customer.CustomerRepositoryTestDriver = (*FooCustomerRepositoryTestDriver)(nil)")
+	})
+
+	t.Run("should fail on unknown interfaces", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"-src", "testdata/widget", "-interface", "Gadget", "-type", "T"}, noEnv, &stdout, &stderr)
+
+		require.Equal(t, 1, status)
+		require.Contains(t, stderr.String(), "interface 'Gadget' not found in package 'widget'")
+	})
+
+	t.Run("should fail on usage errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, 2, run([]string{"-interface", "WidgetStoreTestDriver"}, noEnv, &stdout, &stderr))
+		require.Contains(t, stderr.String(), "usage: gen-test-driver")
+	})
+}
+
+func TestParamName(t *testing.T) {
+	for typ, expected := range map[string]string{
+		"*testing.T":  "t",
+		"testing.TB":  "t2",
+		"*Widget":     "widget",
+		"[]*Widget":   "widgets",
+		"time.Time":   "time",
+		"string":      "s",
+		"map[int]int": "m",
+		"Type":        "type2",
+		"func()":      "p",
+	} {
+		t.Run(typ, func(t *testing.T) {
+			expr, err := parser.ParseExpr(typ)
+			require.NoError(t, err)
+
+			taken := map[string]bool{"t": typ == "testing.TB"}
+			isTesting := testingTypeRegexp.MatchString(typ)
+
+			require.Equal(t, expected, paramName(expr, isTesting, false, taken))
+			require.True(t, taken[expected])
+		})
+	}
+}
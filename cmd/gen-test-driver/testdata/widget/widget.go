@@ -0,0 +1,36 @@
+// Package widget is the input of the gen-test-driver tests.
+package widget
+
+import (
+	"testing"
+	"time"
+)
+
+// Widget is a stored item.
+type Widget struct {
+	Name string
+}
+
+// WidgetStoreTestDriver is a test driver for a widget store.
+type WidgetStoreTestDriver interface {
+	//
+	// Arrange
+
+	// ArrangeSomeWidgetsAreStored stores the given widgets.
+	ArrangeSomeWidgetsAreStored(t testing.TB, widgets ...*Widget)
+
+	//
+	// Act
+
+	// ActTryToFindAWidget looks for the widget named `name`.
+	ActTryToFindAWidget(t *testing.T, name string) (*Widget, error)
+
+	// ActNow returns the time of the store.
+	ActNow(t *testing.T) (now time.Time)
+
+	//
+	// Assert
+
+	// AssertWidgetsShouldBeStored asserts that the widgets are stored.
+	AssertWidgetsShouldBeStored(t *testing.T, widgets map[string]Widget)
+}
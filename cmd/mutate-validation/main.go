@@ -0,0 +1,205 @@
+// Command mutate-validation tells which validation rules are not pinned down
+// by the acceptance suite. It mutates the constants of the validation rules,
+// such as the lengths and the regular expressions of customer/validation.go,
+// runs the suite against each mutant, and reports the mutants surviving it:
+//
+//	mutate-validation
+//	mutate-validation -const 'name' -v
+//
+// The literals are moved by one, up and down, and the items of the character
+// classes are dropped one at a time. A surviving mutant is a change of a rule
+// that no test case notices, e.g. a length limit with no case at its bound.
+//
+// The suite is run with `go test -overlay`, leaving the sources untouched, and
+// must be run from the root of the module. The command exits with status 1
+// when mutants survive and 2 on usage errors, or when the suite fails without
+// mutants.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run mutates the file selected by `args`, runs the suite against each mutant
+// and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mutate-validation", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: mutate-validation [flags]")
+		fs.PrintDefaults()
+	}
+
+	file := fs.String("file", "customer/validation.go", "file declaring the constants to mutate")
+	only := fs.String("const", "", "regular expression selecting the constants to mutate (default all)")
+	verbose := fs.Bool("v", false, "also report the killed mutants")
+
+	var s suite
+	fs.StringVar(&s.pkg, "pkg", "./test/acceptance/customer", "package of the suite")
+	fs.StringVar(&s.run, "run", "TestRegisterCustomer$", "tests of the suite, as for `go test -run`")
+	fs.StringVar(&s.variants, "variants", "reference", "SUT variants the suite runs against, as for `-gtd.variants`")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var onlyRegex *regexp.Regexp
+	if *only != "" {
+		var err error
+		if onlyRegex, err = regexp.Compile(*only); err != nil {
+			fmt.Fprintf(stderr, "invalid -const: %v\n", err)
+			return 2
+		}
+	}
+
+	path, err := filepath.Abs(*file)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	ms, err := mutants(path, src, onlyRegex)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if len(ms) == 0 {
+		fmt.Fprintln(stderr, "no mutant to run")
+		return 2
+	}
+
+	if killed, output, err := s.test(""); err != nil || killed {
+		fmt.Fprintf(stderr, "the suite fails without mutants: %v\n%s", err, output)
+		return 2
+	}
+
+	dir, err := os.MkdirTemp("", "mutate-validation-")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer os.RemoveAll(dir)
+
+	var rules []string
+	counts := map[string]*struct{ total, survived int }{}
+
+	for i, m := range ms {
+		overlay, err := writeOverlay(dir, i, path, m.apply(src))
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+
+		killed, output, err := s.test(overlay)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n%s", m, err, output)
+			return 2
+		}
+
+		if counts[m.Rule] == nil {
+			rules = append(rules, m.Rule)
+			counts[m.Rule] = &struct{ total, survived int }{}
+		}
+		counts[m.Rule].total++
+
+		switch {
+		case !killed:
+			counts[m.Rule].survived++
+			fmt.Fprintf(stdout, "%s: SURVIVED\n", m)
+		case *verbose:
+			fmt.Fprintf(stdout, "%s: killed\n", m)
+		}
+	}
+
+	status := 0
+
+	for _, rule := range rules {
+		c := counts[rule]
+		fmt.Fprintf(stdout, "%s: %d of %d mutants survived\n", rule, c.survived, c.total)
+
+		if c.survived > 0 {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// writeOverlay writes the mutated source of the file at `path` and the
+// overlay replacing the file by it, as expected by `go test -overlay`, and
+// returns the path of the overlay.
+func writeOverlay(dir string, i int, path string, mutated []byte) (string, error) {
+	mutatedPath := filepath.Join(dir, fmt.Sprintf("mutant-%d.go", i))
+	if err := os.WriteFile(mutatedPath, mutated, 0o600); err != nil {
+		return "", err
+	}
+
+	overlay, err := json.Marshal(map[string]any{"Replace": map[string]string{path: mutatedPath}})
+	if err != nil {
+		return "", err
+	}
+
+	overlayPath := filepath.Join(dir, fmt.Sprintf("mutant-%d.json", i))
+
+	return overlayPath, os.WriteFile(overlayPath, overlay, 0o600)
+}
+
+// suite runs the acceptance suite.
+type suite struct {
+	pkg      string
+	run      string
+	variants string
+}
+
+// test runs the suite with the `overlay`, if any, and tells whether it
+// failed, i.e. killed the mutant. It returns an error when the suite could
+// not be run, e.g. when the mutant does not build.
+func (s suite) test(overlay string) (killed bool, output []byte, err error) {
+	args := []string{"test", "-tags", "test", "-count=1", "-run", s.run}
+	if overlay != "" {
+		args = append(args, "-overlay", overlay)
+	}
+	args = append(args, s.pkg, "-args", "-gtd.variants="+s.variants)
+
+	output, err = exec.Command("go", args...).CombinedOutput()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return false, output, nil
+
+	case !errors.As(err, &exitErr):
+		return false, output, err
+
+	case strings.Contains(string(output), "[build failed]") || strings.Contains(string(output), "[setup failed]"):
+		return false, output, errors.New("the suite does not build")
+
+	default:
+		return true, output, nil
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const validationSource = `package customer
+
+//
+// Name Validation
+
+const (
+	minimumNameLength = 2 * 3
+	nameRegex         = ` + "`^[a-z\\-]{2,}$`" + `
+)
+
+//
+// Phone Validation
+
+const phoneRegex = "[0-9]+"
+`
+
+func TestMutants(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("should mutate the integers, the quantifiers and the classes", func(t *testing.T) {
+		ms, err := mutants("validation.go", []byte(validationSource), nil)
+		r.NoError(err)
+
+		var got []string
+		for _, m := range ms {
+			got = append(got, m.String())
+		}
+
+		r.Equal([]string{
+			"name: minimumNameLength: 2 -> 1",
+			"name: minimumNameLength: 2 -> 3",
+			"name: minimumNameLength: 3 -> 2",
+			"name: minimumNameLength: 3 -> 4",
+			"name: nameRegex: `{2,}` -> `{1,}` at 8",
+			"name: nameRegex: `{2,}` -> `{3,}` at 8",
+			"name: nameRegex: `[a-z\\-]` drops `a-z` at 1",
+			"name: nameRegex: `[a-z\\-]` drops `\\-` at 1",
+		}, got)
+	})
+
+	t.Run("should apply the mutants to the source", func(t *testing.T) {
+		ms, err := mutants("validation.go", []byte(validationSource), regexp.MustCompile("Regex$"))
+		r.NoError(err)
+		r.Len(ms, 4)
+
+		r.Contains(string(ms[1].apply([]byte(validationSource))), "`^[a-z\\-]{3,}$`")
+		r.Contains(string(ms[3].apply([]byte(validationSource))), "`^[a-z]{2,}$`")
+	})
+
+	t.Run("should leave out the invalid regular expressions", func(t *testing.T) {
+		src := "package p\n\nconst r = `[a]{0,1}`\n"
+
+		ms, err := mutants("p.go", []byte(src), nil)
+		r.NoError(err)
+
+		var got []string
+		for _, m := range ms {
+			got = append(got, m.Description)
+		}
+
+		// Dropping the only item of `[a]` would leave an empty class, and the
+		// bounds are never moved below zero.
+		r.Equal([]string{"`{0,1}` -> `{1,1}` at 3", "`{0,1}` -> `{0,0}` at 3", "`{0,1}` -> `{0,2}` at 3"}, got)
+	})
+}
+
+func TestRun(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("should fail on usage errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		r.Equal(2, run([]string{"unexpected"}, &stdout, &stderr))
+		r.Contains(stderr.String(), "usage: mutate-validation")
+
+		stderr.Reset()
+		r.Equal(2, run([]string{"-file", "../../customer/validation.go", "-const", "^nothing$"}, &stdout, &stderr))
+		r.Equal("no mutant to run\n", stderr.String())
+	})
+
+	t.Run("should kill the mutants of a rule pinned down by the suite", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("runs the acceptance suite once per mutant")
+		}
+
+		// The suite is run from the root of the module.
+		wd, err := os.Getwd()
+		r.NoError(err)
+		r.NoError(os.Chdir("../.."))
+		t.Cleanup(func() { _ = os.Chdir(wd) })
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"-const", "^minimumNameParts$", "-v"}, &stdout, &stderr)
+
+		r.Equal(0, status, stdout.String()+stderr.String())
+		r.Equal("name: minimumNameParts: 2 -> 1: killed\n"+
+			"name: minimumNameParts: 2 -> 3: killed\n"+
+			"name: 0 of 2 mutants survived\n", stdout.String())
+	})
+}
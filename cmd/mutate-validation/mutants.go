@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mutant is a variation of the source file, replacing a literal in the value
+// of a constant.
+type mutant struct {
+	// Rule is the validation rule of the constant, taken from the section
+	// comment preceding its declaration, e.g. `name` for `// Name Validation`.
+	Rule  string
+	Const string
+
+	// Description tells the change, e.g. "`{5,58}` -> `{5,59}` at 19", with
+	// the offset of the changed part of a regular expression, since the same
+	// quantifier or class may appear several times.
+	Description string
+
+	// The bytes from Start to End of the source are replaced by Replacement.
+	Start, End  int
+	Replacement string
+}
+
+func (m mutant) String() string {
+	return fmt.Sprintf("%s: %s: %s", m.Rule, m.Const, m.Description)
+}
+
+// apply returns the source mutated by `m`.
+func (m mutant) apply(src []byte) []byte {
+	mutated := make([]byte, 0, len(src)-(m.End-m.Start)+len(m.Replacement))
+	mutated = append(mutated, src[:m.Start]...)
+	mutated = append(mutated, m.Replacement...)
+
+	return append(mutated, src[m.End:]...)
+}
+
+// sectionRegex matches the section comments of the rules, e.g.
+// `// Name Validation`.
+var sectionRegex = regexp.MustCompile(`^(\w+) Validation$`)
+
+// mutants returns the mutants of the constants of `src` whose name matches
+// `only`, in their order of declaration:
+//   - every integer literal is moved by one, up and down;
+//   - every bound of a regular expression quantifier, e.g. `{5,58}`, is moved
+//     by one, up and down;
+//   - every item of a regular expression character class, e.g. `a-z` or `\.`
+//     in `[a-z\.]`, is dropped.
+//
+// The mutants turning a constant into an invalid regular expression, or into
+// one which does not change, are left out.
+func mutants(filename string, src []byte, only *regexp.Regexp) ([]mutant, error) {
+	fset := token.NewFileSet()
+
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []mutant
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+
+		rule := ruleOf(f, gen)
+
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+
+			for i, name := range vs.Names {
+				if i >= len(vs.Values) || (only != nil && !only.MatchString(name.Name)) {
+					continue
+				}
+
+				ast.Inspect(vs.Values[i], func(n ast.Node) bool {
+					lit, ok := n.(*ast.BasicLit)
+					if !ok {
+						return true
+					}
+
+					for _, m := range literalMutants(lit) {
+						m.Rule, m.Const = rule, name.Name
+						m.Start += fset.Position(lit.Pos()).Offset
+						m.End += fset.Position(lit.Pos()).Offset
+						all = append(all, m)
+					}
+
+					return false
+				})
+			}
+		}
+	}
+
+	return all, nil
+}
+
+// ruleOf returns the rule of the last section comment preceding `decl`, in
+// lower case, or "" when there is none.
+func ruleOf(f *ast.File, decl *ast.GenDecl) string {
+	rule := ""
+
+	for _, group := range f.Comments {
+		if group.End() >= decl.Pos() {
+			break
+		}
+
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if match := sectionRegex.FindStringSubmatch(text); match != nil {
+				rule = strings.ToLower(match[1])
+			}
+		}
+	}
+
+	return rule
+}
+
+// literalMutants returns the mutants of a literal, with offsets relative to
+// the start of the literal.
+func literalMutants(lit *ast.BasicLit) []mutant {
+	switch lit.Kind {
+	case token.INT:
+		n, err := strconv.Atoi(lit.Value)
+		if err != nil {
+			return nil
+		}
+
+		var ms []mutant
+		for _, moved := range []int{n - 1, n + 1} {
+			if moved < 0 {
+				continue
+			}
+
+			ms = append(ms, mutant{
+				Description: fmt.Sprintf("%d -> %d", n, moved),
+				End:         len(lit.Value),
+				Replacement: strconv.Itoa(moved),
+			})
+		}
+
+		return ms
+
+	case token.STRING:
+		// Only the raw strings are mutated, so that the offsets in the
+		// expression are the ones in the source.
+		if !strings.HasPrefix(lit.Value, "`") {
+			return nil
+		}
+
+		expr := lit.Value[1 : len(lit.Value)-1]
+
+		var ms []mutant
+		for _, m := range append(quantifierMutants(expr), classMutants(expr)...) {
+			mutated := expr[:m.Start] + m.Replacement + expr[m.End:]
+			if _, err := regexp.Compile(mutated); err != nil || mutated == expr {
+				continue
+			}
+
+			// Skips the opening backquote.
+			m.Start++
+			m.End++
+			ms = append(ms, m)
+		}
+
+		return ms
+
+	default:
+		return nil
+	}
+}
+
+// quantifierRegex matches the bounded quantifiers of a regular expression,
+// e.g. `{2,}` or `{5,58}`.
+var quantifierRegex = regexp.MustCompile(`\{(\d+)(,(\d*))?\}`)
+
+// quantifierMutants returns the mutants moving a bound of the quantifiers of
+// `expr` by one.
+func quantifierMutants(expr string) []mutant {
+	var ms []mutant
+
+	for _, loc := range quantifierRegex.FindAllStringSubmatchIndex(expr, -1) {
+		quantifier := expr[loc[0]:loc[1]]
+
+		// Moves the minimum (group 1) then the maximum (group 3), if any.
+		for _, group := range []int{1, 3} {
+			start, end := loc[2*group], loc[2*group+1]
+			if start < 0 || start == end {
+				continue
+			}
+
+			n, _ := strconv.Atoi(expr[start:end])
+			for _, moved := range []int{n - 1, n + 1} {
+				if moved < 0 {
+					continue
+				}
+
+				replaced := quantifier[:start-loc[0]] + strconv.Itoa(moved) + quantifier[end-loc[0]:]
+				ms = append(ms, mutant{
+					Description: fmt.Sprintf("`%s` -> `%s` at %d", quantifier, replaced, loc[0]),
+					Start:       loc[0],
+					End:         loc[1],
+					Replacement: replaced,
+				})
+			}
+		}
+	}
+
+	return ms
+}
+
+// classMutants returns the mutants dropping an item of the character classes
+// of `expr`.
+func classMutants(expr string) []mutant {
+	var ms []mutant
+
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '\\':
+			// Skips the escaped character, outside of the classes.
+			i++
+
+		case '[':
+			start := i
+			items, end := classItems(expr, i+1)
+
+			class := expr[start:end]
+			for _, item := range items {
+				ms = append(ms, mutant{
+					Description: fmt.Sprintf("`%s` drops `%s` at %d", class, expr[item[0]:item[1]], start),
+					Start:       item[0],
+					End:         item[1],
+				})
+			}
+
+			i = end - 1
+		}
+	}
+
+	return ms
+}
+
+// classItems splits the character class of `expr` starting at `i`, right
+// after its `[`, into items: escaped characters, ranges and single
+// characters. It returns the bounds of the items, and the end of the class,
+// right after its `]`.
+func classItems(expr string, i int) (items [][2]int, end int) {
+	if i < len(expr) && expr[i] == '^' {
+		i++
+	}
+
+	for i < len(expr) && expr[i] != ']' {
+		start := i
+
+		if expr[i] == '\\' {
+			i++
+		}
+		i++
+
+		if i+1 < len(expr) && expr[i] == '-' && expr[i+1] != ']' {
+			i += 2
+		}
+
+		items = append(items, [2]int{start, min(i, len(expr))})
+	}
+
+	return items, min(i+1, len(expr))
+}
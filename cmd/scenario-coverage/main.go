@@ -0,0 +1,243 @@
+// Command scenario-coverage maps the code of the customer packages to the
+// acceptance scenarios running it, and tells the code run by no scenario:
+//
+//	scenario-coverage -o coverage-by-scenario.md
+//	scenario-coverage -run 'TestRegisterCustomer$' -variants 'sqlite*'
+//
+// It builds the acceptance suite with coverage, then runs it once with the
+// GTD_SCENARIOS_FILE harness mode to list the scenarios: the innermost tests
+// setting up a SUT, i.e. a case run against a variant. Then it runs every
+// scenario on its own, collecting its coverage, and writes a Markdown report
+// listing, for each block of code, the scenarios running it.
+//
+// The coverage of a process can't be split between the tests it runs, hence
+// one run per scenario: mapping all the variants takes a few minutes. It must
+// be run from the root of the module. The command exits with status 1 when a
+// scenario fails and 2 on usage errors.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// config is the configuration of a run.
+type config struct {
+	pkg      string
+	coverpkg string
+	run      string
+	variants string
+	exclude  *regexp.Regexp
+	output   string
+}
+
+// run maps the coverage of the scenarios selected by `args` and returns the
+// exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("scenario-coverage", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: scenario-coverage [flags]")
+		fs.PrintDefaults()
+	}
+
+	var cfg config
+	fs.StringVar(&cfg.pkg, "pkg", "./test/acceptance/customer", "package of the acceptance suite")
+	fs.StringVar(&cfg.coverpkg, "coverpkg", "./customer/...", "packages whose coverage is mapped, as for `go test -coverpkg`")
+	fs.StringVar(&cfg.run, "run", "", "tests of the suite, as for `go test -run` (default all)")
+	fs.StringVar(&cfg.variants, "variants", "", "SUT variants, as for `-gtd.variants` (default all)")
+	exclude := fs.String("exclude", `_test_driver\.go$`, "regular expression of the files left out of the report")
+	fs.StringVar(&cfg.output, "o", "coverage-by-scenario.md", "report file")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	if *exclude != "" {
+		var err error
+		if cfg.exclude, err = regexp.Compile(*exclude); err != nil {
+			fmt.Fprintf(stderr, "invalid -exclude: %v\n", err)
+			return 2
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "scenario-coverage-")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer os.RemoveAll(dir)
+
+	cm, err := mapCoverage(cfg, dir, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	module, err := goOutput("list", "-m")
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	f, err := os.Create(cfg.output)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer f.Close()
+
+	if err := cm.writeMarkdown(f, strings.TrimSpace(module)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	fmt.Fprintf(stdout, "%d scenarios mapped to %s\n", len(cm.scenarios), cfg.output)
+
+	if len(cm.failed) > 0 {
+		fmt.Fprintf(stderr, "%d scenarios failed: %s\n", len(cm.failed), strings.Join(cm.failed, ", "))
+		return 1
+	}
+
+	return 0
+}
+
+// mapCoverage builds the suite in `dir`, lists its scenarios and runs each of
+// them, reporting the progress to `progress`.
+func mapCoverage(cfg config, dir string, progress io.Writer) (*coverageMap, error) {
+	binary, err := filepath.Abs(filepath.Join(dir, "suite.test"))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := goOutput("test", "-c", "-tags", "test", "-cover", "-covermode=set",
+		"-coverpkg", cfg.coverpkg, "-o", binary, cfg.pkg); err != nil {
+		return nil, err
+	}
+
+	scenariosFile := filepath.Join(dir, "scenarios.txt")
+
+	listing := exec.Command(binary, "-test.run", cfg.run, "-test.gocoverdir", dir, "-gtd.variants", cfg.variants)
+	listing.Dir = cfg.pkg
+	listing.Env = append(os.Environ(), "GTD_SCENARIOS_FILE="+scenariosFile)
+	if output, err := listing.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("the suite fails: %w\n%s", err, output)
+	}
+
+	scenarios, err := readScenarios(scenariosFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("the suite sets up no SUT")
+	}
+
+	cm := newCoverageMap()
+
+	for i, scenario := range scenarios {
+		fmt.Fprintf(progress, "[%d/%d] %s\n", i+1, len(scenarios), scenario)
+
+		coverDir := filepath.Join(dir, fmt.Sprintf("scenario-%d", i))
+		if err := os.Mkdir(coverDir, 0o755); err != nil {
+			return nil, err
+		}
+
+		cmd := exec.Command(binary, "-test.run", runPattern(scenario), "-test.gocoverdir", coverDir,
+			"-gtd.variants", cfg.variants)
+		cmd.Dir = cfg.pkg
+		if output, err := cmd.CombinedOutput(); err != nil {
+			fmt.Fprintf(progress, "%s failed: %v\n%s", scenario, err, output)
+			cm.failed = append(cm.failed, scenario)
+		}
+
+		profilePath := coverDir + ".txt"
+		if _, err := goOutput("tool", "covdata", "textfmt", "-i", coverDir, "-o", profilePath); err != nil {
+			return nil, err
+		}
+
+		profile, err := readProfileFile(profilePath)
+		if err != nil {
+			return nil, err
+		}
+
+		cm.add(scenario, profile, cfg.exclude)
+	}
+
+	return cm, nil
+}
+
+// readScenarios reads the scenarios recorded by the harness, leaving out the
+// ones with recorded subtests, which are run along with them.
+func readScenarios(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		names = append(names, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	var scenarios []string
+	for i, name := range names {
+		// The subtests of a test follow it in the sorted names.
+		if i+1 < len(names) && strings.HasPrefix(names[i+1], name+"/") {
+			continue
+		}
+
+		scenarios = append(scenarios, name)
+	}
+
+	return scenarios, nil
+}
+
+// runPattern returns the `-test.run` pattern matching the test `name` only.
+func runPattern(name string) string {
+	elems := strings.Split(name, "/")
+	for i, elem := range elems {
+		elems[i] = "^" + regexp.QuoteMeta(elem) + "$"
+	}
+
+	return strings.Join(elems, "/")
+}
+
+// goOutput runs the go command with `args`, and returns its output.
+func goOutput(args ...string) (string, error) {
+	output, err := exec.Command("go", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("go %s: %w\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return string(output), nil
+}
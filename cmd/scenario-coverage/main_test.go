@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const profile = `mode: set
+example.com/m/p/f.go:3.20,5.2 2 1
+example.com/m/p/f.go:6.2,6.10 1 0
+example.com/m/p/f.go:8.20,9.2 1 0
+example.com/m/p/f_test_driver.go:3.20,5.2 2 1
+`
+
+func TestCoverageMap(t *testing.T) {
+	r := require.New(t)
+
+	blocks, err := readProfile(strings.NewReader(profile))
+	r.NoError(err)
+	r.Len(blocks, 4)
+
+	other := map[block]bool{}
+	for b := range blocks {
+		other[b] = b.StartLine == 8
+	}
+
+	cm := newCoverageMap()
+	cm.add("TestA/first", blocks, nil)
+	cm.add("TestA/second", other, nil)
+
+	var md bytes.Buffer
+	r.NoError(cm.writeMarkdown(&md, "example.com/m"))
+
+	r.Equal("# Coverage by scenario\n\n"+
+		"2 scenarios run 5 of 6 statements; 1 statements, in 1 blocks, are run by no scenario.\n\n"+
+		"## Run by no scenario\n\n"+
+		"- **p/f.go**: 6\n\n"+
+		"## p/f.go\n\n"+
+		"| Lines | Scenarios |\n| --- | --- |\n"+
+		"| 3-5 | 1: `TestA/first` |\n"+
+		"| **6** | **none** |\n"+
+		"| 8-9 | 1: `TestA/second` |\n\n"+
+		"## p/f_test_driver.go\n\n"+
+		"| Lines | Scenarios |\n| --- | --- |\n"+
+		"| 3-5 | 1: `TestA/first` |\n", md.String())
+
+	t.Run("should merge the adjacent blocks", func(t *testing.T) {
+		ranges := lineRanges(cm.sortedBlocks())
+		require.Equal(t, []fileLines{
+			{"example.com/m/p/f.go", []string{"3-6", "8-9"}},
+			{"example.com/m/p/f_test_driver.go", []string{"3-5"}},
+		}, ranges)
+	})
+
+	t.Run("should reject malformed profiles", func(t *testing.T) {
+		_, err := readProfile(strings.NewReader("mode: set\nf.go:3.20\n"))
+		require.Error(t, err)
+	})
+}
+
+func TestReadScenarios(t *testing.T) {
+	r := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "scenarios.txt")
+	r.NoError(os.WriteFile(path, []byte("TestA/x\nTestA/seeded\nTestA/seeded/case_1\nTestA/seeded/case_2\nTestA/x\n"), 0o600))
+
+	scenarios, err := readScenarios(path)
+	r.NoError(err)
+	r.Equal([]string{"TestA/seeded/case_1", "TestA/seeded/case_2", "TestA/x"}, scenarios)
+}
+
+func TestRunPattern(t *testing.T) {
+	require.Equal(t, `^TestA$/^when_\(a\)_is_long#01$`, runPattern("TestA/when_(a)_is_long#01"))
+}
+
+func TestRun(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("should fail on usage errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		r.Equal(2, run([]string{"unexpected"}, &stdout, &stderr))
+		r.Contains(stderr.String(), "usage: scenario-coverage")
+	})
+
+	t.Run("should map the code to the scenarios running it", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("builds and runs the acceptance suite")
+		}
+
+		// The command is run from the root of the module.
+		wd, err := os.Getwd()
+		r.NoError(err)
+		r.NoError(os.Chdir("../.."))
+		t.Cleanup(func() { _ = os.Chdir(wd) })
+
+		output := filepath.Join(t.TempDir(), "coverage.md")
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{
+			"-run", "TestRegisterCustomer$/reference$/same_user_twice",
+			"-variants", "reference",
+			"-o", output,
+		}, &stdout, &stderr)
+
+		const scenario = "TestRegisterCustomer/with_system_variant_reference/should_not_register_the_same_user_twice"
+
+		r.Equal(0, status, stderr.String())
+		r.Equal("[1/1] "+scenario+"\n1 scenarios mapped to "+output+"\n", stdout.String())
+
+		md, err := os.ReadFile(output)
+		r.NoError(err)
+		r.Contains(string(md), "## customer/validation.go")
+		r.Contains(string(md), "1: `"+scenario+"`")
+		r.NotContains(string(md), "_test_driver.go")
+	})
+}
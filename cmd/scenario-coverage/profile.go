@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// block is a block of statements of a coverage profile.
+type block struct {
+	File                string
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	Statements          int
+}
+
+// lines formats the lines of the block, e.g. `12-15`.
+func (b block) lines() string {
+	if b.StartLine == b.EndLine {
+		return strconv.Itoa(b.StartLine)
+	}
+
+	return fmt.Sprintf("%d-%d", b.StartLine, b.EndLine)
+}
+
+// profileLineRegex matches the block lines of a text coverage profile, e.g.
+// `example.com/m/p/f.go:12.34,15.2 3 1`.
+var profileLineRegex = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+) (\d+)$`)
+
+// readProfile reads a text coverage profile, as written by `go tool covdata
+// textfmt`, and returns its blocks with whether they were run.
+func readProfile(r io.Reader) (map[block]bool, error) {
+	blocks := map[block]bool{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		m := profileLineRegex.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("malformed profile line: '%s'", line)
+		}
+
+		atoi := func(s string) int {
+			n, _ := strconv.Atoi(s)
+			return n
+		}
+
+		b := block{
+			File:      m[1],
+			StartLine: atoi(m[2]), StartCol: atoi(m[3]),
+			EndLine: atoi(m[4]), EndCol: atoi(m[5]),
+			Statements: atoi(m[6]),
+		}
+
+		// The same block may appear several times, once per counter file.
+		blocks[b] = blocks[b] || atoi(m[7]) > 0
+	}
+
+	return blocks, scanner.Err()
+}
+
+// readProfileFile reads the profile at `path`. A missing profile has no
+// block, as `go tool covdata` writes none when there is no counter data.
+func readProfileFile(path string) (map[block]bool, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return map[block]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readProfile(f)
+}
+
+// coverageMap maps the blocks of the covered packages to the scenarios
+// running them.
+type coverageMap struct {
+	scenarios []string
+	failed    []string
+	blocks    map[block][]string
+}
+
+func newCoverageMap() *coverageMap {
+	return &coverageMap{blocks: map[block][]string{}}
+}
+
+// add adds the profile of `scenario`, keeping the blocks of the files
+// matching `exclude` out.
+func (cm *coverageMap) add(scenario string, profile map[block]bool, exclude *regexp.Regexp) {
+	cm.scenarios = append(cm.scenarios, scenario)
+
+	for b, run := range profile {
+		if exclude != nil && exclude.MatchString(b.File) {
+			continue
+		}
+
+		if _, found := cm.blocks[b]; !found {
+			cm.blocks[b] = nil
+		}
+
+		if run {
+			cm.blocks[b] = append(cm.blocks[b], scenario)
+		}
+	}
+}
+
+// sortedBlocks returns the blocks in the order of the files, then of the
+// lines.
+func (cm *coverageMap) sortedBlocks() []block {
+	var blocks []block
+	for b := range cm.blocks {
+		blocks = append(blocks, b)
+	}
+
+	slices.SortFunc(blocks, func(a, b block) int {
+		return cmp.Or(
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.StartLine, b.StartLine),
+			cmp.Compare(a.StartCol, b.StartCol),
+		)
+	})
+
+	return blocks
+}
+
+// writeMarkdown writes the map as a Markdown document: a summary, the code
+// run by no scenario, then the scenarios running each block, file by file.
+// The files are named relative to `module`.
+func (cm *coverageMap) writeMarkdown(w io.Writer, module string) error {
+	blocks := cm.sortedBlocks()
+
+	name := func(file string) string {
+		return strings.TrimPrefix(file, module+"/")
+	}
+
+	var statements, uncoveredStatements int
+	var uncovered []block
+	for _, b := range blocks {
+		statements += b.Statements
+		if len(cm.blocks[b]) == 0 {
+			uncovered = append(uncovered, b)
+			uncoveredStatements += b.Statements
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# Coverage by scenario\n\n")
+	fmt.Fprintf(bw, "%d scenarios run %d of %d statements; %d statements, in %d blocks, are run by no scenario.\n",
+		len(cm.scenarios), statements-uncoveredStatements, statements, uncoveredStatements, len(uncovered))
+
+	if len(cm.failed) > 0 {
+		fmt.Fprintf(bw, "\n## Failed scenarios\n\n")
+		for _, scenario := range cm.failed {
+			fmt.Fprintf(bw, "- `%s`\n", scenario)
+		}
+	}
+
+	fmt.Fprintf(bw, "\n## Run by no scenario\n\n")
+	if len(uncovered) == 0 {
+		fmt.Fprintf(bw, "Every block is run by a scenario.\n")
+	}
+	for _, ranges := range lineRanges(uncovered) {
+		fmt.Fprintf(bw, "- **%s**: %s\n", name(ranges.file), strings.Join(ranges.lines, ", "))
+	}
+
+	file := ""
+	for _, b := range blocks {
+		if b.File != file {
+			file = b.File
+			fmt.Fprintf(bw, "\n## %s\n\n| Lines | Scenarios |\n| --- | --- |\n", name(file))
+		}
+
+		scenarios := cm.blocks[b]
+		if len(scenarios) == 0 {
+			fmt.Fprintf(bw, "| **%s** | **none** |\n", b.lines())
+			continue
+		}
+
+		quoted := make([]string, len(scenarios))
+		for i, s := range scenarios {
+			quoted[i] = "`" + s + "`"
+		}
+		fmt.Fprintf(bw, "| %s | %d: %s |\n", b.lines(), len(scenarios), strings.Join(quoted, "<br>"))
+	}
+
+	return bw.Flush()
+}
+
+// fileLines are the line ranges of the blocks of a file.
+type fileLines struct {
+	file  string
+	lines []string
+}
+
+// lineRanges merges the sorted `blocks` into line ranges, file by file, the
+// blocks on adjacent lines being merged together.
+func lineRanges(blocks []block) []fileLines {
+	var ranges []fileLines
+
+	var current *block
+	flush := func() {
+		if current != nil {
+			ranges[len(ranges)-1].lines = append(ranges[len(ranges)-1].lines, current.lines())
+		}
+	}
+
+	for _, b := range blocks {
+		switch {
+		case current != nil && b.File == current.File && b.StartLine <= current.EndLine+1:
+			current.EndLine = max(current.EndLine, b.EndLine)
+			continue
+
+		case current == nil || b.File != current.File:
+			flush()
+			ranges = append(ranges, fileLines{file: b.File})
+
+		default:
+			flush()
+		}
+
+		current = &b
+	}
+	flush()
+
+	return ranges
+}
@@ -0,0 +1,77 @@
+// Command validate-testdata checks YAML test data files against a JSON Schema,
+// without running the test suites:
+//
+//	validate-testdata -schema test/acceptance/customer/data/schema/cases.schema.json \
+//		test/acceptance/customer/data/*-cases.yaml
+//
+// Every violation is printed as `file:line:column: path: message`. The command
+// exits with status 1 when a file is invalid and 2 on usage errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maniosgrivei/go-test-drivers/test/dataschema"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run validates the files given in `args` and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate-testdata", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: validate-testdata -schema <schema.json> <file.yaml>...")
+		fs.PrintDefaults()
+	}
+
+	schemaPath := fs.String("schema", "", "JSON Schema the files must match")
+	quiet := fs.Bool("q", false, "do not report valid files")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *schemaPath == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	schema, err := dataschema.LoadSchema(*schemaPath)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	status := 0
+
+	for _, path := range fs.Args() {
+		err := schema.ValidateFile(path)
+
+		var verr *dataschema.ValidationError
+		switch {
+		case err == nil:
+			if !*quiet {
+				fmt.Fprintf(stdout, "%s: ok\n", path)
+			}
+
+		case errors.As(err, &verr):
+			for _, v := range verr.Violations {
+				fmt.Fprintln(stderr, v)
+			}
+			status = 1
+
+		default:
+			fmt.Fprintln(stderr, err)
+			status = 1
+		}
+	}
+
+	return status
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const casesSchema = "../../test/acceptance/customer/data/schema/cases.schema.json"
+
+func TestRun(t *testing.T) {
+	r := require.New(t)
+
+	t.Run("should accept the acceptance test data", func(t *testing.T) {
+		paths, err := filepath.Glob("../../test/acceptance/customer/data/*-cases.yaml")
+		r.NoError(err)
+		r.NotEmpty(paths)
+
+		var stdout, stderr bytes.Buffer
+		status := run(append([]string{"-q", "-schema", casesSchema}, paths...), &stdout, &stderr)
+
+		r.Equal(0, status, stderr.String())
+		r.Empty(stdout.String())
+	})
+
+	t.Run("should report the violations of an invalid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bad-cases.yaml")
+		r.NoError(os.WriteFile(path, []byte("cases:\n  \"when bad\":\n    request: {name: 42}\n"), 0o600))
+
+		var stdout, stderr bytes.Buffer
+		status := run([]string{"-schema", casesSchema, path}, &stdout, &stderr)
+
+		r.Equal(1, status)
+		r.Equal(path+`:3:21: cases."when bad".request.name: should be string, got integer`+"\n", stderr.String())
+	})
+
+	t.Run("should fail on usage errors", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		r.Equal(2, run([]string{"-schema", casesSchema}, &stdout, &stderr))
+		r.Contains(stderr.String(), "usage: validate-testdata")
+	})
+}
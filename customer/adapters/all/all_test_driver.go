@@ -0,0 +1,13 @@
+//go:build test
+
+// Package all registers every customer adapter on the customer.SUTHarness.
+// Test suites import it for its side effects to run against all the SUT
+// variants; new adapters only need to be added here.
+package all
+
+import (
+	_ "github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
+	_ "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/badger-poc"
+	_ "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/reference"
+	_ "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/sqlite-poc"
+)
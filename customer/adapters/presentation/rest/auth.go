@@ -0,0 +1,181 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Roles understood by the default access policy.
+const (
+	RoleCRMRead  = "crm:read"
+	RoleCRMWrite = "crm:write"
+)
+
+// Authentication errors. They are reported to the client with a
+// `401 Unauthorized` status.
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Roles   []string
+}
+
+// HasRole checks if the identity was granted `role`.
+func (id *Identity) HasRole(role string) bool {
+	return id != nil && slices.Contains(id.Roles, role)
+}
+
+// Authenticator establishes the identity of the caller of a request.
+//
+// Authenticate returns a nil identity and a nil error when the request carries
+// no credentials it understands, so that several authenticators can be
+// combined. It returns an error wrapping ErrInvalidCredentials when the
+// credentials are present but not acceptable.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as
+// authenticators.
+type AuthenticatorFunc func(r *http.Request) (*Identity, error)
+
+// Authenticate implements the Authenticator interface.
+func (f AuthenticatorFunc) Authenticate(r *http.Request) (*Identity, error) {
+	return f(r)
+}
+
+// Authenticators combines several authenticators. The first one which
+// recognizes the request credentials decides the outcome.
+type Authenticators []Authenticator
+
+// Authenticate implements the Authenticator interface.
+func (as Authenticators) Authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range as {
+		id, err := a.Authenticate(r)
+		if err != nil || id != nil {
+			return id, err
+		}
+	}
+
+	return nil, nil
+}
+
+// StaticAPIKeys is an Authenticator which maps the API keys sent in a header
+// to fixed identities.
+type StaticAPIKeys struct {
+	// Header carrying the API key. Defaults to DefaultAPIKeyHeader.
+	Header string
+
+	// Keys maps each accepted API key to its identity.
+	Keys map[string]Identity
+}
+
+// Authenticate implements the Authenticator interface.
+func (s *StaticAPIKeys) Authenticate(r *http.Request) (*Identity, error) {
+	header := s.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	key := r.Header.Get(header)
+	if key == "" {
+		return nil, nil
+	}
+
+	id, found := s.Keys[key]
+	if !found {
+		return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredentials)
+	}
+
+	return &id, nil
+}
+
+// identityKey is the context key holding the caller identity.
+type identityKey struct{}
+
+// IdentityFromContext returns the identity stored in `ctx` by the
+// Authenticate middleware, or nil for anonymous requests.
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+
+	return id
+}
+
+// Authenticate returns a middleware that identifies the caller of each
+// request with `authn` and stores the identity in the request context.
+// Requests with invalid credentials are rejected with `401 Unauthorized`;
+// requests without credentials go through anonymously and are left to the
+// Authorize middleware.
+func Authenticate(authn Authenticator) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := authn.Authenticate(r)
+			if err != nil {
+				writeUnauthorized(w, err)
+				return
+			}
+
+			if id != nil {
+				r = r.WithContext(context.WithValue(r.Context(), identityKey{}, id))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AccessPolicy maps routes, written as `METHOD /path`, to the roles allowed to
+// call them. A caller needs any one of the listed roles. Routes absent from
+// the policy are public.
+type AccessPolicy map[string][]string
+
+// DefaultAccessPolicy only lets `crm:write` callers register customers.
+func DefaultAccessPolicy() AccessPolicy {
+	return AccessPolicy{
+		"POST /customers": {RoleCRMWrite},
+	}
+}
+
+// Authorize returns a middleware enforcing `policy`. Anonymous callers of a
+// protected route get `401 Unauthorized`, and authenticated callers lacking
+// the required role get `403 Forbidden`.
+func Authorize(policy AccessPolicy) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			roles, protected := policy[r.Method+" "+r.URL.Path]
+			if !protected {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id := IdentityFromContext(r.Context())
+			if id == nil {
+				writeUnauthorized(w, ErrMissingCredentials)
+				return
+			}
+
+			if !slices.ContainsFunc(roles, id.HasRole) {
+				message := fmt.Sprintf("forbidden: '%s' lacks the role(s) %s", id.Subject, strings.Join(roles, ", "))
+				writeError(w, message, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeUnauthorized is a helper function to write a `401 Unauthorized`
+// response with its authentication challenge.
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="crm"`)
+	writeError(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+}
@@ -0,0 +1,118 @@
+//go:build test
+
+package rest
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTAuthenticator(t *testing.T) {
+	creds := NewTestCredentials()
+
+	authenticate := func(ja *JWTAuthenticator, token string) (*Identity, error) {
+		req, err := http.NewRequest(http.MethodPost, "/customers", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		return ja.Authenticate(req)
+	}
+
+	newAuthenticator := func() *JWTAuthenticator {
+		return &JWTAuthenticator{
+			Keys:     map[string][]byte{creds.JWTKeyID: creds.JWTKey},
+			Issuer:   creds.JWTIssuer,
+			Audience: creds.JWTAudience,
+		}
+	}
+
+	token := creds.signToken(t, callerData{Subject: "sales-person", Roles: []string{RoleCRMWrite}})
+
+	t.Run("should accept a valid token", func(t *testing.T) {
+		id, err := authenticate(newAuthenticator(), token)
+		require.NoError(t, err)
+		require.Equal(t, &Identity{Subject: "sales-person", Roles: []string{RoleCRMWrite}}, id)
+	})
+
+	t.Run("should ignore requests without bearer tokens", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/customers", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+
+		id, err := newAuthenticator().Authenticate(req)
+		require.NoError(t, err)
+		require.Nil(t, id)
+	})
+
+	t.Run("should reject tokens for another audience", func(t *testing.T) {
+		ja := newAuthenticator()
+		ja.Audience = "billing"
+
+		_, err := authenticate(ja, token)
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		require.ErrorContains(t, err, "not intended for 'billing'")
+	})
+
+	t.Run("should reject tokens from another issuer", func(t *testing.T) {
+		ja := newAuthenticator()
+		ja.Issuer = "someone-else"
+
+		_, err := authenticate(ja, token)
+		require.ErrorContains(t, err, "unexpected issuer")
+	})
+
+	t.Run("should honour the leeway for expired tokens", func(t *testing.T) {
+		ja := newAuthenticator()
+		ja.Now = func() time.Time { return time.Now().Add(6 * time.Minute) }
+
+		_, err := authenticate(ja, token)
+		require.ErrorContains(t, err, "token expired")
+
+		ja.Leeway = 2 * time.Minute
+		_, err = authenticate(ja, token)
+		require.NoError(t, err)
+	})
+
+	t.Run("should reject unsigned tokens", func(t *testing.T) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+		claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"mallory","exp":9999999999}`))
+
+		_, err := authenticate(newAuthenticator(), header+"."+claims+".")
+		require.ErrorContains(t, err, "unsupported algorithm 'none'")
+	})
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := DefaultAccessPolicy()
+
+	for title, tc := range map[string]struct {
+		id     *Identity
+		method string
+		status int
+	}{
+		"anonymous on protected route":   {nil, http.MethodPost, http.StatusUnauthorized},
+		"reader on protected route":      {&Identity{Subject: "auditor", Roles: []string{RoleCRMRead}}, http.MethodPost, http.StatusForbidden},
+		"writer on protected route":      {&Identity{Subject: "integrator", Roles: []string{RoleCRMWrite}}, http.MethodPost, http.StatusNoContent},
+		"anonymous on unprotected route": {nil, http.MethodGet, http.StatusNoContent},
+	} {
+		t.Run(title, func(t *testing.T) {
+			handler := Chain(
+				http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+				Authenticate(AuthenticatorFunc(func(*http.Request) (*Identity, error) { return tc.id, nil })),
+				Authorize(policy),
+			)
+
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(tc.method, "/customers", nil)
+			require.NoError(t, err)
+
+			handler.ServeHTTP(recorder, req)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
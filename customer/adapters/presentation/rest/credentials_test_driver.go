@@ -0,0 +1,179 @@
+//go:build test
+
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
+	"github.com/stretchr/testify/require"
+)
+
+// Well-known API keys registered by NewTestCredentials.
+const (
+	TestWriterAPIKey = "test-writer-api-key"
+	TestReaderAPIKey = "test-reader-api-key"
+)
+
+// TestCredentials holds the secrets shared by the authentication middlewares
+// of a handler and its test driver, so that the test driver can arrange the
+// identity of the caller of each request.
+type TestCredentials struct {
+	APIKeyHeader string
+	APIKeys      map[string]Identity
+
+	// JWTKey is nil when the credentials can't sign tokens, in which case the
+	// tests signing tokens are skipped.
+	JWTKeyID    string
+	JWTKey      []byte
+	JWTIssuer   string
+	JWTAudience string
+
+	// DefaultCaller is the caller used when the extra parameters have none.
+	DefaultCaller map[string]any
+}
+
+// NewTestCredentials creates test credentials with a random JWT key, a writer
+// and a reader API key, and a `crm:write` default caller.
+func NewTestCredentials() *TestCredentials {
+	return &TestCredentials{
+		APIKeyHeader: DefaultAPIKeyHeader,
+		APIKeys: map[string]Identity{
+			TestWriterAPIKey: {Subject: "integrator", Roles: []string{RoleCRMWrite}},
+			TestReaderAPIKey: {Subject: "auditor", Roles: []string{RoleCRMRead}},
+		},
+		JWTKeyID:    "test-key",
+		JWTKey:      randomKey(),
+		JWTIssuer:   "crm-tests",
+		JWTAudience: "crm",
+		DefaultCaller: map[string]any{
+			"subject": "sales-person",
+			"roles":   []any{RoleCRMWrite},
+		},
+	}
+}
+
+// Middlewares returns the authentication and authorization middlewares
+// accepting these credentials and enforcing the DefaultAccessPolicy.
+func (c *TestCredentials) Middlewares() []Middleware {
+	return []Middleware{
+		Authenticate(Authenticators{
+			&StaticAPIKeys{Header: c.APIKeyHeader, Keys: c.APIKeys},
+			&JWTAuthenticator{
+				Keys:     map[string][]byte{c.JWTKeyID: c.JWTKey},
+				Issuer:   c.JWTIssuer,
+				Audience: c.JWTAudience,
+			},
+		}),
+		Authorize(DefaultAccessPolicy()),
+	}
+}
+
+// callerData is the shape of the `caller` extra parameter.
+type callerData struct {
+	Anonymous  bool          `driver:"anonymous"`
+	APIKey     *string       `driver:"api_key"`
+	Token      *string       `driver:"token"`
+	Subject    string        `driver:"subject"`
+	Roles      []string      `driver:"roles"`
+	ExpiresIn  time.Duration `driver:"expires_in"`
+	SigningKey string        `driver:"signing_key"`
+}
+
+// applyCaller sets the credentials of the caller described in the extra
+// parameters, or of the default caller, on the request.
+//
+// It looks for the following optional attributes:
+// - caller: map[string]any
+//   - anonymous: bool (sends no credentials)
+//   - api_key: string
+//   - token: string (sent as is as a bearer token)
+//   - subject: string
+//   - roles: []string
+//   - expires_in: string (duration, defaults to 5m; negative for expired tokens)
+//   - signing_key: string (`unknown` signs with a key the server ignores)
+func (c *TestCredentials) applyCaller(t *testing.T, req *http.Request, extraParams map[string]any) {
+	t.Helper()
+
+	caller, found := driverdata.BindOptionalKey[callerData](t, extraParams, "caller")
+	if !found {
+		caller = driverdata.Bind[callerData](t, c.DefaultCaller)
+	}
+
+	switch {
+	case caller.Anonymous:
+		return
+
+	case caller.APIKey != nil:
+		req.Header.Set(c.APIKeyHeader, *caller.APIKey)
+
+	case caller.Token != nil:
+		req.Header.Set("Authorization", "Bearer "+*caller.Token)
+
+	default:
+		req.Header.Set("Authorization", "Bearer "+c.signToken(t, caller))
+	}
+}
+
+// signToken issues a JWT for the given caller.
+func (c *TestCredentials) signToken(t *testing.T, caller callerData) string {
+	t.Helper()
+
+	r := require.New(t)
+
+	if c.JWTKey == nil {
+		t.Skip("the test credentials have no key to sign tokens with")
+	}
+
+	expiresIn := caller.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = 5 * time.Minute
+	}
+
+	roles := caller.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+
+	key := c.JWTKey
+	if caller.SigningKey == "unknown" {
+		key = randomKey()
+	}
+
+	header := map[string]any{"alg": "HS256", "typ": "JWT", "kid": c.JWTKeyID}
+	claims := map[string]any{
+		"sub":   caller.Subject,
+		"iss":   c.JWTIssuer,
+		"aud":   c.JWTAudience,
+		"exp":   time.Now().Add(expiresIn).Unix(),
+		"roles": roles,
+	}
+
+	headerJSON, err := json.Marshal(header)
+	r.NoError(err)
+	claimsJSON, err := json.Marshal(claims)
+	r.NoError(err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// randomKey generates a random 256-bit HMAC key.
+func randomKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	return key
+}
@@ -3,6 +3,7 @@ package rest
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
@@ -16,15 +17,23 @@ type CustomerRESTAPIHandler struct {
 }
 
 // NewCustomerRESTAPIHandler creates and initializes a new CustomerRESTAPI
-// instance. It sets up the routing and returns a configured API handler.
-func NewCustomerRESTAPIHandler(service *customer.CustomerService) *CustomerRESTAPIHandler {
+// instance. It sets up the routing, wraps it with the given middlewares and
+// returns a configured API handler.
+//
+// When no middleware is given, DefaultMiddlewares logging to slog.Default()
+// is used.
+func NewCustomerRESTAPIHandler(service *customer.CustomerService, middlewares ...Middleware) *CustomerRESTAPIHandler {
 	api := &CustomerRESTAPIHandler{
 		service: service,
 	}
 
+	if len(middlewares) == 0 {
+		middlewares = DefaultMiddlewares(slog.Default())
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /customers", api.RegisterHandler)
-	api.handler = mux
+	api.handler = Chain(mux, middlewares...)
 
 	return api
 }
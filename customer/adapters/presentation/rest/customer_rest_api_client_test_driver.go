@@ -0,0 +1,195 @@
+//go:build test
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/stretchr/testify/require"
+)
+
+// Server timeouts used by NewCustomerRESTAPIServerTestDriver. They mirror the
+// defaults of the crm-server command.
+const (
+	testServerReadTimeout  = 5 * time.Second
+	testServerWriteTimeout = 10 * time.Second
+	testServerIdleTimeout  = 60 * time.Second
+)
+
+// CustomerRESTAPIClientTestDriver is a test driver for the customer REST API
+// which talks to a real HTTP server over the network, exercising the socket,
+// TLS, server timeouts and any middleware in front of the handler.
+type CustomerRESTAPIClientTestDriver struct {
+	baseURL string
+	client  *http.Client
+	logs    *LogRecorder
+	creds   *TestCredentials
+
+	interactions *InteractionRecorder
+}
+
+var _ customer.CustomerUpperLayerTestDriver = (*CustomerRESTAPIClientTestDriver)(nil)
+
+// NewCustomerRESTAPIClientTestDriver creates a new test driver that sends its
+// requests to the server at `baseURL` (e.g. `http://localhost:8080`) using the
+// given HTTP client. A nil `client` means http.DefaultClient.
+func NewCustomerRESTAPIClientTestDriver(baseURL string, client *http.Client) *CustomerRESTAPIClientTestDriver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &CustomerRESTAPIClientTestDriver{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// NewCustomerRESTAPIServerTestDriver starts `handler` on a local HTTP server,
+// or HTTPS server when `useTLS` is set, and creates a new test driver that
+// talks to it. The server is closed when the test and all its subtests
+// complete.
+func NewCustomerRESTAPIServerTestDriver(t *testing.T, handler http.Handler, useTLS bool) *CustomerRESTAPIClientTestDriver {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ReadTimeout = testServerReadTimeout
+	server.Config.ReadHeaderTimeout = testServerReadTimeout
+	server.Config.WriteTimeout = testServerWriteTimeout
+	server.Config.IdleTimeout = testServerIdleTimeout
+
+	if useTLS {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+
+	t.Cleanup(server.Close)
+
+	return NewCustomerRESTAPIClientTestDriver(server.URL, server.Client())
+}
+
+// WithLogRecorder makes the test driver also assert on the records captured by
+// `logs`, which must be the recorder behind the logger given to the server
+// middlewares.
+func (td *CustomerRESTAPIClientTestDriver) WithLogRecorder(logs *LogRecorder) *CustomerRESTAPIClientTestDriver {
+	td.logs = logs
+
+	return td
+}
+
+// WithCredentials makes the test driver authenticate its requests with
+// `creds`, which must be the credentials accepted by the handler middlewares.
+// The caller of each request can then be arranged through the `caller` extra
+// parameter.
+func (td *CustomerRESTAPIClientTestDriver) WithCredentials(creds *TestCredentials) *CustomerRESTAPIClientTestDriver {
+	td.creds = creds
+
+	return td
+}
+
+// WithInteractionRecorder makes the test driver capture its HTTP interactions
+// with `rec`.
+func (td *CustomerRESTAPIClientTestDriver) WithInteractionRecorder(rec *InteractionRecorder) *CustomerRESTAPIClientTestDriver {
+	td.interactions = rec
+
+	return td
+}
+
+//
+// Act
+
+// ActTryToRegisterACustomer sends an HTTP request to the registration
+// endpoint of the server.
+func (td *CustomerRESTAPIClientTestDriver) ActTryToRegisterACustomer(
+	t *testing.T,
+	request map[string]any,
+	extraParams map[string]any,
+) map[string]any {
+	t.Helper()
+
+	r := require.New(t)
+
+	// Prepare request
+	body, err := json.Marshal(request)
+	r.NoError(err)
+
+	req, err := http.NewRequest(http.MethodPost, td.baseURL+"/customers", bytes.NewReader(body))
+	r.NoError(err)
+
+	req.Header.Set("Content-Type", "application/json")
+	setRequestHeaders(t, req, extraParams)
+	if td.creds != nil {
+		td.creds.applyCaller(t, req, extraParams)
+	}
+
+	// Send request
+	resp, err := td.client.Do(req)
+	r.NoError(err)
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	r.NoError(err)
+
+	td.interactions.Record(req, body, resp.StatusCode, resp.Header, responseBody)
+
+	return buildResult(t, resp.StatusCode, resp.Header, responseBody)
+}
+
+//
+// Observe
+
+// ObserveRegistrationError maps the HTTP response of a registration back to
+// the errors of the customer service.
+func (td *CustomerRESTAPIClientTestDriver) ObserveRegistrationError(t *testing.T, result map[string]any) error {
+	t.Helper()
+
+	return observeRegistrationError(t, result)
+}
+
+//
+// Assert
+
+// AssertRegistrationShouldSucceed asserts that the HTTP response indicates a
+// successful registration.
+func (td *CustomerRESTAPIClientTestDriver) AssertRegistrationShouldSucceed(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+) {
+	t.Helper()
+
+	assertRegistrationShouldSucceed(t, result, extraParams, td.logs)
+}
+
+// AssertRegistrationShouldFail asserts that the HTTP response indicates a
+// failure.
+func (td *CustomerRESTAPIClientTestDriver) AssertRegistrationShouldFail(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+) {
+	t.Helper()
+
+	assertRegistrationShouldFail(t, result, extraParams, td.logs)
+}
+
+// AssertRegistrationShouldFailWithMessage asserts that the HTTP response
+// indicates a failure with specific status codes and error messages.
+func (td *CustomerRESTAPIClientTestDriver) AssertRegistrationShouldFailWithMessage(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+	targetMessages ...string,
+) {
+	t.Helper()
+
+	assertRegistrationShouldFailWithMessage(t, result, extraParams, td.logs, targetMessages...)
+}
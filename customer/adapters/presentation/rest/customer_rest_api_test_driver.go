@@ -5,11 +5,16 @@ package rest
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,6 +22,11 @@ import (
 // CustomerRESTAPIHandler.
 type CustomerRESTAPIHandlerTestDriver struct {
 	restAPI *CustomerRESTAPIHandler
+	logs    *LogRecorder
+	creds   *TestCredentials
+	clock   *ManualClock
+
+	interactions *InteractionRecorder
 }
 
 var _ customer.CustomerUpperLayerTestDriver = (*CustomerRESTAPIHandlerTestDriver)(nil)
@@ -29,6 +39,54 @@ func NewCustomerRESTAPIHandlerTestDriver(restAPI *CustomerRESTAPIHandler) *Custo
 	}
 }
 
+// WithLogRecorder makes the test driver also assert on the records captured by
+// `logs`, which must be the recorder behind the logger given to the handler
+// middlewares.
+func (td *CustomerRESTAPIHandlerTestDriver) WithLogRecorder(logs *LogRecorder) *CustomerRESTAPIHandlerTestDriver {
+	td.logs = logs
+
+	return td
+}
+
+// WithClock lets the test driver control the time seen by the handler
+// middlewares. `clock.Now` must be the function given to them.
+func (td *CustomerRESTAPIHandlerTestDriver) WithClock(clock *ManualClock) *CustomerRESTAPIHandlerTestDriver {
+	td.clock = clock
+
+	return td
+}
+
+// WithCredentials makes the test driver authenticate its requests with
+// `creds`, which must be the credentials accepted by the handler middlewares.
+// The caller of each request can then be arranged through the `caller` extra
+// parameter.
+func (td *CustomerRESTAPIHandlerTestDriver) WithCredentials(creds *TestCredentials) *CustomerRESTAPIHandlerTestDriver {
+	td.creds = creds
+
+	return td
+}
+
+// WithInteractionRecorder makes the test driver capture its HTTP interactions
+// with `rec`.
+func (td *CustomerRESTAPIHandlerTestDriver) WithInteractionRecorder(rec *InteractionRecorder) *CustomerRESTAPIHandlerTestDriver {
+	td.interactions = rec
+
+	return td
+}
+
+//
+// Arrange
+
+// ArrangeTimeHasPassed moves the clock of the handler forward by `d`. It
+// requires a clock.
+func (td *CustomerRESTAPIHandlerTestDriver) ArrangeTimeHasPassed(t *testing.T, d time.Duration) {
+	t.Helper()
+
+	require.NotNil(t, td.clock, "a clock is required to arrange the passage of time")
+
+	td.clock.Advance(d)
+}
+
 //
 // Act
 
@@ -50,14 +108,129 @@ func (td *CustomerRESTAPIHandlerTestDriver) ActTryToRegisterACustomer(
 	r.NoError(err)
 
 	req.Header.Set("Content-Type", "application/json")
+	setRequestHeaders(t, req, extraParams)
+	if td.creds != nil {
+		td.creds.applyCaller(t, req, extraParams)
+	}
 
 	// Record response
 	recorder := httptest.NewRecorder()
 	td.restAPI.ServeHTTP(recorder, req)
 
+	td.interactions.Record(req, body, recorder.Code, recorder.Header(), recorder.Body.Bytes())
+
+	return buildResult(t, recorder.Code, recorder.Header(), recorder.Body.Bytes())
+}
+
+//
+// Observe
+
+// ObserveRegistrationError maps the HTTP response of a registration back to
+// the errors of the customer service.
+func (td *CustomerRESTAPIHandlerTestDriver) ObserveRegistrationError(t *testing.T, result map[string]any) error {
+	t.Helper()
+
+	return observeRegistrationError(t, result)
+}
+
+//
+// Assert
+
+// AssertRegistrationShouldSucceed asserts that the HTTP response indicates a successful registration.
+func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldSucceed(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+) {
+	t.Helper()
+
+	assertRegistrationShouldSucceed(t, result, extraParams, td.logs)
+}
+
+// AssertRegistrationShouldFail asserts that the HTTP response indicates a failure.
+func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldFail(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+) {
+	t.Helper()
+
+	assertRegistrationShouldFail(t, result, extraParams, td.logs)
+}
+
+// AssertRegistrationShouldFailWithMessage asserts that the HTTP response indicates a failure
+// with specific status codes and error messages.
+func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldFailWithMessage(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+	targetMessages ...string,
+) {
+	t.Helper()
+
+	assertRegistrationShouldFailWithMessage(t, result, extraParams, td.logs, targetMessages...)
+}
+
+// AssertRegistrationShouldBeThrottled asserts that the HTTP response indicates
+// the request was rejected by the rate limiter.
+//
+// It looks for the following optional attributes in the `extraParams` map:
+// - rate_limit: map[string]any
+//   - limit: int
+//   - retry_after: int (seconds)
+func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldBeThrottled(
+	t *testing.T,
+	result map[string]any,
+	extraParams map[string]any,
+) {
+	t.Helper()
+
+	assertRegistrationShouldBeThrottled(t, result, extraParams, td.logs)
+}
+
+// AssertPanicShouldBeLogged asserts that a panic was recovered while serving
+// the request that produced `result`, and that it was logged with the request
+// ID. It requires a log recorder.
+func (td *CustomerRESTAPIHandlerTestDriver) AssertPanicShouldBeLogged(t *testing.T, result map[string]any) {
+	t.Helper()
+
+	assertPanicShouldBeLogged(t, result, td.logs)
+}
+
+//
+// Shared Helpers
+
+// setRequestHeaders sets the optional request headers found in the extra
+// parameters.
+//
+// It looks for the following optional attributes:
+// - http_request: map[string]any
+//   - headers: map[string]string
+func setRequestHeaders(t *testing.T, req *http.Request, extraParams map[string]any) {
+	t.Helper()
+
+	httpRequest, _ := driverdata.BindOptionalKey[expectedHTTPRequest](t, extraParams, "http_request")
+
+	for name, val := range httpRequest.Headers {
+		req.Header.Set(name, val)
+	}
+}
+
+// buildResult builds the result map returned by the Act methods from the HTTP
+// response status code, headers and body.
+//
+// It returns a map containing:
+// - id: string
+// - response_body: map[string]any
+// - status_code: int
+// - status: string
+// - request_id: string
+func buildResult(t *testing.T, statusCode int, header http.Header, body []byte) map[string]any {
+	t.Helper()
+
 	// Parse response body
 	var responseBody map[string]any
-	err = json.Unmarshal(recorder.Body.Bytes(), &responseBody)
+	err := json.Unmarshal(body, &responseBody)
 	if err != nil {
 		responseBody = nil // Handle cases with no or non-JSON body
 	}
@@ -65,81 +238,144 @@ func (td *CustomerRESTAPIHandlerTestDriver) ActTryToRegisterACustomer(
 	result := map[string]any{
 		"id":            "",
 		"response_body": responseBody,
-		"status_code":   recorder.Code,
-		"status":        http.StatusText(recorder.Code),
+		"status_code":   statusCode,
+		"status":        http.StatusText(statusCode),
+		"request_id":    header.Get(RequestIDHeader),
 	}
 
-	if recorder.Code < http.StatusBadRequest {
+	if rateLimit := getRateLimitFromHeader(t, header); rateLimit != nil {
+		result["rate_limit"] = rateLimit
+	}
+
+	if statusCode < http.StatusBadRequest {
 		result["id"] = getIDFromResponseBody(t, responseBody)
 	}
 
 	return result
 }
 
+// registrationErrors maps the HTTP status codes of the rejected registrations
+// to the errors of the customer service.
+var registrationErrors = map[int]error{
+	http.StatusBadRequest:          customer.ErrValidation,
+	http.StatusConflict:            customer.ErrDuplication,
+	http.StatusInternalServerError: customer.ErrSystem,
+}
+
+// rejectionStatusCodes maps the reasons why registrations are rejected, as
+// given in the `rejected_because` extra parameter, to the HTTP status codes
+// answering them.
+var rejectionStatusCodes = map[string]int{
+	"invalid data":    http.StatusBadRequest,
+	"duplicated data": http.StatusConflict,
+	"system failure":  http.StatusInternalServerError,
+	"unauthenticated": http.StatusUnauthorized,
+	"forbidden":       http.StatusForbidden,
+}
+
+// expectedResponse returns the HTTP response expected by the extra parameters
+// of a registration assertion: the `http_response` one when given, otherwise
+// the response rejecting a registration for the `rejected_because` reason, or
+// `201 Created` when the registration should succeed.
 //
-// Assert
+// It looks for the following optional attributes:
+// - rejected_because: string (e.g. `duplicated data`)
+// - http_response: map[string]any
+//   - status_code: int
+//   - status: string
+func expectedResponse(t *testing.T, extraParams map[string]any, shouldSucceed bool) expectedHTTPResponse {
+	t.Helper()
 
-// AssertRegistrationShouldSucceed asserts that the HTTP response indicates a successful registration.
-func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldSucceed(
-	t *testing.T,
-	result map[string]any,
-	extraParams map[string]any,
-) {
+	if expected, found := driverdata.BindOptionalKey[expectedHTTPResponse](t, extraParams, "http_response"); found {
+		return expected
+	}
+
+	statusCode := http.StatusCreated
+	if !shouldSucceed {
+		reason := driverdata.BindKey[string](t, extraParams, "rejected_because")
+
+		var known bool
+		statusCode, known = rejectionStatusCodes[reason]
+		require.True(t, known, "unknown rejection reason '%s'", reason)
+	}
+
+	return expectedHTTPResponse{StatusCode: statusCode, Status: http.StatusText(statusCode)}
+}
+
+// observeRegistrationError returns nil for the successful registrations, and
+// otherwise an error wrapping the customer error matching the status code of
+// the response, if any, and carrying the error message of its body.
+func observeRegistrationError(t *testing.T, result map[string]any) error {
 	t.Helper()
 
 	r := require.New(t)
 
-	// Check status
-	expectedStatus := getExpectedStatus(t, extraParams)
-	r.IsType(expectedStatus, result["status"])
-	r.Equal(expectedStatus, result["status"].(string))
+	r.Contains(result, "status_code")
+	statusCode := result["status_code"].(int)
+	if statusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	responseBody, _ := result["response_body"].(map[string]any)
+	message, _ := responseBody["error"].(string)
 
-	// Check status code
-	expectedStatusCode := getExpectedStatusCode(t, extraParams)
-	r.IsType(expectedStatusCode, result["status_code"])
-	r.Equal(expectedStatusCode, result["status_code"].(int))
+	if err, found := registrationErrors[statusCode]; found {
+		return fmt.Errorf("%w: HTTP %d: %s", err, statusCode, message)
+	}
+
+	return fmt.Errorf("unexpected HTTP %d %s: %s", statusCode, http.StatusText(statusCode), message)
+}
+
+// assertRegistrationShouldSucceed asserts that the HTTP response indicates a
+// successful registration.
+func assertRegistrationShouldSucceed(t *testing.T, result map[string]any, extraParams map[string]any, logs *LogRecorder) {
+	t.Helper()
+
+	r := require.New(t)
+
+	// Check status and status code
+	expected := expectedResponse(t, extraParams, true)
+	r.Equal(expected.Status, result["status"])
+	r.Equal(expected.StatusCode, result["status_code"])
 
 	// Check body for ID
 	r.Contains(result, "response_body")
 	r.IsType(map[string]any{}, result["response_body"])
 	responseBody := result["response_body"].(map[string]any)
 	r.NotEmpty(getIDFromResponseBody(t, responseBody))
+
+	assertRequestShouldBeTraceable(t, result, logs)
 }
 
-// AssertRegistrationShouldFail asserts that the HTTP response indicates a failure.
-func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldFail(
-	t *testing.T,
-	result map[string]any,
-	extraParams map[string]any,
-) {
+// assertRegistrationShouldFail asserts that the HTTP response indicates a
+// failure.
+func assertRegistrationShouldFail(t *testing.T, result map[string]any, extraParams map[string]any, logs *LogRecorder) {
 	t.Helper()
 
 	r := require.New(t)
 
-	// Check status
-	expectedStatus := getExpectedStatus(t, extraParams)
-	r.IsType(expectedStatus, result["status"])
-	r.Equal(expectedStatus, result["status"].(string))
+	// Check status and status code
+	expected := expectedResponse(t, extraParams, false)
+	r.Equal(expected.Status, result["status"])
+	r.Equal(expected.StatusCode, result["status_code"])
 
-	// Check status code
-	expectedStatusCode := getExpectedStatusCode(t, extraParams)
-	r.IsType(expectedStatusCode, result["status_code"])
-	r.Equal(expectedStatusCode, result["status_code"].(int))
+	assertRequestShouldBeTraceable(t, result, logs)
 }
 
-// AssertRegistrationShouldFailWithMessage asserts that the HTTP response indicates a failure
-// with specific status codes and error messages.
-func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldFailWithMessage(
+// assertRegistrationShouldFailWithMessage asserts that the HTTP response
+// indicates a failure with specific status codes and error messages.
+func assertRegistrationShouldFailWithMessage(
 	t *testing.T,
 	result map[string]any,
 	extraParams map[string]any,
+	logs *LogRecorder,
 	targetMessages ...string,
 ) {
 	t.Helper()
 
 	r := require.New(t)
 
-	td.AssertRegistrationShouldFail(t, result, extraParams)
+	assertRegistrationShouldFail(t, result, extraParams, logs)
 
 	// Check error message in body
 	r.Contains(result, "response_body")
@@ -156,45 +392,126 @@ func (td *CustomerRESTAPIHandlerTestDriver) AssertRegistrationShouldFailWithMess
 	}
 }
 
-func getIDFromResponseBody(t *testing.T, responseBody map[string]any) string {
+// getRateLimitFromHeader extracts the rate limiting headers of a response. It
+// returns nil when the response carries none.
+//
+// It returns a map containing:
+// - limit: int
+// - remaining: int
+// - reset: int (seconds)
+// - retry_after: int (seconds, only on throttled responses)
+func getRateLimitFromHeader(t *testing.T, header http.Header) map[string]any {
 	t.Helper()
 
 	r := require.New(t)
 
-	r.Contains(responseBody, "id")
-	r.IsType("", responseBody["id"])
+	if header.Get(RateLimitLimitHeader) == "" {
+		return nil
+	}
 
-	return responseBody["id"].(string)
+	rateLimit := make(map[string]any)
+	for key, name := range map[string]string{
+		"limit":       RateLimitLimitHeader,
+		"remaining":   RateLimitRemainingHeader,
+		"reset":       RateLimitResetHeader,
+		"retry_after": RetryAfterHeader,
+	} {
+		val := header.Get(name)
+		if val == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(val)
+		r.NoError(err, "header '%s' should be an integer", name)
+		rateLimit[key] = n
+	}
+
+	return rateLimit
 }
 
-// getExpectedStatus extracts the expected HTTP status from the extra parameters.
-func getExpectedStatus(t *testing.T, extraParams map[string]any) string {
+// assertRegistrationShouldBeThrottled asserts that the HTTP response indicates
+// the request was rejected by the rate limiter.
+func assertRegistrationShouldBeThrottled(t *testing.T, result map[string]any, extraParams map[string]any, logs *LogRecorder) {
 	t.Helper()
 
 	r := require.New(t)
 
-	r.Contains(extraParams, "http_response")
-	httpResponse, ok := extraParams["http_response"].(map[string]any)
-	r.True(ok)
-	r.Contains(httpResponse, "status")
-	status, ok := httpResponse["status"].(string)
-	r.True(ok)
+	r.Equal(http.StatusTooManyRequests, result["status_code"])
+	r.Equal(http.StatusText(http.StatusTooManyRequests), result["status"])
+	r.Empty(result["id"])
+
+	r.Contains(result, "rate_limit", "throttled responses should carry the rate limiting headers")
+	rateLimit := result["rate_limit"].(map[string]any)
+	r.Equal(0, rateLimit["remaining"])
+	r.Contains(rateLimit, "retry_after")
+	r.Positive(rateLimit["retry_after"])
+
+	expected, _ := driverdata.BindOptionalKey[map[string]int](t, extraParams, "rate_limit")
+	for key, val := range expected {
+		r.Equal(val, rateLimit[key], "unexpected rate limit '%s'", key)
+	}
 
-	return status
+	assertRequestShouldBeTraceable(t, result, logs)
 }
 
-// getExpectedStatusCode extracts the expected HTTP status code from the extra parameters.
-func getExpectedStatusCode(t *testing.T, extraParams map[string]any) int {
+// assertRequestShouldBeTraceable asserts that the response carries a request
+// ID and, when a log recorder is available, that an access log record was
+// emitted for that request with the same status code.
+func assertRequestShouldBeTraceable(t *testing.T, result map[string]any, logs *LogRecorder) {
 	t.Helper()
 
 	r := require.New(t)
 
-	r.Contains(extraParams, "http_response")
-	httpResponse, ok := extraParams["http_response"].(map[string]any)
-	r.True(ok)
-	r.Contains(httpResponse, "status_code")
-	statusCode, ok := httpResponse["status_code"].(int)
-	r.True(ok)
+	r.Contains(result, "request_id")
+	r.IsType("", result["request_id"])
+	requestID := result["request_id"].(string)
+	r.NotEmpty(requestID, "response should carry the %s header", RequestIDHeader)
+
+	if logs == nil {
+		return
+	}
+
+	records := logs.Find("http request", map[string]any{"request_id": requestID})
+	r.Len(records, 1, "one access log record should be emitted for request '%s'", requestID)
+	r.EqualValues(result["status_code"], records[0].Attrs["status"])
+}
+
+// assertPanicShouldBeLogged asserts that a recovered panic was logged for the
+// request that produced `result`.
+func assertPanicShouldBeLogged(t *testing.T, result map[string]any, logs *LogRecorder) {
+	t.Helper()
+
+	r := require.New(t)
+
+	r.NotNil(logs, "a log recorder is required to assert on panics")
+
+	requestID, _ := result["request_id"].(string)
+	r.NotEmpty(requestID)
+
+	records := logs.Find("panic recovered", map[string]any{"request_id": requestID})
+	r.Len(records, 1, "the panic should be logged for request '%s'", requestID)
+	r.Equal(slog.LevelError, records[0].Level)
+	r.NotEmpty(records[0].Attrs["stack"])
+}
+
+func getIDFromResponseBody(t *testing.T, responseBody map[string]any) string {
+	t.Helper()
+
+	r := require.New(t)
+
+	r.Contains(responseBody, "id")
+	r.IsType("", responseBody["id"])
+
+	return responseBody["id"].(string)
+}
+
+// expectedHTTPRequest is the shape of the `http_request` extra parameter.
+type expectedHTTPRequest struct {
+	Headers map[string]string `driver:"headers"`
+}
 
-	return statusCode
+// expectedHTTPResponse is the shape of the `http_response` extra parameter.
+type expectedHTTPResponse struct {
+	StatusCode int    `driver:"status_code,required"`
+	Status     string `driver:"status,required"`
 }
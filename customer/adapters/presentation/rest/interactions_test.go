@@ -0,0 +1,144 @@
+//go:build test
+
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordRegistration records a registration request answered with
+// `statusCode` and `responseBody`.
+func recordRegistration(rec *InteractionRecorder, statusCode int, responseBody string) {
+	req := httptest.NewRequest(http.MethodPost, "/customers", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer a-token")
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(RequestIDHeader, "a-request-id")
+
+	rec.Record(req, []byte(`{"name": "John Doe", "phone": "+55 11 99999-9999"}`), statusCode, header, []byte(responseBody))
+}
+
+func TestInteractionRecorder(t *testing.T) {
+	t.Run("should find no difference with the recorded interactions", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "case.json")
+
+		rec := newInteractionRecorder(InteractionsRecord, golden)
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0001"}`)
+		require.NoError(t, rec.save())
+
+		rec = newInteractionRecorder(InteractionsCompare, golden)
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0002"}`)
+
+		diff, err := rec.compare()
+		require.NoError(t, err)
+		require.Empty(t, diff, "only the shape of the bodies should be compared")
+	})
+
+	t.Run("should describe the changes of status, headers and body shape", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "case.json")
+
+		rec := newInteractionRecorder(InteractionsRecord, golden)
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0001"}`)
+		require.NoError(t, rec.save())
+
+		rec = newInteractionRecorder(InteractionsCompare, golden)
+		recordRegistration(rec, http.StatusConflict, `{"error": "duplicated", "fields": ["name"]}`)
+
+		diff, err := rec.compare()
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			`[0].response.body.error: added ("string")`,
+			`[0].response.body.fields: added (["string"])`,
+			`[0].response.body.id: removed (was "string")`,
+			`[0].response.status_code: 409 instead of 201`,
+		}, diff)
+	})
+
+	t.Run("should describe the missing interactions", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "case.json")
+
+		rec := newInteractionRecorder(InteractionsRecord, golden)
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0001"}`)
+		require.NoError(t, rec.save())
+
+		rec = newInteractionRecorder(InteractionsCompare, golden)
+
+		diff, err := rec.compare()
+		require.NoError(t, err)
+		require.Equal(t, []string{"interactions: 0 items instead of 1"}, diff)
+	})
+
+	t.Run("should report a missing golden file as a difference", func(t *testing.T) {
+		rec := newInteractionRecorder(InteractionsCompare, filepath.Join(t.TempDir(), "missing.json"))
+
+		diff, err := rec.compare()
+		require.NoError(t, err)
+		require.Len(t, diff, 1)
+	})
+
+	t.Run("should reject a malformed golden file", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "case.json")
+		require.NoError(t, os.WriteFile(golden, []byte("not json"), 0o644))
+
+		_, err := newInteractionRecorder(InteractionsCompare, golden).compare()
+		require.ErrorContains(t, err, "malformed golden file")
+	})
+
+	t.Run("should hide the values of the volatile headers", func(t *testing.T) {
+		golden := filepath.Join(t.TempDir(), "case.json")
+
+		rec := newInteractionRecorder(InteractionsRecord, golden)
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0001"}`)
+
+		require.Equal(t, map[string]string{
+			"Authorization": volatileValue,
+			"Content-Type":  "application/json",
+		}, rec.interactions[0].Request.Headers)
+		require.Equal(t, map[string]string{
+			"Content-Type":  "application/json",
+			RequestIDHeader: volatileValue,
+		}, rec.interactions[0].Response.Headers)
+	})
+
+	t.Run("should do nothing when nil", func(t *testing.T) {
+		var rec *InteractionRecorder
+
+		recordRegistration(rec, http.StatusCreated, `{"id": "CUST-0001"}`)
+	})
+}
+
+func TestBodyShape(t *testing.T) {
+	for title, tc := range map[string]struct {
+		body     string
+		expected any
+	}{
+		"when the body is empty":    {body: " ", expected: nil},
+		"when the body is not JSON": {body: "Not Found", expected: "text"},
+		"when the body is an object": {
+			body: `{"id": "CUST-0001", "count": 1, "ok": true, "none": null, "tags": ["a", 2]}`,
+			expected: map[string]any{
+				"id": "string", "count": "number", "ok": "boolean", "none": "null", "tags": []any{"string", "number"},
+			},
+		},
+	} {
+		t.Run(title, func(t *testing.T) {
+			require.Equal(t, tc.expected, bodyShape([]byte(tc.body)))
+		})
+	}
+}
+
+func TestGoldenInteractionsPath(t *testing.T) {
+	require.Equal(t,
+		filepath.Join(InteractionsDir, "TestRegister", "with_system_variant_sqlite-rest", "when_is_a_person__01_.json"),
+		goldenInteractionsPath("TestRegister/with_system_variant_sqlite-rest/when_is_a_person_(#01)"),
+	)
+}
@@ -0,0 +1,474 @@
+//go:build test
+
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// InteractionMode tells what an InteractionRecorder does with the HTTP
+// interactions of a test case once the test ends.
+type InteractionMode string
+
+// Modes of the InteractionRecorder.
+const (
+	// InteractionsOff only logs the interactions of the failing test cases.
+	InteractionsOff InteractionMode = ""
+
+	// InteractionsRecord writes the interactions of every passing test case
+	// to its golden file.
+	InteractionsRecord InteractionMode = "record"
+
+	// InteractionsCompare fails the test cases whose interactions differ from
+	// their golden files.
+	InteractionsCompare InteractionMode = "compare"
+
+	// InteractionsUpdate compares the interactions like InteractionsCompare,
+	// but rewrites the differing golden files instead of failing.
+	InteractionsUpdate InteractionMode = "update"
+)
+
+// InteractionsEnv is the environment variable selecting the InteractionMode.
+// The `-gtd.interactions` flag takes precedence over it.
+const InteractionsEnv = "GTD_INTERACTIONS"
+
+// InteractionsDir is the directory of the golden files, relative to the
+// directory of the test package.
+const InteractionsDir = "testdata/interactions"
+
+var interactionsFlag = flag.String("gtd.interactions", "",
+	"what to do with the HTTP interactions of the REST variants: record, compare or update (env "+InteractionsEnv+")")
+
+// volatileHeaders are the headers whose values change from run to run. Only
+// their presence is recorded.
+var volatileHeaders = []string{
+	"Authorization",
+	"Content-Length",
+	"Date",
+	DefaultAPIKeyHeader,
+	RateLimitResetHeader,
+	RequestIDHeader,
+	RetryAfterHeader,
+}
+
+// volatileValue replaces the values of the volatile headers.
+const volatileValue = "*"
+
+// Interaction is a request/response pair as kept in the golden files. The
+// bodies are reduced to their shape: the JSON values are replaced by their
+// types, e.g. `{"id": "string"}`, so that generated IDs and timestamps don't
+// count as changes.
+type Interaction struct {
+	Request  InteractionRequest  `json:"request"`
+	Response InteractionResponse `json:"response"`
+}
+
+// InteractionRequest is the request of an Interaction.
+type InteractionRequest struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+}
+
+// InteractionResponse is the response of an Interaction.
+type InteractionResponse struct {
+	StatusCode int               `json:"status_code"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       any               `json:"body,omitempty"`
+}
+
+// InteractionRecorder captures the HTTP interactions of the REST test drivers
+// for a test case. Once the test ends, it records them into the golden file of
+// the case, or compares them to it, depending on its mode. In every mode, the
+// full interactions are logged when the test fails, so that the failures of
+// the REST variants show more than an assertion diff.
+//
+// The methods of InteractionRecorder do nothing on a nil recorder, so that the
+// test drivers can record unconditionally.
+type InteractionRecorder struct {
+	mode   InteractionMode
+	golden string
+
+	mu           sync.Mutex
+	interactions []Interaction
+
+	// raw are the interactions as exchanged, for the failure logs.
+	raw []string
+}
+
+// NewInteractionRecorder creates a recorder for the test case `t`, in the mode
+// selected by the `-gtd.interactions` flag or the GTD_INTERACTIONS
+// environment variable. The golden file of the case is named after the test,
+// under InteractionsDir.
+func NewInteractionRecorder(t *testing.T) *InteractionRecorder {
+	t.Helper()
+
+	mode, err := selectedInteractionMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := newInteractionRecorder(mode, goldenInteractionsPath(t.Name()))
+
+	t.Cleanup(func() { rec.finish(t) })
+
+	return rec
+}
+
+// newInteractionRecorder creates a recorder whose golden file is `golden`.
+func newInteractionRecorder(mode InteractionMode, golden string) *InteractionRecorder {
+	return &InteractionRecorder{mode: mode, golden: golden}
+}
+
+// selectedInteractionMode returns the mode selected by the flag or the
+// environment.
+func selectedInteractionMode() (InteractionMode, error) {
+	mode := InteractionMode(*interactionsFlag)
+	if mode == "" {
+		mode = InteractionMode(os.Getenv(InteractionsEnv))
+	}
+
+	switch mode {
+	case InteractionsOff, InteractionsRecord, InteractionsCompare, InteractionsUpdate:
+		return mode, nil
+	}
+
+	return "", fmt.Errorf("rest: unknown interaction mode '%s'; expected record, compare or update", mode)
+}
+
+// unsafePathChars are the characters of the test names not kept in the golden
+// file paths.
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// goldenInteractionsPath returns the golden file of the test `name`, with a
+// directory per subtest level.
+func goldenInteractionsPath(name string) string {
+	segments := strings.Split(name, "/")
+	for i, s := range segments {
+		segments[i] = unsafePathChars.ReplaceAllString(s, "_")
+	}
+
+	return filepath.Join(InteractionsDir, filepath.Join(segments...)+".json")
+}
+
+// Record captures an interaction: the request `req` sent with `requestBody`
+// and the response it got.
+func (rec *InteractionRecorder) Record(
+	req *http.Request,
+	requestBody []byte,
+	statusCode int,
+	header http.Header,
+	responseBody []byte,
+) {
+	if rec == nil {
+		return
+	}
+
+	interaction := Interaction{
+		Request: InteractionRequest{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Headers: headerShape(req.Header),
+			Body:    bodyShape(requestBody),
+		},
+		Response: InteractionResponse{
+			StatusCode: statusCode,
+			Headers:    headerShape(header),
+			Body:       bodyShape(responseBody),
+		},
+	}
+
+	var raw strings.Builder
+	fmt.Fprintf(&raw, "%s %s\n", req.Method, req.URL.Path)
+	_ = req.Header.Write(&raw)
+	fmt.Fprintf(&raw, "\n%s\n\n%d %s\n", requestBody, statusCode, http.StatusText(statusCode))
+	_ = header.Write(&raw)
+	fmt.Fprintf(&raw, "\n%s", responseBody)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.interactions = append(rec.interactions, interaction)
+	rec.raw = append(rec.raw, raw.String())
+}
+
+// finish logs, records or compares the interactions once the test `t` ends.
+func (rec *InteractionRecorder) finish(t *testing.T) {
+	failed := t.Failed()
+	if failed {
+		rec.logInteractions(t)
+	}
+
+	switch rec.mode {
+	case InteractionsRecord:
+		if failed {
+			t.Logf("the interactions of the failed test are not recorded to '%s'", rec.golden)
+			return
+		}
+
+		if err := rec.save(); err != nil {
+			t.Error(err)
+		}
+
+	case InteractionsCompare, InteractionsUpdate:
+		diff, err := rec.compare()
+		switch {
+		case err != nil:
+			t.Error(err)
+
+		case len(diff) == 0:
+			// Nothing changed.
+
+		case rec.mode == InteractionsUpdate && !failed:
+			if err := rec.save(); err != nil {
+				t.Error(err)
+				return
+			}
+			t.Logf("updated the interactions of '%s':\n%s", rec.golden, strings.Join(diff, "\n"))
+
+		default:
+			t.Errorf("the interactions differ from '%s' (run with -gtd.interactions=update to accept them):\n%s",
+				rec.golden, strings.Join(diff, "\n"))
+			if !failed {
+				rec.logInteractions(t)
+			}
+		}
+	}
+}
+
+// logInteractions logs the interactions as exchanged.
+func (rec *InteractionRecorder) logInteractions(t *testing.T) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	for i, raw := range rec.raw {
+		t.Logf("HTTP interaction [%d]:\n%s", i, raw)
+	}
+}
+
+// save writes the interactions to the golden file.
+func (rec *InteractionRecorder) save() error {
+	rec.mu.Lock()
+	data, err := json.MarshalIndent(rec.interactionsOrEmpty(), "", "  ")
+	rec.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rec.golden), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(rec.golden, append(data, '\n'), 0o644)
+}
+
+// compare describes the differences between the interactions and the golden
+// file, one per line. A missing golden file is a difference.
+func (rec *InteractionRecorder) compare() ([]string, error) {
+	data, err := os.ReadFile(rec.golden)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{"no interactions were recorded for the test"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var want any
+	if err := json.Unmarshal(data, &want); err != nil {
+		return nil, fmt.Errorf("rest: malformed golden file '%s': %w", rec.golden, err)
+	}
+
+	rec.mu.Lock()
+	data, err = json.Marshal(rec.interactionsOrEmpty())
+	rec.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var got any
+	if err := json.Unmarshal(data, &got); err != nil {
+		return nil, err
+	}
+
+	return diffJSON("", want, got), nil
+}
+
+// interactionsOrEmpty returns the interactions, never nil so that the golden
+// files of the cases without interactions hold an empty list.
+func (rec *InteractionRecorder) interactionsOrEmpty() []Interaction {
+	if rec.interactions == nil {
+		return []Interaction{}
+	}
+
+	return rec.interactions
+}
+
+//
+// Shapes
+
+// headerShape flattens the header, hiding the values of the volatile headers.
+func headerShape(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	shape := make(map[string]string, len(header))
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(volatileHeaders, name) {
+			shape[name] = volatileValue
+		} else {
+			shape[name] = strings.Join(values, ", ")
+		}
+	}
+
+	return shape
+}
+
+// bodyShape returns the shape of a JSON body, "text" for the other non-empty
+// bodies, and nil for the empty ones.
+func bodyShape(body []byte) any {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "text"
+	}
+
+	return jsonShape(v)
+}
+
+// jsonShape replaces the JSON values found in `v` by their types.
+func jsonShape(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		shape := make(map[string]any, len(v))
+		for key, val := range v {
+			shape[key] = jsonShape(val)
+		}
+		return shape
+
+	case []any:
+		shape := make([]any, len(v))
+		for i, val := range v {
+			shape[i] = jsonShape(val)
+		}
+		return shape
+
+	case string:
+		return "string"
+
+	case float64:
+		return "number"
+
+	case bool:
+		return "boolean"
+
+	default:
+		return "null"
+	}
+}
+
+// diffJSON describes the differences between the decoded JSON values `want`
+// and `got`, found at `path`.
+func diffJSON(path string, want, got any) []string {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			break
+		}
+
+		var diff []string
+		for _, key := range sortedKeys(w, g) {
+			wv, inWant := w[key]
+			gv, inGot := g[key]
+
+			keyPath := strings.TrimPrefix(path+"."+key, ".")
+			switch {
+			case !inGot:
+				diff = append(diff, fmt.Sprintf("%s: removed (was %s)", keyPath, compactJSON(wv)))
+			case !inWant:
+				diff = append(diff, fmt.Sprintf("%s: added (%s)", keyPath, compactJSON(gv)))
+			default:
+				diff = append(diff, diffJSON(keyPath, wv, gv)...)
+			}
+		}
+		return diff
+
+	case []any:
+		g, ok := got.([]any)
+		if !ok {
+			break
+		}
+
+		var diff []string
+		if len(w) != len(g) {
+			diff = append(diff, fmt.Sprintf("%s: %d items instead of %d", cmpPath(path), len(g), len(w)))
+		}
+		for i := range min(len(w), len(g)) {
+			diff = append(diff, diffJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return diff
+	}
+
+	if compactJSON(want) == compactJSON(got) {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("%s: %s instead of %s", cmpPath(path), compactJSON(got), compactJSON(want))}
+}
+
+// sortedKeys returns the keys of both maps, sorted.
+func sortedKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, found := a[key]; !found {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// compactJSON formats a decoded JSON value for the differences.
+func compactJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+// cmpPath names the root of the compared values.
+func cmpPath(path string) string {
+	if path == "" {
+		return "interactions"
+	}
+
+	return path
+}
@@ -0,0 +1,207 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jwtAlgorithms maps the supported JWT signing algorithms to their hashes.
+// Only HMAC algorithms are supported, as keys are shared secrets.
+var jwtAlgorithms = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// JWTAuthenticator is an Authenticator verifying HMAC-signed JSON Web Tokens
+// sent as `Authorization: Bearer <token>` against locally held keys.
+//
+// The token must carry an `exp` claim. Roles are read from the `roles` claim
+// (a list of strings) and from the `scope` claim (a space separated list).
+type JWTAuthenticator struct {
+	// Keys maps key IDs (the `kid` header) to their secrets. Tokens without a
+	// `kid` are accepted only when there is a single key.
+	Keys map[string][]byte
+
+	// Issuer and Audience, when set, must match the `iss` and `aud` claims.
+	Issuer   string
+	Audience string
+
+	// Leeway tolerates clock skew when checking `exp` and `nbf`.
+	Leeway time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// jwtHeader is the JOSE header of a token.
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jwtClaims are the claims understood by the JWTAuthenticator.
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *int64      `json:"exp"`
+	NotBefore *int64      `json:"nbf"`
+	Roles     []string    `json:"roles"`
+	Scope     string      `json:"scope"`
+}
+
+// jwtAudience accepts the `aud` claim both as a string and as a list.
+type jwtAudience []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+// Authenticate implements the Authenticator interface.
+func (ja *JWTAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, nil
+	}
+
+	claims, err := ja.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	roles := slices.Clone(claims.Roles)
+	if claims.Scope != "" {
+		roles = append(roles, strings.Fields(claims.Scope)...)
+	}
+
+	return &Identity{Subject: claims.Subject, Roles: roles}, nil
+}
+
+// verify checks the token signature and its registered claims.
+func (ja *JWTAuthenticator) verify(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+
+	newHash, supported := jwtAlgorithms[header.Algorithm]
+	if !supported {
+		return nil, fmt.Errorf("unsupported algorithm '%s'", header.Algorithm)
+	}
+
+	key, err := ja.key(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature")
+	}
+
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+
+	if err := ja.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// key returns the secret for the key ID `kid`.
+func (ja *JWTAuthenticator) key(kid string) ([]byte, error) {
+	if kid == "" && len(ja.Keys) == 1 {
+		for _, key := range ja.Keys {
+			return key, nil
+		}
+	}
+
+	key, found := ja.Keys[kid]
+	if !found {
+		return nil, fmt.Errorf("unknown key '%s'", kid)
+	}
+
+	return key, nil
+}
+
+// validateClaims checks the time, issuer and audience claims.
+func (ja *JWTAuthenticator) validateClaims(claims *jwtClaims) error {
+	now := time.Now()
+	if ja.Now != nil {
+		now = ja.Now()
+	}
+
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("token has no expiration")
+	}
+
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(ja.Leeway)) {
+		return fmt.Errorf("token expired")
+	}
+
+	if claims.NotBefore != nil && now.Before(time.Unix(*claims.NotBefore, 0).Add(-ja.Leeway)) {
+		return fmt.Errorf("token not valid yet")
+	}
+
+	if ja.Issuer != "" && claims.Issuer != ja.Issuer {
+		return fmt.Errorf("unexpected issuer '%s'", claims.Issuer)
+	}
+
+	if ja.Audience != "" && !slices.Contains(claims.Audience, ja.Audience) {
+		return fmt.Errorf("token not intended for '%s'", ja.Audience)
+	}
+
+	if claims.Subject == "" {
+		return fmt.Errorf("token has no subject")
+	}
+
+	return nil
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a token.
+func decodeJWTSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
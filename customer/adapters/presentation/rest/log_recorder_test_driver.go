@@ -0,0 +1,166 @@
+//go:build test
+
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// LogRecord is a log record captured by a LogRecorder, with its attributes
+// resolved into a flat map. Attributes inside groups are keyed as
+// `group.key`.
+type LogRecord struct {
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+// LogRecorder captures the records emitted through its logger so that test
+// drivers can assert on them. It is safe for concurrent use.
+type LogRecorder struct {
+	mu      sync.Mutex
+	records []LogRecord
+}
+
+// NewLogRecorder creates a new, empty LogRecorder.
+func NewLogRecorder() *LogRecorder {
+	return &LogRecorder{}
+}
+
+// Logger returns a logger writing into the recorder.
+func (lr *LogRecorder) Logger() *slog.Logger {
+	return slog.New(&logRecorderHandler{recorder: lr})
+}
+
+// Records returns a copy of all the records captured so far.
+func (lr *LogRecorder) Records() []LogRecord {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	records := make([]LogRecord, len(lr.records))
+	copy(records, lr.records)
+
+	return records
+}
+
+// Find returns the records with the given message and whose attributes
+// contain all the given ones.
+func (lr *LogRecorder) Find(message string, attrs map[string]any) []LogRecord {
+	var found []LogRecord
+
+	for _, rec := range lr.Records() {
+		if rec.Message != message {
+			continue
+		}
+
+		if recordHasAttrs(rec, attrs) {
+			found = append(found, rec)
+		}
+	}
+
+	return found
+}
+
+// append stores a new record.
+func (lr *LogRecorder) append(rec LogRecord) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	lr.records = append(lr.records, rec)
+}
+
+// recordHasAttrs checks if the record carries all the given attributes.
+func recordHasAttrs(rec LogRecord, attrs map[string]any) bool {
+	for key, val := range attrs {
+		if got, ok := rec.Attrs[key]; !ok || got != val {
+			return false
+		}
+	}
+
+	return true
+}
+
+// logRecorderHandler is the slog.Handler feeding a LogRecorder.
+type logRecorderHandler struct {
+	recorder *LogRecorder
+	attrs    []slog.Attr
+	group    string
+}
+
+// Enabled records every level.
+func (h *logRecorderHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle converts the record into a LogRecord and stores it.
+func (h *logRecorderHandler) Handle(_ context.Context, r slog.Record) error {
+	rec := LogRecord{
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   make(map[string]any),
+	}
+
+	for _, a := range h.attrs {
+		addAttr(rec.Attrs, "", a)
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(rec.Attrs, h.group, a)
+		return true
+	})
+
+	h.recorder.append(rec)
+
+	return nil
+}
+
+// WithAttrs returns a handler which adds `attrs` to every record.
+func (h *logRecorderHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]slog.Attr{}, h.attrs...)
+
+	for _, a := range attrs {
+		if h.group != "" {
+			a.Key = h.group + "." + a.Key
+		}
+		clone.attrs = append(clone.attrs, a)
+	}
+
+	return &clone
+}
+
+// WithGroup returns a handler which nests the following attributes under
+// `name`.
+func (h *logRecorderHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+
+	if h.group != "" {
+		clone.group = h.group + "." + name
+	} else {
+		clone.group = name
+	}
+
+	return &clone
+}
+
+// addAttr resolves and flattens the attribute `a` into `dst`.
+func addAttr(dst map[string]any, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	key := a.Key
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			addAttr(dst, key, ga)
+		}
+
+		return
+	}
+
+	dst[key] = a.Value.Any()
+}
@@ -0,0 +1,211 @@
+package rest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+// RequestIDHeader is the HTTP header carrying the request ID, both on incoming
+// requests (when the caller provides one) and on every response.
+const RequestIDHeader = "X-Request-Id"
+
+// maximumRequestIDLength limits the size of caller-provided request IDs.
+const maximumRequestIDLength = 128
+
+// Middleware decorates an http.Handler with a cross-cutting behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps `handler` with the given middlewares. The first middleware is the
+// outermost one, so it sees the request first and the response last.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// DefaultMiddlewares returns the middleware chain used by the REST adapter
+// when none is given: request ID propagation, access logging and panic
+// recovery, all logging to `logger`.
+func DefaultMiddlewares(logger *slog.Logger) []Middleware {
+	return []Middleware{
+		RequestID(),
+		AccessLog(logger),
+		Recover(logger),
+	}
+}
+
+//
+// Request ID
+
+// requestIDKey is the context key holding the request ID.
+type requestIDKey struct{}
+
+// RequestID returns a middleware that assigns an ID to each request. A valid
+// ID sent by the caller in the `X-Request-Id` header is kept, otherwise a new
+// random one is generated. The ID is stored in the request context and echoed
+// in the response headers.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !isValidRequestID(id) {
+				id = newRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID stored in `ctx` by the RequestID
+// middleware, or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// isValidRequestID checks if a caller-provided request ID is safe to be
+// propagated to logs and response headers.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maximumRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID generates a random 128-bit request ID in hexadecimal.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+
+	return hex.EncodeToString(b[:])
+}
+
+//
+// Access Log
+
+// AccessLog returns a middleware that emits one structured log record per
+// request, after the response is written.
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(sw, r)
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "http request",
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.Status()),
+				slog.Int("bytes", sw.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
+		})
+	}
+}
+
+// statusRecorder is an http.ResponseWriter which keeps track of the status
+// code and the number of bytes written.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int
+}
+
+// WriteHeader records the status code before delegating.
+func (sw *statusRecorder) WriteHeader(statusCode int) {
+	if sw.status == 0 {
+		sw.status = statusCode
+	}
+
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the number of bytes written before delegating.
+func (sw *statusRecorder) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+
+	return n, err
+}
+
+// Status returns the status code sent to the client.
+func (sw *statusRecorder) Status() int {
+	if sw.status == 0 {
+		return http.StatusOK
+	}
+
+	return sw.status
+}
+
+// Unwrap allows http.ResponseController to reach the underlying writer.
+func (sw *statusRecorder) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+//
+// Panic Recovery
+
+// Recover returns a middleware that recovers from panics in the handlers
+// below it, logs them with their stack trace and answers with the same
+// generic system error used for any other internal failure.
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &statusRecorder{ResponseWriter: w}
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				// The server uses this panic to abort the response on purpose.
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				logger.LogAttrs(r.Context(), slog.LevelError, "panic recovered",
+					slog.String("request_id", RequestIDFromContext(r.Context())),
+					slog.String("panic", fmt.Sprint(rec)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				if sw.status == 0 {
+					writeError(sw, customer.ErrSystem.Error(), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+//go:build test
+
+package rest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/stretchr/testify/require"
+)
+
+// panickingRepository is a customer.CustomerRepository which panics on every
+// call, like the PoC repositories do when their databases are unusable.
+type panickingRepository struct{}
+
+func (panickingRepository) Save(*customer.Customer) error {
+	panic("database exploded")
+}
+
+func TestMiddlewares(t *testing.T) {
+	request := map[string]any{
+		"name":  "John Due",
+		"email": "john.due@somecompany.com",
+		"phone": "+1 234 567 890",
+	}
+
+	t.Run("should recover from panics with a system error", func(t *testing.T) {
+		logs := NewLogRecorder()
+		service := customer.NewCustomerService(panickingRepository{})
+		td := NewCustomerRESTAPIHandlerTestDriver(NewCustomerRESTAPIHandler(service, DefaultMiddlewares(logs.Logger())...)).
+			WithLogRecorder(logs)
+
+		extraArgs := map[string]any{
+			"http_response": map[string]any{"status_code": http.StatusInternalServerError, "status": "Internal Server Error"},
+		}
+
+		result := td.ActTryToRegisterACustomer(t, request, extraArgs)
+
+		td.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, customer.ErrSystem.Error())
+		td.AssertPanicShouldBeLogged(t, result)
+	})
+
+	t.Run("should propagate the caller request ID", func(t *testing.T) {
+		logs := NewLogRecorder()
+		service := customer.NewCustomerService(panickingRepository{})
+		td := NewCustomerRESTAPIHandlerTestDriver(NewCustomerRESTAPIHandler(service, DefaultMiddlewares(logs.Logger())...)).
+			WithLogRecorder(logs)
+
+		extraArgs := map[string]any{
+			"http_request":  map[string]any{"headers": map[string]any{RequestIDHeader: "caller-id-42"}},
+			"http_response": map[string]any{"status_code": http.StatusBadRequest, "status": "Bad Request"},
+		}
+
+		result := td.ActTryToRegisterACustomer(t, map[string]any{"name": "Jo"}, extraArgs)
+
+		td.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, customer.ErrValidation.Error())
+		require.Equal(t, "caller-id-42", result["request_id"])
+	})
+
+	t.Run("should replace invalid caller request IDs", func(t *testing.T) {
+		service := customer.NewCustomerService(panickingRepository{})
+		td := NewCustomerRESTAPIHandlerTestDriver(NewCustomerRESTAPIHandler(service, RequestID()))
+
+		extraArgs := map[string]any{
+			"http_request":  map[string]any{"headers": map[string]any{RequestIDHeader: "bad id\twith spaces"}},
+			"http_response": map[string]any{"status_code": http.StatusBadRequest, "status": "Bad Request"},
+		}
+
+		result := td.ActTryToRegisterACustomer(t, map[string]any{"name": "Jo"}, extraArgs)
+
+		td.AssertRegistrationShouldFail(t, result, extraArgs)
+		require.Len(t, result["request_id"], 32)
+	})
+}
@@ -0,0 +1,259 @@
+package rest
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rate limiting headers, following the IETF `RateLimit` header fields draft.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// DefaultAPIKeyHeader is the header used to identify API clients when
+// RateLimitConfig.APIKeyHeader is empty.
+const DefaultAPIKeyHeader = "X-Api-Key"
+
+// maximumBuckets is the number of buckets kept by each bucket set. Past it, the
+// least recently used bucket is evicted, so that clients rotating bogus API
+// keys can't grow the set without bound.
+const maximumBuckets = 10_000
+
+// Rate is a token bucket limit allowing bursts of up to `Limit` requests, with
+// the whole bucket being refilled every `Period`.
+type Rate struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRate parses a rate in the `<limit>/<period>` format, e.g. `10/1m`.
+func ParseRate(s string) (Rate, error) {
+	limit, period, found := strings.Cut(s, "/")
+	if !found {
+		return Rate{}, fmt.Errorf("invalid rate '%s': expected <limit>/<period>", s)
+	}
+
+	l, err := strconv.Atoi(limit)
+	if err != nil || l < 0 {
+		return Rate{}, fmt.Errorf("invalid rate '%s': invalid limit", s)
+	}
+
+	p, err := time.ParseDuration(period)
+	if err != nil || p <= 0 {
+		return Rate{}, fmt.Errorf("invalid rate '%s': invalid period", s)
+	}
+
+	return Rate{Limit: l, Period: p}, nil
+}
+
+// String formats the rate in the format accepted by ParseRate.
+func (r Rate) String() string {
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
+
+// enabled checks if the rate actually limits anything.
+func (r Rate) enabled() bool {
+	return r.Limit > 0 && r.Period > 0
+}
+
+// RateLimitConfig configures the RateLimit middleware. A zero Rate disables
+// the corresponding limit.
+type RateLimitConfig struct {
+	// PerIP limits the requests of each client IP address.
+	PerIP Rate
+
+	// PerAPIKey limits the requests of each API key, for the requests that
+	// carry one.
+	PerAPIKey Rate
+
+	// APIKeyHeader is the header carrying the API key. Defaults to
+	// DefaultAPIKeyHeader.
+	APIKeyHeader string
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// RateLimit returns a middleware that throttles requests using one token
+// bucket per client IP and one per API key. Throttled requests are answered
+// with `429 Too Many Requests` and a `Retry-After` header. Every response
+// carries the `RateLimit-*` headers of the most restrictive bucket.
+func RateLimit(cfg RateLimitConfig) Middleware {
+	if cfg.APIKeyHeader == "" {
+		cfg.APIKeyHeader = DefaultAPIKeyHeader
+	}
+
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+
+	perIP := newBucketSet(cfg.PerIP)
+	perAPIKey := newBucketSet(cfg.PerAPIKey)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			now := cfg.Now()
+
+			var decisions []rateDecision
+
+			if cfg.PerIP.enabled() {
+				decisions = append(decisions, perIP.take(clientIP(r), now))
+			}
+
+			if key := r.Header.Get(cfg.APIKeyHeader); key != "" && cfg.PerAPIKey.enabled() {
+				decisions = append(decisions, perAPIKey.take(key, now))
+			}
+
+			if len(decisions) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			decision := mostRestrictive(decisions)
+
+			w.Header().Set(RateLimitLimitHeader, strconv.Itoa(decision.limit))
+			w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(decision.remaining))
+			w.Header().Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(decision.reset)))
+
+			if !decision.allowed {
+				w.Header().Set(RetryAfterHeader, strconv.Itoa(ceilSeconds(decision.retryAfter)))
+				writeError(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP extracts the IP address of the client from the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// ceilSeconds rounds `d` up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+//
+// Token Buckets
+
+// rateDecision is the outcome of taking a token from a bucket.
+type rateDecision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// mostRestrictive returns the decision to be reported to the client: a denial
+// if there is one, otherwise the one with fewer remaining requests.
+func mostRestrictive(decisions []rateDecision) rateDecision {
+	result := decisions[0]
+
+	for _, d := range decisions[1:] {
+		switch {
+		case !d.allowed && (result.allowed || d.retryAfter > result.retryAfter):
+			result = d
+
+		case d.allowed == result.allowed && d.remaining < result.remaining:
+			result = d
+		}
+	}
+
+	return result
+}
+
+// bucket is the token bucket of a client key.
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// bucketSet keeps one bucket per client key, up to maximumBuckets. It is safe
+// for concurrent use.
+type bucketSet struct {
+	mu      sync.Mutex
+	rate    Rate
+	buckets map[string]*list.Element
+
+	// lru holds the buckets, the most recently used first.
+	lru *list.List
+}
+
+// newBucketSet creates an empty set of buckets limited by `rate`.
+func newBucketSet(rate Rate) *bucketSet {
+	return &bucketSet{
+		rate:    rate,
+		buckets: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// take refills the bucket of `key` up to `now` and tries to take a token from
+// it.
+func (bs *bucketSet) take(key string, now time.Time) rateDecision {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	capacity := float64(bs.rate.Limit)
+	perToken := bs.rate.Period / time.Duration(bs.rate.Limit)
+
+	elem, found := bs.buckets[key]
+	if found {
+		bs.lru.MoveToFront(elem)
+	} else {
+		if bs.lru.Len() >= maximumBuckets {
+			bs.evictLeastRecentlyUsed()
+		}
+
+		elem = bs.lru.PushFront(&bucket{key: key, tokens: capacity, last: now})
+		bs.buckets[key] = elem
+	}
+
+	b := elem.Value.(*bucket)
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(capacity, b.tokens+elapsed.Seconds()/perToken.Seconds())
+		b.last = now
+	}
+
+	decision := rateDecision{limit: bs.rate.Limit}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.allowed = true
+	} else {
+		decision.retryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	decision.remaining = int(b.tokens)
+	decision.reset = time.Duration((capacity - b.tokens) * float64(perToken))
+
+	return decision
+}
+
+// evictLeastRecentlyUsed removes the bucket used the longest time ago.
+func (bs *bucketSet) evictLeastRecentlyUsed() {
+	oldest := bs.lru.Back()
+	bs.lru.Remove(oldest)
+	delete(bs.buckets, oldest.Value.(*bucket).key)
+}
@@ -0,0 +1,151 @@
+//go:build test
+
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	rate, err := ParseRate("10/1m")
+	require.NoError(t, err)
+	require.Equal(t, Rate{Limit: 10, Period: time.Minute}, rate)
+	require.Equal(t, "10/1m0s", rate.String())
+
+	for _, invalid := range []string{"", "10", "ten/1m", "10/forever", "-1/1s", "10/0s"} {
+		_, err := ParseRate(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	// Invalid requests never reach the repository, so a nil one is enough.
+	service := customer.NewCustomerService(nil)
+	request := map[string]any{"name": "Jo"}
+	badRequest := map[string]any{"status_code": http.StatusBadRequest, "status": "Bad Request"}
+
+	newTestDriver := func(cfg RateLimitConfig) *CustomerRESTAPIHandlerTestDriver {
+		clock := NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		cfg.Now = clock.Now
+
+		logs := NewLogRecorder()
+		middlewares := append(DefaultMiddlewares(logs.Logger()), RateLimit(cfg))
+
+		return NewCustomerRESTAPIHandlerTestDriver(NewCustomerRESTAPIHandler(service, middlewares...)).
+			WithLogRecorder(logs).
+			WithClock(clock)
+	}
+
+	t.Run("should throttle each client IP", func(t *testing.T) {
+		td := newTestDriver(RateLimitConfig{PerIP: Rate{Limit: 2, Period: time.Minute}})
+		extraArgs := map[string]any{"http_response": badRequest}
+
+		for remaining := 1; remaining >= 0; remaining-- {
+			result := td.ActTryToRegisterACustomer(t, request, extraArgs)
+			td.AssertRegistrationShouldFail(t, result, extraArgs)
+			require.Equal(t, map[string]any{"limit": 2, "remaining": remaining, "reset": 30 * (2 - remaining)}, result["rate_limit"])
+		}
+
+		result := td.ActTryToRegisterACustomer(t, request, extraArgs)
+		td.AssertRegistrationShouldBeThrottled(t, result, map[string]any{
+			"rate_limit": map[string]any{"limit": 2, "retry_after": 30},
+		})
+
+		td.ArrangeTimeHasPassed(t, 20*time.Second)
+
+		result = td.ActTryToRegisterACustomer(t, request, extraArgs)
+		td.AssertRegistrationShouldBeThrottled(t, result, map[string]any{
+			"rate_limit": map[string]any{"retry_after": 10},
+		})
+
+		td.ArrangeTimeHasPassed(t, 10*time.Second)
+
+		result = td.ActTryToRegisterACustomer(t, request, extraArgs)
+		td.AssertRegistrationShouldFail(t, result, extraArgs)
+	})
+
+	t.Run("should throttle each API key independently", func(t *testing.T) {
+		td := newTestDriver(RateLimitConfig{PerAPIKey: Rate{Limit: 1, Period: time.Hour}})
+
+		withKey := func(key string) map[string]any {
+			return map[string]any{
+				"http_request":  map[string]any{"headers": map[string]any{DefaultAPIKeyHeader: key}},
+				"http_response": badRequest,
+			}
+		}
+
+		result := td.ActTryToRegisterACustomer(t, request, withKey("alice"))
+		td.AssertRegistrationShouldFail(t, result, withKey("alice"))
+
+		result = td.ActTryToRegisterACustomer(t, request, withKey("alice"))
+		td.AssertRegistrationShouldBeThrottled(t, result, map[string]any{
+			"rate_limit": map[string]any{"retry_after": 3600},
+		})
+
+		result = td.ActTryToRegisterACustomer(t, request, withKey("bob"))
+		td.AssertRegistrationShouldFail(t, result, withKey("bob"))
+
+		// Requests without a key are not limited by the API key buckets.
+		extraArgs := map[string]any{"http_response": badRequest}
+		result = td.ActTryToRegisterACustomer(t, request, extraArgs)
+		td.AssertRegistrationShouldFail(t, result, extraArgs)
+		require.NotContains(t, result, "rate_limit")
+	})
+	t.Run("should keep a bounded number of buckets when the API keys rotate", func(t *testing.T) {
+		td := newTestDriver(RateLimitConfig{PerAPIKey: Rate{Limit: 1, Period: time.Hour}})
+
+		withKey := func(key string) map[string]any {
+			return map[string]any{
+				"http_request":  map[string]any{"headers": map[string]any{DefaultAPIKeyHeader: key}},
+				"http_response": badRequest,
+			}
+		}
+
+		result := td.ActTryToRegisterACustomer(t, request, withKey("alice"))
+		td.AssertRegistrationShouldFail(t, result, withKey("alice"))
+
+		// A flood of bogus keys, interleaved with alice's requests so that her
+		// bucket stays among the most recently used ones.
+		for i := range 2 * maximumBuckets {
+			td.ActTryToRegisterACustomer(t, request, withKey(fmt.Sprintf("bogus-%d", i)))
+
+			if i%1000 == 0 {
+				td.ActTryToRegisterACustomer(t, request, withKey("alice"))
+			}
+		}
+
+		result = td.ActTryToRegisterACustomer(t, request, withKey("alice"))
+		td.AssertRegistrationShouldBeThrottled(t, result, map[string]any{
+			"rate_limit": map[string]any{"retry_after": 3600},
+		})
+
+		// The first bogus keys were evicted, and start over with a full bucket.
+		result = td.ActTryToRegisterACustomer(t, request, withKey("bogus-0"))
+		td.AssertRegistrationShouldFail(t, result, withKey("bogus-0"))
+	})
+}
+
+func TestBucketSet(t *testing.T) {
+	r := require.New(t)
+
+	bs := newBucketSet(Rate{Limit: 1, Period: time.Hour})
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 3 * maximumBuckets {
+		r.True(bs.take(fmt.Sprintf("bogus-%d", i), now).allowed)
+	}
+
+	r.Equal(maximumBuckets, bs.lru.Len())
+	r.Len(bs.buckets, maximumBuckets)
+
+	// The least recently used buckets were evicted, the others are kept.
+	r.False(bs.take(fmt.Sprintf("bogus-%d", 3*maximumBuckets-1), now).allowed)
+	r.True(bs.take("bogus-0", now).allowed)
+	r.Equal(maximumBuckets, bs.lru.Len())
+}
@@ -0,0 +1,103 @@
+//go:build test
+
+package rest
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+// Environment variables configuring the client-only variant.
+const (
+	// BaseURLEnv holds the base URL of an external server, e.g.
+	// `http://localhost:8080`. When set, the `remote-rest-client` variant
+	// drives that server instead of an in-process one.
+	BaseURLEnv = "GTD_BASE_URL"
+
+	// JWTKeyEnv holds the base64 encoded HMAC secret the external server
+	// accepts for the JWT key ID `test-key`. Without it, the tests signing
+	// tokens are skipped.
+	JWTKeyEnv = "GTD_JWT_KEY"
+)
+
+func init() {
+	customer.SUTHarness.Register(customer.PresentationLayer, "rest", func(t *testing.T, sut *customer.SUT) {
+		handler, logs, creds := newTestHandler(sut.Service)
+
+		sut.UpperLayerTD = NewCustomerRESTAPIHandlerTestDriver(handler).
+			WithLogRecorder(logs).
+			WithCredentials(creds).
+			WithInteractionRecorder(NewInteractionRecorder(t))
+	})
+
+	for _, server := range []struct {
+		name   string
+		useTLS bool
+	}{{"rest-http", false}, {"rest-https", true}} {
+		customer.SUTHarness.Register(customer.PresentationLayer, server.name, func(t *testing.T, sut *customer.SUT) {
+			handler, logs, creds := newTestHandler(sut.Service)
+
+			sut.UpperLayerTD = NewCustomerRESTAPIServerTestDriver(t, handler, server.useTLS).
+				WithLogRecorder(logs).
+				WithCredentials(creds).
+				WithInteractionRecorder(NewInteractionRecorder(t))
+		})
+	}
+
+	if baseURL := os.Getenv(BaseURLEnv); baseURL != "" {
+		registerClientOnlyVariant(baseURL)
+	}
+}
+
+// registerClientOnlyVariant registers the variant driving the external server
+// at `baseURL` through its REST API only, with the repository behind it.
+func registerClientOnlyVariant(baseURL string) {
+	customer.SUTHarness.RegisterExclusive(customer.RepositoryLayer, customer.RemoteRepository, func(_ *testing.T, sut *customer.SUT) {
+		sut.RepositoryTD = customer.NewRemoteRepositoryTestDriver(baseURL)
+	})
+
+	customer.SUTHarness.RegisterExclusive(customer.PresentationLayer, "rest-client", func(t *testing.T, sut *customer.SUT) {
+		sut.UpperLayerTD = NewCustomerRESTAPIClientTestDriver(baseURL, nil).
+			WithCredentials(newRemoteTestCredentials(t)).
+			WithInteractionRecorder(NewInteractionRecorder(t))
+	})
+}
+
+// newRemoteTestCredentials creates the test credentials of an external
+// server, which must accept the well-known API keys, and the JWT key in
+// JWTKeyEnv, if any. The default caller uses the writer API key when there is
+// no JWT key to sign its tokens.
+func newRemoteTestCredentials(t *testing.T) *TestCredentials {
+	t.Helper()
+
+	creds := NewTestCredentials()
+	creds.JWTKey = nil
+
+	secret := os.Getenv(JWTKeyEnv)
+	if secret == "" {
+		creds.DefaultCaller = map[string]any{"api_key": TestWriterAPIKey}
+		return creds
+	}
+
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("invalid %s: %v", JWTKeyEnv, err)
+	}
+
+	creds.JWTKey = key
+
+	return creds
+}
+
+// newTestHandler creates a handler with the default middlewares, recording its
+// logs and authenticating the test credentials.
+func newTestHandler(service *customer.CustomerService) (*CustomerRESTAPIHandler, *LogRecorder, *TestCredentials) {
+	logs := NewLogRecorder()
+	creds := NewTestCredentials()
+	middlewares := append(DefaultMiddlewares(logs.Logger()), creds.Middlewares()...)
+
+	return NewCustomerRESTAPIHandler(service, middlewares...), logs, creds
+}
@@ -2,6 +2,7 @@ package badgerpoc
 
 import (
 	"bytes"
+	"context"
 	"encoding/gob"
 	"errors"
 	"fmt"
@@ -30,6 +31,12 @@ var _ customer.CustomerRepository = (*BadgerCustomerRepository)(nil)
 func NewBadgerCustomerRepository() *BadgerCustomerRepository {
 	opts := badger.DefaultOptions("").WithInMemory(true)
 	opts.Logger = nil // Suppress verbose logging during tests
+	return NewBadgerCustomerRepositoryWithOptions(opts)
+}
+
+// NewBadgerCustomerRepositoryWithOptions creates and initializes a new
+// BadgerCustomerRepository with the given Badger options.
+func NewBadgerCustomerRepositoryWithOptions(opts badger.Options) *BadgerCustomerRepository {
 	db, err := badger.Open(opts)
 	if err != nil {
 		panic(fmt.Sprintf("failed to open badger database: %v", err))
@@ -86,6 +93,48 @@ func (r *BadgerCustomerRepository) Save(c *customer.Customer) error {
 	return nil
 }
 
+// Ping verifies that the Badger database is still open.
+func (r *BadgerCustomerRepository) Ping(ctx context.Context) error {
+	if r.db == nil || r.db.IsClosed() {
+		return customer.ErrSystem
+	}
+
+	return ctx.Err()
+}
+
+// List returns all the customers of the repository, ordered by ID.
+func (r *BadgerCustomerRepository) List() ([]*customer.Customer, error) {
+	if r.db == nil {
+		return nil, customer.ErrSystem
+	}
+
+	var customers []*customer.Customer
+	err := r.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.IteratorOptions{Prefix: prefixID})
+		defer it.Close()
+
+		// The keys are iterated in order, and all share the same prefix.
+		for it.Rewind(); it.Valid(); it.Next() {
+			c := &customer.Customer{}
+			err := it.Item().Value(func(val []byte) error {
+				return gob.NewDecoder(bytes.NewReader(val)).Decode(c)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode customer: %w", err)
+			}
+
+			customers = append(customers, c)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", customer.ErrSystem, err)
+	}
+
+	return customers, nil
+}
+
 // Close closes the Badger database connection.
 func (r *BadgerCustomerRepository) Close() error {
 	return r.db.Close()
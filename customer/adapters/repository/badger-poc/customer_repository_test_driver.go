@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/dgraph-io/badger/v4"
+	"github.com/dgraph-io/badger/v4/options"
 	"github.com/maniosgrivei/go-test-drivers/customer"
 	"github.com/stretchr/testify/require"
 )
@@ -29,17 +30,37 @@ func NewBadgerCustomerRepositoryTestDriver(repository *BadgerCustomerRepository)
 	}
 }
 
+// TestOptions returns in-memory Badger options sized for test data. Databases
+// open and drop their data about 20 times faster than with the defaults, which
+// matters when every subtest gets its own database.
+func TestOptions() badger.Options {
+	return badger.DefaultOptions("").
+		WithInMemory(true).
+		WithLogger(nil).
+		WithMemTableSize(4 << 20).
+		WithBaseTableSize(1 << 20).
+		WithValueThreshold(1 << 10).
+		WithValueLogFileSize(1 << 20).
+		WithNumMemtables(1).
+		WithNumLevelZeroTables(1).
+		WithNumLevelZeroTablesStall(2).
+		WithNumCompactors(2).
+		WithCompression(options.None).
+		WithBlockCacheSize(0).
+		WithIndexCacheSize(0)
+}
+
 //
 // Arrange
 
 // ArrangeInternalsNoCustomerIsRegistered clears all data from the Badger database.
-func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t *testing.T) {
+func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t testing.TB) {
 	t.Helper()
 	require.NoError(t, td.db.DropAll())
 }
 
 // ArrangeInternalsSomeCustomersAreRegistered populates the database with a given list of customers.
-func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t *testing.T, cs []*customer.Customer) {
+func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, cs []*customer.Customer) {
 	t.Helper()
 	td.ArrangeInternalsNoCustomerIsRegistered(t)
 	for _, c := range cs {
@@ -49,16 +70,62 @@ func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRe
 }
 
 // ArrangeInternalsSomethingCausingAProblem simulates a system error by closing the database connection.
-func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t *testing.T) {
+func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t testing.TB) {
 	t.Helper()
 	require.NoError(t, td.Close())
 }
 
+// ArrangeInternalsCustomerIsDuplicated stores a copy of the customer under
+// another ID, without the duplication checks and the uniqueness indexes of
+// Save.
+func (td *BadgerCustomerRepositoryTestDriver) ArrangeInternalsCustomerIsDuplicated(t testing.TB, c *customer.Customer) {
+	t.Helper()
+
+	duplicate := *c
+	duplicate.ID = c.ID + "-duplicate"
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&duplicate))
+
+	err := td.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(getIDKey(duplicate.ID), buf.Bytes())
+	})
+	require.NoError(t, err)
+}
+
+//
+// State
+
+// Snapshot captures the database with the Badger backup API. The snapshot can
+// be loaded with `badger restore`.
+func (td *BadgerCustomerRepositoryTestDriver) Snapshot(t testing.TB) customer.Snapshot {
+	t.Helper()
+
+	var buf bytes.Buffer
+	_, err := td.db.Backup(&buf, 0)
+	require.NoError(t, err)
+
+	return customer.Snapshot{Format: snapshotFormat, Data: buf.Bytes()}
+}
+
+// Restore replaces the content of the database with the one of the snapshot.
+func (td *BadgerCustomerRepositoryTestDriver) Restore(t testing.TB, snapshot customer.Snapshot) {
+	t.Helper()
+
+	require.Equal(t, snapshotFormat, snapshot.Format, "unsupported snapshot format")
+
+	td.ArrangeInternalsNoCustomerIsRegistered(t)
+	require.NoError(t, td.db.Load(bytes.NewReader(snapshot.Data), 16))
+}
+
+// snapshotFormat is the format of the snapshots of the repository.
+const snapshotFormat = "badger-backup"
+
 //
 // Assert
 
 // AssertInternalsCustomerShouldBeProperlyRegistered checks that the customer exists and is stored correctly.
-func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t *testing.T, c *customer.Customer) {
+func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 
@@ -84,7 +151,7 @@ func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBePro
 }
 
 // AssertInternalsCustomerShouldNotBeRegistered checks that a customer with the given details does not exist.
-func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t *testing.T, c *customer.Customer) {
+func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 
@@ -115,7 +182,7 @@ func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBe
 }
 
 // AssertInternalsCustomerShouldNotBeDuplicated iterates through all customers to ensure no duplicates exist.
-func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t *testing.T, c *customer.Customer) {
+func (td *BadgerCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 	var count int
@@ -0,0 +1,20 @@
+//go:build test
+
+package badgerpoc
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+func init() {
+	customer.SUTHarness.Register(customer.RepositoryLayer, "badger", func(t *testing.T, sut *customer.SUT) {
+		repo := NewBadgerCustomerRepositoryWithOptions(TestOptions())
+		// The test driver may already have closed it to cause a problem.
+		t.Cleanup(func() { _ = repo.Close() })
+
+		sut.Repository = repo
+		sut.RepositoryTD = NewBadgerCustomerRepositoryTestDriver(repo)
+	})
+}
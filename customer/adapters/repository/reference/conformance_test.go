@@ -0,0 +1,16 @@
+//go:build test
+
+package reference
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+func TestRepositoryTestDriverConformance(t *testing.T) {
+	customer.RunRepositoryTestDriverConformance(t, func(t *testing.T) (customer.CustomerRepository, customer.CustomerRepositoryTestDriver) {
+		repo := NewReferenceCustomerRepository()
+		return repo, NewReferenceCustomerRepositoryTestDriver(repo)
+	})
+}
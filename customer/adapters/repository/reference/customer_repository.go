@@ -1,13 +1,19 @@
 package reference
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
 )
 
 type ReferenceCustomerRepository struct {
+	mu sync.RWMutex
+
 	customers  []*customer.Customer
 	idIndex    map[string]*customer.Customer
 	nameIndex  map[string]*customer.Customer
@@ -29,6 +35,9 @@ func NewReferenceCustomerRepository() *ReferenceCustomerRepository {
 
 // Save adds a new customer to the repository.
 func (r *ReferenceCustomerRepository) Save(c *customer.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if r.customers == nil || r.nameIndex == nil || r.emailIndex == nil || r.phoneIndex == nil {
 		return customer.ErrSystem
 	}
@@ -46,6 +55,33 @@ func (r *ReferenceCustomerRepository) Save(c *customer.Customer) error {
 	return nil
 }
 
+// Ping reports whether the repository internals are in a usable state.
+func (r *ReferenceCustomerRepository) Ping(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.customers == nil || r.nameIndex == nil || r.emailIndex == nil || r.phoneIndex == nil {
+		return customer.ErrSystem
+	}
+
+	return ctx.Err()
+}
+
+// List returns all the customers of the repository, ordered by ID.
+func (r *ReferenceCustomerRepository) List() ([]*customer.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.customers == nil {
+		return nil, customer.ErrSystem
+	}
+
+	customers := slices.Clone(r.customers)
+	slices.SortFunc(customers, func(a, b *customer.Customer) int { return strings.Compare(a.ID, b.ID) })
+
+	return customers, nil
+}
+
 // checkDuplication checks if the id name, email, or phone in the request
 // already exist in the repository.
 func (r *ReferenceCustomerRepository) checkDuplication(c *customer.Customer) error {
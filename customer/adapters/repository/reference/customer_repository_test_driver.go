@@ -3,6 +3,7 @@
 package reference
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
@@ -25,9 +26,17 @@ func NewReferenceCustomerRepositoryTestDriver(repository *ReferenceCustomerRepos
 // Arrange
 
 // ArrangeInternalsNoCustomerIsRegistered initializes the repository to a clean state.
-func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t *testing.T) {
+func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t testing.TB) {
 	t.Helper()
 
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.reset()
+}
+
+// reset empties the repository. The caller must hold the lock.
+func (td *ReferenceCustomerRepositoryTestDriver) reset() {
 	td.customers = make([]*customer.Customer, 0)
 	td.idIndex = make(map[string]*customer.Customer)
 	td.nameIndex = make(map[string]*customer.Customer)
@@ -37,10 +46,18 @@ func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsReg
 
 // ArrangeInternalsSomeCustomersAreRegistered populates the repository with the
 // given customers.
-func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t *testing.T, cs []*customer.Customer) {
+func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, cs []*customer.Customer) {
 	t.Helper()
-	td.ArrangeInternalsNoCustomerIsRegistered(t)
 
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.reset()
+	td.insert(cs)
+}
+
+// insert adds the customers to the repository. The caller must hold the lock.
+func (td *ReferenceCustomerRepositoryTestDriver) insert(cs []*customer.Customer) {
 	for _, c := range cs {
 		td.customers = append(td.customers, c)
 		td.idIndex[c.ID] = c
@@ -52,9 +69,12 @@ func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAr
 
 // ArrangeInternalsSomethingCausingAProblem corrupts the internal state to
 // ensure subsequent function calls will result in a system error.
-func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t *testing.T) {
+func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t testing.TB) {
 	t.Helper()
 
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
 	td.customers = nil
 	td.idIndex = nil
 	td.nameIndex = nil
@@ -62,14 +82,66 @@ func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausin
 	td.phoneIndex = nil
 }
 
+// ArrangeInternalsCustomerIsDuplicated adds a copy of the customer under
+// another ID, without checking for duplications as Save does.
+func (td *ReferenceCustomerRepositoryTestDriver) ArrangeInternalsCustomerIsDuplicated(t testing.TB, c *customer.Customer) {
+	t.Helper()
+
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	duplicate := *c
+	duplicate.ID = c.ID + "-duplicate"
+
+	td.insert([]*customer.Customer{&duplicate})
+}
+
+//
+// State
+
+// Snapshot captures the registered customers as a JSON list.
+func (td *ReferenceCustomerRepositoryTestDriver) Snapshot(t testing.TB) customer.Snapshot {
+	t.Helper()
+
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+
+	data, err := json.MarshalIndent(td.customers, "", "  ")
+	require.NoError(t, err)
+
+	return customer.Snapshot{Format: snapshotFormat, Data: data}
+}
+
+// Restore replaces the registered customers with the ones of the snapshot.
+func (td *ReferenceCustomerRepositoryTestDriver) Restore(t testing.TB, snapshot customer.Snapshot) {
+	t.Helper()
+
+	require.Equal(t, snapshotFormat, snapshot.Format, "unsupported snapshot format")
+
+	var cs []*customer.Customer
+	require.NoError(t, json.Unmarshal(snapshot.Data, &cs))
+
+	td.mu.Lock()
+	defer td.mu.Unlock()
+
+	td.reset()
+	td.insert(cs)
+}
+
+// snapshotFormat is the format of the snapshots of the repository.
+const snapshotFormat = "json"
+
 //
 // Assert
 
 // AssertInternalsCustomerShouldBeProperlyRegistered asserts that the customer
 // is properly registered in the internal data structures.
-func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t *testing.T, c *customer.Customer) {
+func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+
 	r := require.New(t)
 
 	r.True(sliceContainsCustomer(td.customers, c))
@@ -89,9 +161,12 @@ func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBe
 
 // AssertInternalsCustomerShouldNotBeRegistered asserts that the customer is not
 // present in the internal data structures.
-func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t *testing.T, c *customer.Customer) {
+func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+
 	r := require.New(t)
 
 	r.False(sliceContainsCustomer(td.customers, c))
@@ -103,9 +178,12 @@ func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNo
 
 // AssertInternalsCustomerShouldNotBeDuplicated asserts that the customer is not
 // duplicated in the internal data structures. IDs are not compared.
-func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t *testing.T, c *customer.Customer) {
+func (td *ReferenceCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, c *customer.Customer) {
 	t.Helper()
 
+	td.mu.RLock()
+	defer td.mu.RUnlock()
+
 	r := require.New(t)
 
 	r.LessOrEqual(sliceCountCustomeOccurrences(td.customers, c), 1)
@@ -0,0 +1,17 @@
+//go:build test
+
+package reference
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+func init() {
+	customer.SUTHarness.Register(customer.RepositoryLayer, "reference", func(_ *testing.T, sut *customer.SUT) {
+		repo := NewReferenceCustomerRepository()
+		sut.Repository = repo
+		sut.RepositoryTD = NewReferenceCustomerRepositoryTestDriver(repo)
+	})
+}
@@ -0,0 +1,65 @@
+// Package repository creates the customer repository backends by name, for
+// the commands choosing them at run time.
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	badgerpoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/badger-poc"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/reference"
+	sqlitepoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/sqlite-poc"
+)
+
+// Supported repository backends.
+const (
+	Reference = "reference"
+	SQLite    = "sqlite"
+	Badger    = "badger"
+)
+
+// Backends are the names of the supported repository backends.
+var Backends = []string{Reference, SQLite, Badger}
+
+// Repository is the set of behaviours every backend offers on top of the
+// customer.CustomerRepository contract.
+type Repository interface {
+	customer.CustomerRepository
+
+	// Ping reports whether the backend is ready to serve requests.
+	Ping(ctx context.Context) error
+}
+
+// IsKnown checks if `name` is one of the supported backends.
+func IsKnown(name string) bool {
+	return slices.Contains(Backends, name)
+}
+
+// New creates the repository backend called `name`. The returned function
+// releases the resources held by the backend.
+func New(name string) (repo Repository, closeFn func() error, err error) {
+	// The PoC constructors panic when they fail to open their databases.
+	defer func() {
+		if r := recover(); r != nil {
+			repo, closeFn, err = nil, nil, fmt.Errorf("failed to create %s repository: %v", name, r)
+		}
+	}()
+
+	switch name {
+	case Reference:
+		return reference.NewReferenceCustomerRepository(), func() error { return nil }, nil
+
+	case SQLite:
+		r := sqlitepoc.NewSQLiteCustomerRepository()
+		return r, r.Close, nil
+
+	case Badger:
+		r := badgerpoc.NewBadgerCustomerRepository()
+		return r, r.Close, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown repository backend: '%s'", name)
+	}
+}
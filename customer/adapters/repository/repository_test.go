@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	for _, name := range Backends {
+		t.Run(name, func(t *testing.T) {
+			r := require.New(t)
+
+			repo, closeFn, err := New(name)
+			r.NoError(err)
+
+			r.NoError(repo.Ping(context.Background()))
+			r.NoError(repo.Save(&customer.Customer{ID: "JHND-0000-0001", Name: "John Due", Email: "john.due@somecompany.com", Phone: "+1 234 567 890"}))
+			r.NoError(closeFn())
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		_, _, err := New("mongo")
+		require.ErrorContains(t, err, "unknown repository backend: 'mongo'")
+		require.False(t, IsKnown("mongo"))
+	})
+}
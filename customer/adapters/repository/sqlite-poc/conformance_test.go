@@ -0,0 +1,19 @@
+//go:build test
+
+package sqlitepoc
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+func TestRepositoryTestDriverConformance(t *testing.T) {
+	customer.RunRepositoryTestDriverConformance(t, func(t *testing.T) (customer.CustomerRepository, customer.CustomerRepositoryTestDriver) {
+		repo := NewSQLiteCustomerRepository()
+		// The test driver may already have closed it to cause a problem.
+		t.Cleanup(func() { _ = repo.Close() })
+
+		return repo, NewSQLiteCustomerRepositoryTestDriver(repo)
+	})
+}
@@ -1,6 +1,7 @@
 package sqlitepoc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -22,6 +23,10 @@ var _ customer.CustomerRepository = (*SQLiteCustomerRepository)(nil)
 func NewSQLiteCustomerRepository() *SQLiteCustomerRepository {
 	db := sqlx.MustConnect("sqlite3", ":memory:")
 
+	// Each connection to ":memory:" opens its own database, so concurrent
+	// callers must share a single connection to see the same customers.
+	db.SetMaxOpenConns(1)
+
 	schema := `
     CREATE TABLE customers (
         id TEXT PRIMARY KEY,
@@ -61,13 +66,41 @@ func (r *SQLiteCustomerRepository) Save(c *customer.Customer) error {
 	return nil
 }
 
+// Ping verifies that the database connection is still alive.
+func (r *SQLiteCustomerRepository) Ping(ctx context.Context) error {
+	if r.db == nil {
+		return customer.ErrSystem
+	}
+
+	return r.db.PingContext(ctx)
+}
+
+// List returns all the customers of the repository, ordered by ID.
+func (r *SQLiteCustomerRepository) List() ([]*customer.Customer, error) {
+	if r.db == nil {
+		return nil, customer.ErrSystem
+	}
+
+	var customers []*customer.Customer
+	if err := r.db.Select(&customers, "SELECT id, name, email, phone FROM customers ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("%w: %w", customer.ErrSystem, err)
+	}
+
+	return customers, nil
+}
+
+// Close closes the SQLite database connection.
+func (r *SQLiteCustomerRepository) Close() error {
+	return r.db.Close()
+}
+
 // checkDuplication checks if a customer with the same name, email, or phone already exists.
 func (r *SQLiteCustomerRepository) checkDuplication(c *customer.Customer) error {
 	var errs []error
 	var count int
 
 	if err := r.db.Get(&count, "SELECT count(*) FROM customers WHERE id = ?", c.ID); err == nil && count > 0 {
-		errs = append(errs, fmt.Errorf("duplicated id: '%s'", c.Name))
+		errs = append(errs, fmt.Errorf("duplicated id: '%s'", c.ID))
 	}
 
 	if err := r.db.Get(&count, "SELECT count(*) FROM customers WHERE name = ?", c.Name); err == nil && count > 0 {
@@ -0,0 +1,29 @@
+//go:build test
+
+package sqlitepoc
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveDuplicatedID(t *testing.T) {
+	r := require.New(t)
+
+	repo := NewSQLiteCustomerRepository()
+	t.Cleanup(func() { _ = repo.Close() })
+
+	john := &customer.Customer{ID: "JHND-0000-0001", Name: "John Doe", Email: "john@doe.com", Phone: "+1 555 0001"}
+	r.NoError(repo.Save(john))
+
+	again := *john
+	again.Name, again.Email, again.Phone = "Johnny Doe", "johnny@doe.com", "+1 555 0002"
+
+	// The baseline named the customer instead of its ID in this message, which
+	// the differential runner reported as diverging from the other backends.
+	err := repo.Save(&again)
+	r.ErrorIs(err, customer.ErrDuplication)
+	r.EqualError(err, "duplication error: duplicated id: 'JHND-0000-0001'")
+}
@@ -3,10 +3,12 @@
 package sqlitepoc
 
 import (
+	"context"
 	"database/sql"
 	"testing"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
+	sqlite "github.com/mattn/go-sqlite3"
 	"github.com/stretchr/testify/require"
 )
 
@@ -29,13 +31,13 @@ func NewSQLiteCustomerRepositoryTestDriver(repository *SQLiteCustomerRepository)
 // Arrange
 
 // ArrangeInternalsNoCustomerIsRegistered clears all customer data from the database.
-func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t *testing.T) {
+func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t testing.TB) {
 	t.Helper()
 	td.db.MustExec("DELETE FROM customers")
 }
 
 // ArrangeInternalsSomeCustomersAreRegistered populates the database with a given list of customers.
-func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t *testing.T, cs []*customer.Customer) {
+func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, cs []*customer.Customer) {
 	t.Helper()
 	td.ArrangeInternalsNoCustomerIsRegistered(t)
 
@@ -46,16 +48,107 @@ func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRe
 }
 
 // ArrangeInternalsSomethingCausingAProblem simulates a system error by closing the database connection.
-func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t *testing.T) {
+func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t testing.TB) {
 	t.Helper()
 	require.NoError(t, td.db.Close())
 }
 
+// ArrangeInternalsCustomerIsDuplicated inserts a copy of the customer under
+// another ID. The uniqueness constraints of the table are dropped first, as a
+// broken schema would, since SQLite can't disable them.
+func (td *SQLiteCustomerRepositoryTestDriver) ArrangeInternalsCustomerIsDuplicated(t testing.TB, c *customer.Customer) {
+	t.Helper()
+
+	tx, err := td.db.Beginx()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		"ALTER TABLE customers RENAME TO unique_customers",
+		"CREATE TABLE customers (id TEXT PRIMARY KEY, name TEXT NOT NULL, email TEXT NOT NULL, phone TEXT NOT NULL)",
+		"INSERT INTO customers SELECT id, name, email, phone FROM unique_customers",
+		"DROP TABLE unique_customers",
+	} {
+		_, err := tx.Exec(stmt)
+		require.NoError(t, err)
+	}
+
+	_, err = tx.Exec("INSERT INTO customers (id, name, email, phone) VALUES (?, ?, ?, ?)", c.ID+"-duplicate", c.Name, c.Email, c.Phone)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Commit())
+}
+
+//
+// State
+
+// Snapshot captures the whole database with the SQLite serialization API. The
+// snapshot is a database file that the sqlite3 shell can open.
+func (td *SQLiteCustomerRepositoryTestDriver) Snapshot(t testing.TB) customer.Snapshot {
+	t.Helper()
+
+	var data []byte
+	td.withConn(t, func(conn *sqlite.SQLiteConn) (err error) {
+		data, err = conn.Serialize("main")
+		return err
+	})
+
+	return customer.Snapshot{Format: snapshotFormat, Data: data}
+}
+
+// Restore replaces the whole database with the one of the snapshot.
+//
+// A deserialized database can't grow, so the snapshot is deserialized into a
+// database of its own and copied from there with the SQLite backup API,
+// keeping the database of the repository writable.
+func (td *SQLiteCustomerRepositoryTestDriver) Restore(t testing.TB, snapshot customer.Snapshot) {
+	t.Helper()
+
+	require.Equal(t, snapshotFormat, snapshot.Format, "unsupported snapshot format")
+
+	src, err := (&sqlite.SQLiteDriver{}).Open(":memory:")
+	require.NoError(t, err)
+	defer src.Close()
+
+	srcConn := src.(*sqlite.SQLiteConn)
+	require.NoError(t, srcConn.Deserialize(snapshot.Data, "main"))
+
+	td.withConn(t, func(conn *sqlite.SQLiteConn) error {
+		backup, err := conn.Backup("main", srcConn, "main")
+		if err != nil {
+			return err
+		}
+
+		if _, err := backup.Step(-1); err != nil {
+			_ = backup.Finish()
+			return err
+		}
+
+		return backup.Finish()
+	})
+}
+
+// snapshotFormat is the format of the snapshots of the repository.
+const snapshotFormat = "sqlite"
+
+// withConn calls `fn` with the connection to the in-memory database.
+func (td *SQLiteCustomerRepositoryTestDriver) withConn(t testing.TB, fn func(conn *sqlite.SQLiteConn) error) {
+	t.Helper()
+
+	conn, err := td.db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.Raw(func(driverConn any) error {
+		return fn(driverConn.(*sqlite.SQLiteConn))
+	}))
+}
+
 //
 // Assert
 
 // AssertInternalsCustomerShouldBeProperlyRegistered checks that the customer exists in the database.
-func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t *testing.T, c *customer.Customer) {
+func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 
@@ -68,7 +161,7 @@ func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBePro
 }
 
 // AssertInternalsCustomerShouldNotBeRegistered checks that the customer does not exist in the database.
-func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t *testing.T, c *customer.Customer) {
+func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 
@@ -86,7 +179,7 @@ func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBe
 }
 
 // AssertInternalsCustomerShouldNotBeDuplicated checks that no more than one record matches the customer's details.
-func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t *testing.T, c *customer.Customer) {
+func (td *SQLiteCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, c *customer.Customer) {
 	t.Helper()
 	r := require.New(t)
 
@@ -0,0 +1,20 @@
+//go:build test
+
+package sqlitepoc
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+func init() {
+	customer.SUTHarness.Register(customer.RepositoryLayer, "sqlite", func(t *testing.T, sut *customer.SUT) {
+		repo := NewSQLiteCustomerRepository()
+		// The test driver may already have closed it to cause a problem.
+		t.Cleanup(func() { _ = repo.Close() })
+
+		sut.Repository = repo
+		sut.RepositoryTD = NewSQLiteCustomerRepositoryTestDriver(repo)
+	})
+}
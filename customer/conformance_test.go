@@ -0,0 +1,123 @@
+//go:build test
+
+package customer
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpyTB(t *testing.T) {
+	t.Run("records the failures without reporting them", func(t *testing.T) {
+		reached := false
+
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) {
+			require.Fail(tb, "expected failure")
+			reached = true
+		})
+
+		require.True(t, spy.Failed())
+		require.False(t, reached, "FailNow did not stop the function")
+		require.Len(t, spy.logs, 1)
+	})
+
+	t.Run("records errors without stopping", func(t *testing.T) {
+		reached := false
+
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) {
+			tb.Errorf("expected error")
+			reached = true
+		})
+
+		require.True(t, spy.Failed())
+		require.True(t, reached)
+	})
+
+	t.Run("takes skips as failures", func(t *testing.T) {
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) { tb.Skip("not asserting") })
+
+		require.True(t, spy.Failed())
+		require.False(t, t.Skipped())
+	})
+
+	t.Run("passes silently", func(t *testing.T) {
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) { require.True(tb, true) })
+
+		require.False(t, spy.Failed())
+	})
+}
+
+// laxRepositoryTestDriver is a broken CustomerRepositoryTestDriver whose
+// assertions never fail.
+type laxRepositoryTestDriver struct{}
+
+var _ CustomerRepositoryTestDriver = laxRepositoryTestDriver{}
+
+func (laxRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(testing.TB) {}
+
+func (laxRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(testing.TB, []*Customer) {}
+
+func (laxRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(testing.TB) {}
+
+func (laxRepositoryTestDriver) ArrangeInternalsCustomerIsDuplicated(testing.TB, *Customer) {}
+
+func (laxRepositoryTestDriver) Snapshot(testing.TB) Snapshot { return Snapshot{Format: "lax"} }
+
+func (laxRepositoryTestDriver) Restore(testing.TB, Snapshot) {}
+
+func (laxRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(testing.TB, *Customer) {
+}
+
+func (laxRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(testing.TB, *Customer) {}
+
+func (laxRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(testing.TB, *Customer) {}
+
+func TestRunRepositoryTestDriverConformance(t *testing.T) {
+	if failureExpected(t) {
+		RunRepositoryTestDriverConformance(t, func(*testing.T) (CustomerRepository, CustomerRepositoryTestDriver) {
+			return &countingRepository{}, laxRepositoryTestDriver{}
+		})
+		return
+	}
+
+	out := expectTestFailure(t)
+
+	require.Contains(t, out, "should fail, but it passed")
+	require.Contains(t, out, "--- FAIL: TestRunRepositoryTestDriverConformance/a_duplicated_customer_is_detected")
+}
+
+// expectedFailureEnv names the test run by expectTestFailure.
+const expectedFailureEnv = "GTD_EXPECTED_FAILURE"
+
+// failureExpected checks if `t` is run by expectTestFailure, and should run
+// the code expected to fail it.
+func failureExpected(t *testing.T) bool {
+	return os.Getenv(expectedFailureEnv) == t.Name()
+}
+
+// expectTestFailure runs the top-level test `t` again in a subprocess, where
+// failureExpected is true, and fails `t` unless the subprocess fails. It
+// returns the output of the subprocess.
+//
+// It checks the code failing the *testing.T it is given, which can't be spied
+// on as with ExpectTestDriverFailure.
+func expectTestFailure(t *testing.T) string {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+	cmd.Env = append(os.Environ(), expectedFailureEnv+"="+t.Name())
+
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr, "%s passed in the subprocess:\n%s", t.Name(), out)
+
+	return string(out)
+}
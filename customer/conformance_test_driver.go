@@ -0,0 +1,374 @@
+//go:build test
+
+package customer
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// RepositoryFactory creates a new, empty repository along with its test
+// driver. The repository is released by `t.Cleanup`.
+type RepositoryFactory func(t *testing.T) (CustomerRepository, CustomerRepositoryTestDriver)
+
+// RunRepositoryTestDriverConformance checks that a CustomerRepositoryTestDriver
+// honors its contract against the repository it drives: that its arrangements
+// are seen by the repository, and that its assertions do fail when they should.
+// A driver whose assertions never fail would otherwise silently pass the
+// acceptance suites.
+//
+// Every check runs as a subtest on a new repository created by `newRepository`.
+func RunRepositoryTestDriverConformance(t *testing.T, newRepository RepositoryFactory) {
+	t.Helper()
+
+	for _, c := range repositoryContracts {
+		t.Run(c.name, func(t *testing.T) {
+			repository, td := newRepository(t)
+			c.check(t, repository, td)
+		})
+	}
+}
+
+// repositoryContract is a check of the conformance kit.
+type repositoryContract struct {
+	name  string
+	check func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver)
+}
+
+// conformanceCustomers are distinct customers used by the checks.
+func conformanceCustomers() (alice, bob, carol *Customer) {
+	return &Customer{ID: "ALCE-0000-0001", Name: "Alice Wonderland", Email: "alice@wonderland.com", Phone: "+44 20 7946 0001"},
+		&Customer{ID: "BOBB-0000-0002", Name: "Bob Builder", Email: "bob@builder.com", Phone: "+44 20 7946 0002"},
+		&Customer{ID: "CRLL-0000-0003", Name: "Carol Singer", Email: "carol@singer.com", Phone: "+44 20 7946 0003"}
+}
+
+var repositoryContracts = []repositoryContract{
+	{
+		name: "an empty repository has no customer",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, _, _ := conformanceCustomers()
+
+			td.ArrangeInternalsNoCustomerIsRegistered(t)
+
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, alice)
+			td.AssertInternalsCustomerShouldNotBeDuplicated(t, alice)
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldBeProperlyRegistered on an absent customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, alice) })
+		},
+	},
+	{
+		name: "arranged customers are registered",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, carol := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice, bob})
+
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, alice)
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, bob)
+			td.AssertInternalsCustomerShouldNotBeDuplicated(t, alice)
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, carol)
+
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldNotBeRegistered on a present customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldNotBeRegistered(tb, alice) })
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldBeProperlyRegistered on an absent customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, carol) })
+		},
+	},
+	{
+		name: "arranged customers replace the previous ones",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, _ := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice})
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{bob})
+
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, alice)
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, bob)
+
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldBeProperlyRegistered on a replaced customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, alice) })
+
+			td.ArrangeInternalsNoCustomerIsRegistered(t)
+
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, bob)
+		},
+	},
+	{
+		name: "every field of a registered customer is checked",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, _, _ := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice})
+
+			for _, alteration := range []struct {
+				field string
+				alter func(c *Customer)
+			}{
+				{"name", func(c *Customer) { c.Name = "Alice Liddell" }},
+				{"email", func(c *Customer) { c.Email = "alice@liddell.com" }},
+				{"phone", func(c *Customer) { c.Phone = "+44 20 7946 9999" }},
+			} {
+				altered := *alice
+				alteration.alter(&altered)
+
+				ExpectTestDriverFailure(t,
+					fmt.Sprintf("AssertInternalsCustomerShouldBeProperlyRegistered on a customer with another %s", alteration.field),
+					func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, &altered) })
+			}
+		},
+	},
+	{
+		name: "a duplicated customer is detected",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, _ := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice, bob})
+			td.ArrangeInternalsCustomerIsDuplicated(t, alice)
+
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldNotBeDuplicated on a duplicated customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldNotBeDuplicated(tb, alice) })
+
+			td.AssertInternalsCustomerShouldNotBeDuplicated(t, bob)
+		},
+	},
+	{
+		name: "arranged customers are seen by the repository",
+		check: func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, _, _ := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice})
+
+			again := *alice
+			again.ID = "ALCE-0000-9999"
+
+			require.ErrorIs(t, repository.Save(&again), ErrDuplication,
+				"the repository does not see the arranged customer")
+		},
+	},
+	{
+		name: "saved customers are seen by the test driver",
+		check: func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, _ := conformanceCustomers()
+
+			td.ArrangeInternalsNoCustomerIsRegistered(t)
+
+			require.NoError(t, repository.Save(alice))
+
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, alice)
+			td.AssertInternalsCustomerShouldNotBeDuplicated(t, alice)
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, bob)
+
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldNotBeRegistered on a saved customer",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldNotBeRegistered(tb, alice) })
+		},
+	},
+	{
+		name: "a problem makes the repository fail",
+		check: func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, _, _ := conformanceCustomers()
+
+			td.ArrangeInternalsNoCustomerIsRegistered(t)
+			td.ArrangeInternalsSomethingCausingAProblem(t)
+
+			err := repository.Save(alice)
+			require.ErrorIs(t, err, ErrSystem, "the repository does not fail with a system error")
+		},
+	},
+	{
+		name: "eventual assertions poll until the repository catches up",
+		check: func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, _ := conformanceCustomers()
+
+			td.ArrangeInternalsNoCustomerIsRegistered(t)
+
+			clock := NewVirtualClock(time.Now())
+			eventual := NewEventualCustomerRepositoryTestDriver(td, clock, Polling{Timeout: time.Second, Interval: 10 * time.Millisecond})
+
+			clock.AfterFunc(50*time.Millisecond, func() { require.NoError(t, repository.Save(alice)) })
+
+			eventual.AssertInternalsCustomerShouldBeProperlyRegistered(t, alice)
+			eventual.AssertInternalsCustomerShouldNotBeDuplicated(t, alice)
+			eventual.AssertInternalsCustomerShouldNotBeRegistered(t, bob)
+
+			ExpectTestDriverFailure(t, "the eventual AssertInternalsCustomerShouldBeProperlyRegistered on a customer never saved",
+				func(tb testing.TB) { eventual.AssertInternalsCustomerShouldBeProperlyRegistered(tb, bob) })
+
+			td.ArrangeInternalsCustomerIsDuplicated(t, alice)
+
+			ExpectTestDriverFailure(t, "the eventual AssertInternalsCustomerShouldNotBeRegistered on a registered customer",
+				func(tb testing.TB) { eventual.AssertInternalsCustomerShouldNotBeRegistered(tb, alice) })
+			ExpectTestDriverFailure(t, "the eventual AssertInternalsCustomerShouldNotBeDuplicated on a duplicated customer",
+				func(tb testing.TB) { eventual.AssertInternalsCustomerShouldNotBeDuplicated(tb, alice) })
+		},
+	},
+	{
+		name: "eventual negative assertions fail on a customer saved later",
+		check: func(t *testing.T, repository CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, _ := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{bob})
+
+			clock := NewVirtualClock(time.Now())
+			eventual := NewEventualCustomerRepositoryTestDriver(td, clock, Polling{Timeout: time.Second, Interval: 10 * time.Millisecond})
+
+			faulty := NewFaultyCustomerRepository(repository).WithClock(clock)
+			faulty.InjectFaults(t, Fault{Latency: 50 * time.Millisecond, Deferred: true})
+
+			require.NoError(t, faulty.Save(alice))
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, alice)
+
+			ExpectTestDriverFailure(t, "the eventual AssertInternalsCustomerShouldNotBeRegistered on a customer saved later",
+				func(tb testing.TB) { eventual.AssertInternalsCustomerShouldNotBeRegistered(tb, alice) })
+
+			clock.AfterFunc(50*time.Millisecond, func() { td.ArrangeInternalsCustomerIsDuplicated(t, bob) })
+			td.AssertInternalsCustomerShouldNotBeDuplicated(t, bob)
+
+			ExpectTestDriverFailure(t, "the eventual AssertInternalsCustomerShouldNotBeDuplicated on a customer duplicated later",
+				func(tb testing.TB) { eventual.AssertInternalsCustomerShouldNotBeDuplicated(tb, bob) })
+		},
+	},
+	{
+		name: "a snapshot restores the customers",
+		check: func(t *testing.T, _ CustomerRepository, td CustomerRepositoryTestDriver) {
+			alice, bob, carol := conformanceCustomers()
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{alice, bob})
+
+			snapshot := td.Snapshot(t)
+			require.NotEmpty(t, snapshot.Format, "the snapshot has no format")
+
+			td.ArrangeInternalsSomeCustomersAreRegistered(t, []*Customer{carol})
+			td.Restore(t, snapshot)
+
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, alice)
+			td.AssertInternalsCustomerShouldBeProperlyRegistered(t, bob)
+			td.AssertInternalsCustomerShouldNotBeRegistered(t, carol)
+
+			ExpectTestDriverFailure(t, "AssertInternalsCustomerShouldBeProperlyRegistered on a customer arranged after the snapshot",
+				func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, carol) })
+		},
+	},
+}
+
+//
+// Spy
+
+// ExpectTestDriverFailure runs `fn` with a spy of `t` and fails `t` unless `fn`
+// reports a failure, as an assertion of a test driver given data it should
+// reject. The failures reported by `fn` are not propagated to `t`.
+func ExpectTestDriverFailure(t *testing.T, what string, fn func(tb testing.TB)) {
+	t.Helper()
+
+	spy := &spyTB{TB: t}
+	spy.run(fn)
+
+	if !spy.Failed() {
+		t.Errorf("%s should fail, but it passed", what)
+	}
+}
+
+// spyTB is a testing.TB recording the failures instead of reporting them.
+// Everything else, such as cleanups and temporary directories, is delegated to
+// the wrapped TB.
+type spyTB struct {
+	testing.TB
+
+	mu      sync.Mutex
+	failed  bool
+	skipped bool
+	logs    []string
+}
+
+// run calls `fn` in its own goroutine, so that FailNow can stop it.
+func (s *spyTB) run(fn func(tb testing.TB)) {
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		fn(s)
+	}()
+
+	<-done
+}
+
+func (s *spyTB) Fail() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failed = true
+}
+
+func (s *spyTB) Failed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failed
+}
+
+func (s *spyTB) FailNow() {
+	s.Fail()
+	runtime.Goexit()
+}
+
+func (s *spyTB) Log(args ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logs = append(s.logs, fmt.Sprintln(args...))
+}
+
+func (s *spyTB) Logf(format string, args ...any) {
+	s.Log(fmt.Sprintf(format, args...))
+}
+
+func (s *spyTB) Error(args ...any) {
+	s.Log(args...)
+	s.Fail()
+}
+
+func (s *spyTB) Errorf(format string, args ...any) {
+	s.Logf(format, args...)
+	s.Fail()
+}
+
+func (s *spyTB) Fatal(args ...any) {
+	s.Log(args...)
+	s.FailNow()
+}
+
+func (s *spyTB) Fatalf(format string, args ...any) {
+	s.Logf(format, args...)
+	s.FailNow()
+}
+
+func (s *spyTB) Skip(args ...any) {
+	s.Log(args...)
+	s.SkipNow()
+}
+
+func (s *spyTB) Skipf(format string, args ...any) {
+	s.Logf(format, args...)
+	s.SkipNow()
+}
+
+// SkipNow stops `fn` as a failure: a driver skipping an assertion does not
+// assert anything. Skipped still tells it apart from the other failures.
+func (s *spyTB) SkipNow() {
+	s.mu.Lock()
+	s.skipped = true
+	s.mu.Unlock()
+
+	s.FailNow()
+}
+
+func (s *spyTB) Skipped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.skipped
+}
@@ -0,0 +1,27 @@
+//go:build test
+
+package customer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssertRegistrationShouldFail(t *testing.T) {
+	td := NewCustomerServiceTestDriver(NewCustomerService(&countingRepository{}), laxRepositoryTestDriver{})
+	result := map[string]any{"id": "", "err": fmt.Errorf("%w: the database is down", ErrSystem)}
+
+	if failureExpected(t) {
+		td.AssertRegistrationShouldFail(t, result, map[string]any{"rejected_because": "duplicated data"})
+		return
+	}
+
+	td.AssertRegistrationShouldFail(t, result, map[string]any{"rejected_because": "system failure"})
+	td.AssertRegistrationShouldFailWithMessage(t, result, map[string]any{"rejected_because": "system failure"}, "database")
+
+	out := expectTestFailure(t)
+
+	require.Contains(t, out, "the registration should be rejected because of duplicated data")
+}
@@ -3,9 +3,13 @@
 package customer
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,36 +19,64 @@ import (
 // CustomerRepositoryTestDriver is a test driver for the CustomerRepository.
 // It provides methods for arranging, acting, and asserting on the
 // CustomerRepository.
+//
+// Its methods take a testing.TB, so that RunRepositoryTestDriverConformance can
+// check that its assertions fail when they should.
 type CustomerRepositoryTestDriver interface {
 	//
 	// Arrange
 
 	// ArrangeInternalsNoCustomerIsRegistered initializes the repository to a
 	// clean state.
-	ArrangeInternalsNoCustomerIsRegistered(t *testing.T)
+	ArrangeInternalsNoCustomerIsRegistered(t testing.TB)
 
 	// ArrangeInternalsSomeCustomersAreRegistered populates the repository with
 	// the given customers.
-	ArrangeInternalsSomeCustomersAreRegistered(t *testing.T, customers []*Customer)
+	ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, customers []*Customer)
 
 	// ArrangeInternalsSomethingCausingAProblem corrupts the internal state to
 	// ensure subsequent function calls will result in a system error.
-	ArrangeInternalsSomethingCausingAProblem(t *testing.T)
+	ArrangeInternalsSomethingCausingAProblem(t testing.TB)
+
+	// ArrangeInternalsCustomerIsDuplicated stores the registered customer once
+	// more under another ID, bypassing the uniqueness checks of the
+	// repository, so that RunRepositoryTestDriverConformance can check that
+	// AssertInternalsCustomerShouldNotBeDuplicated detects it.
+	ArrangeInternalsCustomerIsDuplicated(t testing.TB, customer *Customer)
+
+	//
+	// State
+
+	// Snapshot captures the current state of the repository.
+	Snapshot(t testing.TB) Snapshot
+
+	// Restore brings the repository back to the state captured by a snapshot
+	// of the same kind of repository, possibly another instance.
+	Restore(t testing.TB, snapshot Snapshot)
 
 	//
 	// Assert
 
 	// AssertInternalsCustomerShouldBeProperlyRegistered asserts that the
 	// customer is properly registered in the internal data structures.
-	AssertInternalsCustomerShouldBeProperlyRegistered(t *testing.T, customer *Customer)
+	AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, customer *Customer)
 
 	// AssertInternalsCustomerShouldNotBeRegistered asserts that the customer is not
 	// present in the internal data structures.
-	AssertInternalsCustomerShouldNotBeRegistered(t *testing.T, customer *Customer)
+	AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, customer *Customer)
 
 	// AssertInternalsCustomerShouldNotBeDuplicated asserts that the customer is not
 	// duplicated in the internal data structures. IDs are not compared.
-	AssertInternalsCustomerShouldNotBeDuplicated(t *testing.T, customer *Customer)
+	AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, customer *Customer)
+}
+
+// Snapshot is the state of a repository, encoded in the native format of its
+// backend.
+type Snapshot struct {
+	// Format names the encoding of Data, e.g. `json`. It is the extension of
+	// the files the snapshot is dumped to.
+	Format string
+	Data   []byte
 }
 
 //
@@ -61,6 +93,13 @@ type CustomerUpperLayerTestDriver interface {
 	// a map.
 	ActTryToRegisterACustomer(t *testing.T, request map[string]any, extraArgs map[string]any) map[string]any
 
+	//
+	// Observe
+
+	// ObserveRegistrationError returns the error of the registration which
+	// produced `result`, see CustomerServiceTestDriver.ObserveRegistrationError.
+	ObserveRegistrationError(t *testing.T, result map[string]any) error
+
 	//
 	// Assert
 
@@ -86,6 +125,13 @@ type CustomerServiceTestDriver struct {
 
 	repositoryTD CustomerRepositoryTestDriver
 	upperLayerTD CustomerUpperLayerTestDriver
+	faults       *FaultyCustomerRepository
+	fixture      *Fixture
+
+	clock   Clock
+	polling Polling
+
+	tracer *Tracer
 }
 
 // NewCustomerServiceTestDriver creates a new instance of
@@ -94,9 +140,14 @@ func NewCustomerServiceTestDriver(
 	customerService *CustomerService,
 	repositoryTD CustomerRepositoryTestDriver,
 ) *CustomerServiceTestDriver {
+	tracer := &Tracer{}
+
 	return &CustomerServiceTestDriver{
 		CustomerService: customerService,
-		repositoryTD:    repositoryTD,
+		repositoryTD:    traceRepositoryTD(repositoryTD, tracer),
+		clock:           SystemClock{},
+		polling:         DefaultPolling,
+		tracer:          tracer,
 	}
 }
 
@@ -107,13 +158,55 @@ func NewCustomerServiceTestDriverWithPresentation(
 	repositoryTD CustomerRepositoryTestDriver,
 	presentationTD CustomerUpperLayerTestDriver,
 ) *CustomerServiceTestDriver {
+	tracer := &Tracer{}
+
 	return &CustomerServiceTestDriver{
 		CustomerService: customerService,
-		repositoryTD:    repositoryTD,
-		upperLayerTD:    presentationTD,
+		repositoryTD:    traceRepositoryTD(repositoryTD, tracer),
+		upperLayerTD:    traceUpperLayerTD(presentationTD, tracer),
+		clock:           SystemClock{},
+		polling:         DefaultPolling,
+		tracer:          tracer,
 	}
 }
 
+// WithFaultInjection makes the test driver inject the faults given in the
+// extra arguments into `faults`, which must wrap the repository of the
+// service.
+func (td *CustomerServiceTestDriver) WithFaultInjection(faults *FaultyCustomerRepository) *CustomerServiceTestDriver {
+	td.faults = faults
+
+	return td
+}
+
+// WithClock makes the Eventually assertions poll on `clock`, and the latencies
+// of the injected faults elapse on it, the system clock by default. It must be
+// called after WithFaultInjection.
+func (td *CustomerServiceTestDriver) WithClock(clock Clock) *CustomerServiceTestDriver {
+	td.clock = clock
+
+	if td.faults != nil {
+		td.faults.WithClock(clock)
+	}
+
+	return td
+}
+
+// WithPolling sets the polling of the Eventually assertions, DefaultPolling by
+// default. The `eventually` extra argument overrides it for a single
+// assertion.
+func (td *CustomerServiceTestDriver) WithPolling(polling Polling) *CustomerServiceTestDriver {
+	td.polling = polling.orDefault(DefaultPolling)
+
+	return td
+}
+
+// Tracer returns the tracer recording the calls to the test driver, and to
+// the layer test drivers it delegates to, see TraceOnFailure.
+func (td *CustomerServiceTestDriver) Tracer() *Tracer {
+	return td.tracer
+}
+
 //
 // Arrange
 
@@ -122,6 +215,8 @@ func NewCustomerServiceTestDriverWithPresentation(
 func (td *CustomerServiceTestDriver) ArrangeInternalsNoCustomerIsRegistered(t *testing.T) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "ArrangeInternalsNoCustomerIsRegistered").end()
+
 	td.repositoryTD.ArrangeInternalsNoCustomerIsRegistered(t)
 }
 
@@ -139,6 +234,8 @@ func (td *CustomerServiceTestDriver) ArrangeInternalsSomeCustomersAreRegistered(
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "ArrangeInternalsSomeCustomersAreRegistered", customerMaps).end()
+
 	td.ArrangeInternalsNoCustomerIsRegistered(t)
 
 	customers := make([]*Customer, len(customerMaps))
@@ -163,9 +260,72 @@ func (td *CustomerServiceTestDriver) ArrangeInternalsSomeCustomersAreRegistered(
 func (td *CustomerServiceTestDriver) ArrangeInternalsSomethingCausingAProblem(t *testing.T) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "ArrangeInternalsSomethingCausingAProblem").end()
+
 	td.repositoryTD.ArrangeInternalsSomethingCausingAProblem(t)
 }
 
+//
+// State
+
+// Snapshot captures the current state of the repository.
+func (td *CustomerServiceTestDriver) Snapshot(t *testing.T) Snapshot {
+	t.Helper()
+
+	call := td.tracer.begin(t, serviceDriver, "Snapshot")
+	defer call.end()
+
+	snapshot := td.repositoryTD.Snapshot(t)
+	call.returned(snapshot)
+
+	return snapshot
+}
+
+// Restore brings the repository back to the state captured by a snapshot.
+func (td *CustomerServiceTestDriver) Restore(t *testing.T, snapshot Snapshot) {
+	t.Helper()
+
+	defer td.tracer.begin(t, serviceDriver, "Restore", snapshot).end()
+
+	td.repositoryTD.Restore(t, snapshot)
+}
+
+// DumpOnFailure dumps the state of the repository to a file when the test
+// fails. The files go to the directory named by the GTD_DUMP_DIR environment
+// variable, or to the system temporary directory, and are named after the
+// test.
+func (td *CustomerServiceTestDriver) DumpOnFailure(t *testing.T) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+
+		dir := os.Getenv(DumpDirEnv)
+		if dir == "" {
+			dir = os.TempDir()
+		}
+
+		snapshot := td.Snapshot(t)
+
+		name := dumpFileNameReplacer.Replace(t.Name()) + "." + snapshot.Format
+		path := filepath.Join(dir, name)
+
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(path, snapshot.Data, 0o644))
+
+		t.Logf("repository state dumped to %s", path)
+	})
+}
+
+// DumpDirEnv is the environment variable naming the directory where
+// DumpOnFailure writes the repository dumps.
+const DumpDirEnv = "GTD_DUMP_DIR"
+
+// dumpFileNameReplacer turns test names into file names.
+var dumpFileNameReplacer = strings.NewReplacer("/", "__", " ", "_", ":", "_", "\\", "_")
+
 //
 // Act
 
@@ -177,6 +337,13 @@ func (td *CustomerServiceTestDriver) ArrangeInternalsSomethingCausingAProblem(t
 // - email: string
 // - phone: string
 //
+// The references to fixture customers found in `request` are resolved in
+// place, see Fixture.
+//
+// It looks for the following optional attributes in the `extraArgs` map:
+// - faults: []Fault, replacing the faults injected into the repository (the
+// test is skipped when the SUT does not support fault injection)
+//
 // It returns a map containing:
 // - id: string
 // - err: error
@@ -187,12 +354,29 @@ func (td *CustomerServiceTestDriver) ActTryToRegisterACustomer(
 ) map[string]any {
 	t.Helper()
 
+	call := td.tracer.begin(t, serviceDriver, "ActTryToRegisterACustomer", request, extraArgs)
+	defer call.end()
+
+	if faults, found := driverdata.BindOptionalKey[[]Fault](t, extraArgs, "faults"); found {
+		if td.faults == nil {
+			t.Skip("the SUT does not support fault injection")
+		}
+
+		td.faults.InjectFaults(t, faults...)
+	}
+
+	if td.fixture != nil {
+		td.fixture.Resolve(t, request)
+	}
+
 	if td.upperLayerTD != nil {
 		result := td.upperLayerTD.ActTryToRegisterACustomer(t, request, extraArgs)
 
 		// Update the request with the generated customer ID.
 		request["id"] = result["id"]
 
+		call.returned(result)
+
 		return result
 	}
 
@@ -205,10 +389,44 @@ func (td *CustomerServiceTestDriver) ActTryToRegisterACustomer(
 	// Update the request with the generated customer ID.
 	request["id"] = id
 
-	return map[string]any{
+	result := map[string]any{
 		"id":  id,
 		"err": err,
 	}
+	call.returned(result)
+
+	return result
+}
+
+//
+// Observe
+
+// ObserveRegistrationError returns the error of the registration which
+// produced `result`: nil when it succeeded, otherwise an error wrapping
+// ErrValidation, ErrDuplication or ErrSystem, or an error of its own for the
+// rejections foreign to the service, e.g. the ones of an authentication layer.
+// Unlike the Assert methods, it lets the tests compare the outcomes of
+// registrations instead of expecting them.
+//
+// It looks for the following attributes in the `result` map:
+// - err: error
+func (td *CustomerServiceTestDriver) ObserveRegistrationError(t *testing.T, result map[string]any) error {
+	t.Helper()
+
+	call := td.tracer.begin(t, serviceDriver, "ObserveRegistrationError", result)
+	defer call.end()
+
+	if td.upperLayerTD != nil {
+		err := td.upperLayerTD.ObserveRegistrationError(t, result)
+		call.returned(err)
+
+		return err
+	}
+
+	err, _ := result["err"].(error)
+	call.returned(err)
+
+	return err
 }
 
 //
@@ -226,6 +444,8 @@ func (td *CustomerServiceTestDriver) AssertRegistrationShouldSucceed(
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertRegistrationShouldSucceed", result, extraArgs).end()
+
 	if td.upperLayerTD != nil {
 		td.upperLayerTD.AssertRegistrationShouldSucceed(t, result, extraArgs)
 		return
@@ -246,6 +466,10 @@ func (td *CustomerServiceTestDriver) AssertRegistrationShouldSucceed(
 // It looks for the following attributes in the `result` map:
 // - id: string
 // - err: error
+//
+// It looks for the following optional attributes in the `extraArgs` map:
+// - rejected_because: string, the reason of the rejection (e.g. `duplicated
+// data`), checked against the error of the service
 func (td *CustomerServiceTestDriver) AssertRegistrationShouldFail(
 	t *testing.T,
 	result map[string]any,
@@ -253,6 +477,8 @@ func (td *CustomerServiceTestDriver) AssertRegistrationShouldFail(
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertRegistrationShouldFail", result, extraArgs).end()
+
 	if td.upperLayerTD != nil {
 		td.upperLayerTD.AssertRegistrationShouldFail(t, result, extraArgs)
 
@@ -271,6 +497,20 @@ func (td *CustomerServiceTestDriver) AssertRegistrationShouldFail(
 	err, ok := result["err"].(error)
 	r.True(ok, "result 'err' field should be an error type")
 	r.Error(err)
+
+	if reason, found := driverdata.BindOptionalKey[string](t, extraArgs, "rejected_because"); found {
+		expected, known := rejectionErrors[reason]
+		r.True(known, "the service can't reject a registration because of '%s'", reason)
+		r.ErrorIs(err, expected, "the registration should be rejected because of %s", reason)
+	}
+}
+
+// rejectionErrors maps the reasons why registrations are rejected, as given in
+// the `rejected_because` extra argument, to the errors of the service.
+var rejectionErrors = map[string]error{
+	"invalid data":    ErrValidation,
+	"duplicated data": ErrDuplication,
+	"system failure":  ErrSystem,
 }
 
 // AssertRegistrationShouldFailWithMessage asserts that the registration failed
@@ -287,6 +527,8 @@ func (td *CustomerServiceTestDriver) AssertRegistrationShouldFailWithMessage(
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertRegistrationShouldFailWithMessage", result, extraArgs, targetMessages).end()
+
 	if td.upperLayerTD != nil {
 		td.upperLayerTD.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, targetMessages...)
 
@@ -315,6 +557,8 @@ func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldBeProperlyRegi
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldBeProperlyRegistered", customerData).end()
+
 	customer := getCustomerFromMap(t, customerData)
 
 	td.repositoryTD.AssertInternalsCustomerShouldBeProperlyRegistered(t, customer)
@@ -334,6 +578,8 @@ func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldNotBeRegistere
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldNotBeRegistered", customerData).end()
+
 	customer := getCustomerFromMap(t, customerData)
 
 	td.repositoryTD.AssertInternalsCustomerShouldNotBeRegistered(t, customer)
@@ -347,14 +593,108 @@ func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldNotBeDuplicate
 ) {
 	t.Helper()
 
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldNotBeDuplicated", customerData).end()
+
 	customer := getCustomerFromMap(t, customerData)
 
 	td.repositoryTD.AssertInternalsCustomerShouldNotBeDuplicated(t, customer)
 }
 
+// AssertInternalsCustomerShouldEventuallyBeProperlyRegistered asserts that
+// the customer ends up properly registered in the internal data structures,
+// polling as AssertInternalsCustomerShouldBeProperlyRegistered until it does,
+// see Eventually. It suits the registrations whose effects are asynchronous.
+//
+// It looks for the same attributes in the `customerData` map as
+// AssertInternalsCustomerShouldBeProperlyRegistered.
+//
+// It looks for the following optional attributes in the `extraArgs` map:
+// - eventually: Polling, overriding the polling of the test driver
+func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldEventuallyBeProperlyRegistered(
+	t *testing.T,
+	customerData map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldEventuallyBeProperlyRegistered", customerData, extraArgs).end()
+
+	customer := getCustomerFromMap(t, customerData)
+
+	td.eventualRepositoryTD(t, extraArgs).AssertInternalsCustomerShouldBeProperlyRegistered(t, customer)
+}
+
+// AssertInternalsCustomerShouldConsistentlyNotBeRegistered asserts that the
+// customer stays absent from the internal data structures, polling as
+// AssertInternalsCustomerShouldNotBeRegistered for as long as the polling
+// lasts, see Consistently. It catches the rejected customers saved
+// asynchronously.
+//
+// It looks for the same attributes in the `customerData` map as
+// AssertInternalsCustomerShouldNotBeRegistered.
+//
+// It looks for the following optional attributes in the `extraArgs` map:
+// - eventually: Polling, overriding the polling of the test driver
+func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldConsistentlyNotBeRegistered(
+	t *testing.T,
+	customerData map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldConsistentlyNotBeRegistered", customerData, extraArgs).end()
+
+	customer := getCustomerFromMap(t, customerData)
+
+	td.eventualRepositoryTD(t, extraArgs).AssertInternalsCustomerShouldNotBeRegistered(t, customer)
+}
+
+// AssertInternalsCustomerShouldConsistentlyNotBeDuplicated asserts that the
+// customer stays registered at most once in the internal data structures,
+// polling as AssertInternalsCustomerShouldNotBeDuplicated for as long as the
+// polling lasts, see Consistently.
+//
+// It looks for the same attributes in the `customerData` map as
+// AssertInternalsCustomerShouldNotBeDuplicated.
+//
+// It looks for the following optional attributes in the `extraArgs` map:
+// - eventually: Polling, overriding the polling of the test driver
+func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldConsistentlyNotBeDuplicated(
+	t *testing.T,
+	customerData map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, serviceDriver, "AssertInternalsCustomerShouldConsistentlyNotBeDuplicated", customerData, extraArgs).end()
+
+	customer := getCustomerFromMap(t, customerData)
+
+	td.eventualRepositoryTD(t, extraArgs).AssertInternalsCustomerShouldNotBeDuplicated(t, customer)
+}
+
 //
 // Internal Helpers
 
+// eventualRepositoryTD returns the repository test driver polling its
+// assertions as told by the `eventually` extra argument, if any, completed by
+// the polling of the test driver.
+func (td *CustomerServiceTestDriver) eventualRepositoryTD(t *testing.T, extraArgs map[string]any) *EventualCustomerRepositoryTestDriver {
+	t.Helper()
+
+	polling, _ := driverdata.BindOptionalKey[Polling](t, extraArgs, "eventually")
+
+	return NewEventualCustomerRepositoryTestDriver(td.repositoryTD, td.clock, polling.orDefault(td.polling))
+}
+
+// customerData is the shape of the customer maps given to the test driver.
+type customerData struct {
+	ID    string `driver:"id"`
+	Name  string `driver:"name,required"`
+	Email string `driver:"email,required"`
+	Phone string `driver:"phone,required"`
+}
+
 // getCustomerFromMap extracts a customer from a map.
 //
 // It looks for the following attributes in the `data` map:
@@ -365,11 +705,13 @@ func (td *CustomerServiceTestDriver) AssertInternalsCustomerShouldNotBeDuplicate
 func getCustomerFromMap(t *testing.T, data map[string]any) *Customer {
 	t.Helper()
 
+	cd := driverdata.Bind[customerData](t, data)
+
 	return &Customer{
-		ID:    GetOptionalStringFromMap(t, data, "id"),
-		Name:  GetStringFromMap(t, data, "name"),
-		Email: GetStringFromMap(t, data, "email"),
-		Phone: GetStringFromMap(t, data, "phone"),
+		ID:    cd.ID,
+		Name:  cd.Name,
+		Email: cd.Email,
+		Phone: cd.Phone,
 	}
 }
 
@@ -379,31 +721,16 @@ func getCustomerFromMap(t *testing.T, data map[string]any) *Customer {
 // GetStringFromMap safely extracts a required string value from a map.
 func GetStringFromMap(t *testing.T, data map[string]any, key string) string {
 	t.Helper()
-	r := require.New(t)
-
-	r.Contains(data, key, "map should contain required key '%s'", key)
-	val, ok := data[key]
-	r.True(ok)
 
-	strVal, ok := val.(string)
-	r.True(ok, "value for key '%s' should be a string", key)
-
-	return strVal
+	return driverdata.BindKey[string](t, data, key)
 }
 
 // GetOptionalStringFromMap safely extracts an optional string value from a map.
 // It returns an empty string if the key does not exist.
 func GetOptionalStringFromMap(t *testing.T, data map[string]any, key string) string {
 	t.Helper()
-	r := require.New(t)
-
-	val, ok := data[key]
-	if !ok {
-		return ""
-	}
 
-	strVal, ok := val.(string)
-	r.True(ok, "value for key '%s' should be a string", key)
+	val, _ := driverdata.BindOptionalKey[string](t, data, key)
 
-	return strVal
+	return val
 }
@@ -0,0 +1,142 @@
+//go:build test
+
+package customer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVirtualClock(t *testing.T) {
+	r := require.New(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start)
+
+	var calls []string
+	clock.AfterFunc(30*time.Millisecond, func() { calls = append(calls, "30ms") })
+	clock.AfterFunc(10*time.Millisecond, func() { calls = append(calls, "10ms") })
+	clock.AfterFunc(0, func() { calls = append(calls, "now") })
+
+	r.Equal([]string{"now"}, calls)
+
+	clock.Sleep(20 * time.Millisecond)
+	r.Equal([]string{"now", "10ms"}, calls)
+	r.Equal(start.Add(20*time.Millisecond), clock.Now())
+
+	clock.Advance(time.Second)
+	r.Equal([]string{"now", "10ms", "30ms"}, calls)
+}
+
+func TestEventually(t *testing.T) {
+	polling := Polling{Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+
+	t.Run("should pass once the assertion passes", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		start := clock.Now()
+
+		attempts := 0
+		Eventually(t, clock, polling, "the assertion", func(tb testing.TB) {
+			attempts++
+			require.GreaterOrEqual(tb, clock.Now().Sub(start), 35*time.Millisecond)
+		})
+
+		require.Equal(t, 5, attempts)
+		require.Equal(t, start.Add(40*time.Millisecond), clock.Now())
+	})
+
+	t.Run("should fail with the last failure after the timeout", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		start := clock.Now()
+
+		attempts := 0
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) {
+			Eventually(tb, clock, polling, "the assertion", func(tb testing.TB) {
+				attempts++
+				require.Fail(tb, "not yet")
+			})
+		})
+
+		require.True(t, spy.Failed())
+		require.Len(t, spy.logs, 1)
+		require.Contains(t, spy.logs[0], "the assertion did not pass within 100ms, after 11 attempts")
+		require.Contains(t, spy.logs[0], "not yet")
+		require.Equal(t, 11, attempts)
+		require.Equal(t, start.Add(polling.Timeout), clock.Now())
+	})
+}
+
+func TestConsistently(t *testing.T) {
+	polling := Polling{Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond}
+
+	t.Run("should pass when the assertion holds until the timeout", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		start := clock.Now()
+
+		attempts := 0
+		Consistently(t, clock, polling, "the assertion", func(tb testing.TB) { attempts++ })
+
+		require.Equal(t, 11, attempts)
+		require.Equal(t, start.Add(polling.Timeout), clock.Now())
+	})
+
+	t.Run("should fail with the first failure", func(t *testing.T) {
+		clock := NewVirtualClock(time.Now())
+		start := clock.Now()
+
+		attempts := 0
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) {
+			Consistently(tb, clock, polling, "the assertion", func(tb testing.TB) {
+				attempts++
+				require.Less(tb, clock.Now().Sub(start), 35*time.Millisecond, "too late")
+			})
+		})
+
+		require.True(t, spy.Failed())
+		require.Len(t, spy.logs, 1)
+		require.Contains(t, spy.logs[0], "the assertion did not hold for 100ms, failing on attempt 5")
+		require.Contains(t, spy.logs[0], "too late")
+		require.Equal(t, 5, attempts)
+	})
+}
+
+func TestDeferredFaults(t *testing.T) {
+	r := require.New(t)
+
+	c := &Customer{Name: "John Due", Email: "john.due@somecompany.com", Phone: "+1 234 567 890"}
+
+	clock := NewVirtualClock(time.Now())
+	repo := &countingRepository{}
+	faulty := NewFaultyCustomerRepository(repo).WithClock(clock)
+	faulty.InjectFaults(t, Fault{OnCall: 1, Latency: 50 * time.Millisecond, Deferred: true})
+
+	r.NoError(faulty.Save(c))
+	r.Equal(0, repo.saved, "the deferred save should wait for the latency")
+
+	clock.Advance(49 * time.Millisecond)
+	r.Equal(0, repo.saved)
+
+	clock.Advance(time.Millisecond)
+	r.Equal(1, repo.saved)
+
+	t.Run("should delay the calls on the clock", func(t *testing.T) {
+		start := clock.Now()
+		faulty.InjectFaults(t, Fault{Latency: 20 * time.Millisecond})
+
+		require.NoError(t, faulty.Save(c))
+		require.Equal(t, 2, repo.saved)
+		require.Equal(t, start.Add(20*time.Millisecond), clock.Now())
+	})
+
+	t.Run("should fail at once when another fault fails the call", func(t *testing.T) {
+		faulty.InjectFaults(t, Fault{Latency: 20 * time.Millisecond, Deferred: true}, Fault{Error: FaultSystem})
+
+		require.ErrorIs(t, faulty.Save(c), ErrSystem)
+		clock.Advance(time.Second)
+		require.Equal(t, 2, repo.saved)
+	})
+}
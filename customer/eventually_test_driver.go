@@ -0,0 +1,273 @@
+//go:build test
+
+package customer
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// Clocks
+
+// Clock is the time seen by the test drivers: by the polling of the Eventually
+// assertions and by the latencies of the injected faults.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep waits for `d`.
+	Sleep(d time.Duration)
+
+	// AfterFunc calls `f` once `d` has elapsed, without waiting for it.
+	AfterFunc(d time.Duration, f func())
+}
+
+// SystemClock is the Clock of the system.
+type SystemClock struct{}
+
+// Ensure SystemClock implements the Clock interface.
+var _ Clock = SystemClock{}
+
+func (SystemClock) Now() time.Time { return time.Now() }
+
+func (SystemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+func (SystemClock) AfterFunc(d time.Duration, f func()) { time.AfterFunc(d, f) }
+
+// VirtualClock is a Clock whose time only moves when slept on or advanced,
+// without waiting. The functions due are called by the goroutine moving the
+// time, in the order of their due times, so that the tests polling on it are
+// both fast and deterministic. It is safe for concurrent use.
+type VirtualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []timer
+}
+
+// timer is a function waiting for its due time on a VirtualClock.
+type timer struct {
+	at time.Time
+	f  func()
+}
+
+// Ensure VirtualClock implements the Clock interface.
+var _ Clock = (*VirtualClock)(nil)
+
+// NewVirtualClock creates a new clock stopped at `start`.
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the current time of the clock.
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep advances the clock by `d`.
+func (c *VirtualClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+// AfterFunc calls `f` once the clock has been advanced by `d`, or right away
+// when `d` is not positive.
+func (c *VirtualClock) AfterFunc(d time.Duration, f func()) {
+	if d <= 0 {
+		f()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timers = append(c.timers, timer{at: c.now.Add(d), f: f})
+}
+
+// Advance moves the clock forward by `d`, calling the functions falling due.
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+
+	c.now = c.now.Add(d)
+
+	var due []timer
+	c.timers = slices.DeleteFunc(c.timers, func(tm timer) bool {
+		if tm.at.After(c.now) {
+			return false
+		}
+
+		due = append(due, tm)
+		return true
+	})
+
+	c.mu.Unlock()
+
+	slices.SortStableFunc(due, func(a, b timer) int { return a.at.Compare(b.at) })
+	for _, tm := range due {
+		tm.f()
+	}
+}
+
+//
+// Polling
+
+// Polling tells how long Eventually tries an assertion, and how often.
+//
+// It is given to the test drivers as a map in the `eventually` extra argument,
+// e.g.:
+//
+//	eventually:
+//	  timeout: 2s
+//	  interval: 20ms
+type Polling struct {
+	// Timeout is the time after which the assertion is given up.
+	Timeout time.Duration `driver:"timeout"`
+
+	// Interval is the time between two attempts.
+	Interval time.Duration `driver:"interval"`
+}
+
+// DefaultPolling is the Polling of the test drivers unless told otherwise.
+var DefaultPolling = Polling{Timeout: 2 * time.Second, Interval: 10 * time.Millisecond}
+
+// orDefault returns the polling with the unset durations taken from `def`.
+func (p Polling) orDefault(def Polling) Polling {
+	if p.Timeout <= 0 {
+		p.Timeout = def.Timeout
+	}
+
+	if p.Interval <= 0 {
+		p.Interval = def.Interval
+	}
+
+	return p
+}
+
+// Eventually tries the `assertion` of a test driver until it passes, every
+// `polling.Interval` as told by `clock`, and fails `t` with the last failure
+// once `polling.Timeout` has elapsed. The failures of the attempts before are
+// not reported. A skipped assertion skips `t` at once.
+//
+// The assertions checking once flake against the asynchronous side effects,
+// such as the deferred saves of an eventually consistent backend; Eventually
+// lets them wait for the effects instead.
+func Eventually(t testing.TB, clock Clock, polling Polling, what string, assertion func(tb testing.TB)) {
+	t.Helper()
+
+	deadline := clock.Now().Add(polling.Timeout)
+
+	for attempt := 1; ; attempt++ {
+		spy := &spyTB{TB: t}
+		spy.run(assertion)
+
+		if spy.Skipped() {
+			t.Skip(strings.Join(spy.logs, ""))
+		}
+
+		if !spy.Failed() {
+			return
+		}
+
+		if !clock.Now().Before(deadline) {
+			t.Fatalf("%s did not pass within %s, after %d attempts; the last one failed with:\n%s",
+				what, polling.Timeout, attempt, strings.Join(spy.logs, ""))
+		}
+
+		clock.Sleep(min(polling.Interval, deadline.Sub(clock.Now())))
+	}
+}
+
+// Consistently tries the `assertion` of a test driver every
+// `polling.Interval` as told by `clock`, until `polling.Timeout` has elapsed,
+// and fails `t` with the first failure. A skipped assertion skips `t` at once.
+//
+// It is the counterpart of Eventually for the negative assertions, which pass
+// at once against the asynchronous side effects not there yet, such as a
+// deferred save of a rejected customer; Consistently keeps checking that they
+// never show up.
+func Consistently(t testing.TB, clock Clock, polling Polling, what string, assertion func(tb testing.TB)) {
+	t.Helper()
+
+	deadline := clock.Now().Add(polling.Timeout)
+
+	for attempt := 1; ; attempt++ {
+		spy := &spyTB{TB: t}
+		spy.run(assertion)
+
+		if spy.Skipped() {
+			t.Skip(strings.Join(spy.logs, ""))
+		}
+
+		if spy.Failed() {
+			t.Fatalf("%s did not hold for %s, failing on attempt %d with:\n%s",
+				what, polling.Timeout, attempt, strings.Join(spy.logs, ""))
+		}
+
+		if !clock.Now().Before(deadline) {
+			return
+		}
+
+		clock.Sleep(min(polling.Interval, deadline.Sub(clock.Now())))
+	}
+}
+
+//
+// Eventual Layers
+
+// EventualCustomerRepositoryTestDriver wraps a CustomerRepositoryTestDriver so
+// that its assertions poll: the positive one until it passes, see Eventually,
+// and the negative ones for as long as the polling lasts, see Consistently.
+// The other methods are forwarded as they are.
+type EventualCustomerRepositoryTestDriver struct {
+	CustomerRepositoryTestDriver
+
+	clock   Clock
+	polling Polling
+}
+
+// Ensure EventualCustomerRepositoryTestDriver implements the
+// CustomerRepositoryTestDriver interface.
+var _ CustomerRepositoryTestDriver = (*EventualCustomerRepositoryTestDriver)(nil)
+
+// NewEventualCustomerRepositoryTestDriver wraps `td` to poll its assertions as
+// told by `clock` and `polling`, the unset durations of `polling` being taken
+// from DefaultPolling.
+func NewEventualCustomerRepositoryTestDriver(td CustomerRepositoryTestDriver, clock Clock, polling Polling) *EventualCustomerRepositoryTestDriver {
+	return &EventualCustomerRepositoryTestDriver{
+		CustomerRepositoryTestDriver: td,
+		clock:                        clock,
+		polling:                      polling.orDefault(DefaultPolling),
+	}
+}
+
+func (td *EventualCustomerRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	Eventually(t, td.clock, td.polling, "the customer should be properly registered",
+		func(tb testing.TB) {
+			td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldBeProperlyRegistered(tb, customer)
+		})
+}
+
+func (td *EventualCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	Consistently(t, td.clock, td.polling, "the customer should not be registered",
+		func(tb testing.TB) {
+			td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldNotBeRegistered(tb, customer)
+		})
+}
+
+func (td *EventualCustomerRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	Consistently(t, td.clock, td.polling, "the customer should not be duplicated",
+		func(tb testing.TB) {
+			td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldNotBeDuplicated(tb, customer)
+		})
+}
@@ -0,0 +1,128 @@
+//go:build test
+
+package customer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository counts the customers saved.
+type countingRepository struct {
+	saved int
+}
+
+func (r *countingRepository) Save(*Customer) error {
+	r.saved++
+	return nil
+}
+
+func TestFaultyCustomerRepository(t *testing.T) {
+	c := &Customer{Name: "John Due", Email: "john.due@somecompany.com", Phone: "+1 234 567 890"}
+
+	// outcomes calls Save `n` times and reports the error class of each call.
+	outcomes := func(r *FaultyCustomerRepository, n int) []string {
+		var out []string
+		for range n {
+			err := r.Save(c)
+			switch {
+			case err == nil:
+				out = append(out, "ok")
+			case errors.Is(err, context.DeadlineExceeded):
+				out = append(out, "timeout")
+			case errors.Is(err, ErrSystem):
+				out = append(out, "system")
+			case errors.Is(err, ErrDuplication):
+				out = append(out, "duplication")
+			}
+		}
+		return out
+	}
+
+	for title, tc := range map[string]struct {
+		faults []Fault
+		want   []string
+	}{
+		"when no fault is injected": {
+			want: []string{"ok", "ok", "ok"},
+		},
+		"when every call fails": {
+			faults: []Fault{{Error: FaultSystem}},
+			want:   []string{"system", "system", "system"},
+		},
+		"when the first calls fail": {
+			faults: []Fault{{Times: 2, Error: FaultTimeout}},
+			want:   []string{"timeout", "timeout", "ok"},
+		},
+		"when the nth call fails": {
+			faults: []Fault{{OnCall: 2, Error: FaultSystem}},
+			want:   []string{"ok", "system", "ok"},
+		},
+		"when consecutive calls fail": {
+			faults: []Fault{{OnCall: 2, Times: 2, Error: FaultDuplication, Field: "phone"}},
+			want:   []string{"ok", "duplication", "duplication"},
+		},
+		"when faults overlap, the first error wins": {
+			faults: []Fault{{OnCall: 1, Error: FaultTimeout}, {Error: FaultSystem}},
+			want:   []string{"timeout", "system", "system"},
+		},
+	} {
+		t.Run(title, func(t *testing.T) {
+			repo := &countingRepository{}
+			r := NewFaultyCustomerRepository(repo)
+			r.InjectFaults(t, tc.faults...)
+
+			got := outcomes(r, 3)
+
+			require.Equal(t, tc.want, got)
+			require.Equal(t, countOK(got), repo.saved)
+		})
+	}
+
+	t.Run("should report the duplicated field", func(t *testing.T) {
+		r := NewFaultyCustomerRepository(&countingRepository{})
+		r.InjectFaults(t, Fault{Error: FaultDuplication, Field: "email"})
+
+		require.EqualError(t, r.Save(c), "duplication error: duplicated email: 'john.due@somecompany.com'")
+	})
+
+	t.Run("should restart counting the calls on injection", func(t *testing.T) {
+		r := NewFaultyCustomerRepository(&countingRepository{})
+		r.InjectFaults(t, Fault{OnCall: 2, Error: FaultSystem})
+		outcomes(r, 2)
+
+		r.InjectFaults(t, Fault{OnCall: 2, Error: FaultSystem})
+		require.Equal(t, []string{"ok", "system"}, outcomes(r, 2))
+	})
+}
+
+func TestFaultValidate(t *testing.T) {
+	for title, tc := range map[string]struct {
+		fault Fault
+		err   string
+	}{
+		"when the error class is unknown":  {Fault{Error: "flaky"}, "unknown error class 'flaky'"},
+		"when a duplication has no field":  {Fault{Error: FaultDuplication}, "duplication faults need a field"},
+		"when a system error has a field":  {Fault{Error: FaultSystem, Field: "email"}, "only duplication faults have a field"},
+		"when the fault does nothing":      {Fault{OnCall: 1}, "neither an error nor a latency"},
+		"when the call number is negative": {Fault{OnCall: -1, Error: FaultSystem}, "can't be negative"},
+	} {
+		t.Run(title, func(t *testing.T) {
+			require.ErrorContains(t, tc.fault.validate(), tc.err)
+		})
+	}
+}
+
+// countOK counts the successful calls.
+func countOK(outcomes []string) int {
+	var n int
+	for _, o := range outcomes {
+		if o == "ok" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,222 @@
+//go:build test
+
+package customer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+//
+// Faults
+
+// FaultError is the class of error returned by a fault.
+type FaultError string
+
+// Fault error classes.
+const (
+	// FaultNoError only adds latency.
+	FaultNoError FaultError = ""
+
+	// FaultSystem fails as a broken backend: with an ErrSystem.
+	FaultSystem FaultError = "system"
+
+	// FaultTimeout fails as a backend not answering in time: with an ErrSystem
+	// wrapping context.DeadlineExceeded.
+	FaultTimeout FaultError = "timeout"
+
+	// FaultDuplication fails as if the customer `Field` were already taken:
+	// with an ErrDuplication.
+	FaultDuplication FaultError = "duplication"
+)
+
+// Fault describes how calls to a repository misbehave.
+//
+// It is given to the test drivers as a map in the `faults` list of the extra
+// arguments, e.g.:
+//
+//	faults:
+//	  - on_call: 2
+//	    latency: 50ms
+//	    error: "timeout"
+//	  - latency: 200ms
+//	    deferred: true
+type Fault struct {
+	// OnCall is the first call affected by the fault, counting from 1. Zero
+	// affects every call.
+	OnCall int `driver:"on_call"`
+
+	// Times is the number of consecutive calls affected from OnCall. Zero
+	// means one call when OnCall is set, and every call otherwise.
+	Times int `driver:"times"`
+
+	// Latency delays the affected calls.
+	Latency time.Duration `driver:"latency"`
+
+	// Error is the class of error returned by the affected calls.
+	Error FaultError `driver:"error"`
+
+	// Field is the customer field reported as duplicated by FaultDuplication
+	// faults: name, email or phone.
+	Field string `driver:"field"`
+
+	// Deferred makes the affected calls succeed at once, and save the customer
+	// in the background after the latency, as an eventually consistent
+	// backend would. The errors of the deferred saves are lost.
+	Deferred bool `driver:"deferred"`
+}
+
+// validate checks the consistency of the fault.
+func (f Fault) validate() error {
+	switch {
+	case f.OnCall < 0 || f.Times < 0:
+		return fmt.Errorf("on_call and times can't be negative")
+
+	case !slices.Contains([]FaultError{FaultNoError, FaultSystem, FaultTimeout, FaultDuplication}, f.Error):
+		return fmt.Errorf("unknown error class '%s'", f.Error)
+
+	case f.Error == FaultDuplication && !slices.Contains([]string{"name", "email", "phone"}, f.Field):
+		return fmt.Errorf("duplication faults need a field among name, email and phone, got '%s'", f.Field)
+
+	case f.Error != FaultDuplication && f.Field != "":
+		return fmt.Errorf("only duplication faults have a field")
+
+	case f.Error == FaultNoError && f.Latency == 0:
+		return fmt.Errorf("the fault has neither an error nor a latency")
+
+	case f.Deferred && (f.Error != FaultNoError || f.Latency == 0):
+		return fmt.Errorf("deferred faults need a latency and no error")
+	}
+
+	return nil
+}
+
+// affects checks if the fault affects the call number `call`.
+func (f Fault) affects(call int) bool {
+	if f.OnCall == 0 {
+		return f.Times == 0 || call <= f.Times
+	}
+
+	times := max(f.Times, 1)
+
+	return call >= f.OnCall && call < f.OnCall+times
+}
+
+// err returns the error returned by the fault when saving `c`.
+func (f Fault) err(c *Customer) error {
+	switch f.Error {
+	case FaultSystem:
+		return fmt.Errorf("%w: injected fault", ErrSystem)
+
+	case FaultTimeout:
+		return fmt.Errorf("%w: %w", ErrSystem, context.DeadlineExceeded)
+
+	case FaultDuplication:
+		value := map[string]string{"name": c.Name, "email": c.Email, "phone": c.Phone}[f.Field]
+		return fmt.Errorf("%w: duplicated %s: '%s'", ErrDuplication, f.Field, value)
+	}
+
+	return nil
+}
+
+//
+// Faulty Repository
+
+// FaultyCustomerRepository wraps a CustomerRepository to inject faults into
+// its calls. Without faults, it is transparent.
+type FaultyCustomerRepository struct {
+	CustomerRepository
+
+	mu     sync.Mutex
+	clock  Clock
+	faults []Fault
+	calls  int
+}
+
+// Ensure FaultyCustomerRepository implements the CustomerRepository interface.
+var _ CustomerRepository = (*FaultyCustomerRepository)(nil)
+
+// NewFaultyCustomerRepository wraps `repository` without faults.
+func NewFaultyCustomerRepository(repository CustomerRepository) *FaultyCustomerRepository {
+	return &FaultyCustomerRepository{CustomerRepository: repository, clock: SystemClock{}}
+}
+
+// WithClock makes the latencies of the faults elapse on `clock`, the system
+// clock by default.
+func (r *FaultyCustomerRepository) WithClock(clock Clock) *FaultyCustomerRepository {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.clock = clock
+
+	return r
+}
+
+// InjectFaults replaces the injected faults and restarts counting the calls.
+// An empty list of faults makes the repository transparent again.
+func (r *FaultyCustomerRepository) InjectFaults(t *testing.T, faults ...Fault) {
+	t.Helper()
+
+	for i, f := range faults {
+		require.NoError(t, f.validate(), "invalid fault #%d", i+1)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.faults = slices.Clone(faults)
+	r.calls = 0
+}
+
+// Save saves the customer unless a fault affects the call. The latencies of
+// all the faults affecting the call add up, and the first error wins. When a
+// deferred fault affects a call without error, the customer is saved in the
+// background once the latency has elapsed.
+func (r *FaultyCustomerRepository) Save(c *Customer) error {
+	clock, latency, deferred, err := r.nextCall(c)
+
+	if deferred && err == nil {
+		saved := *c
+		clock.AfterFunc(latency, func() { _ = r.CustomerRepository.Save(&saved) })
+
+		return nil
+	}
+
+	clock.Sleep(latency)
+
+	if err != nil {
+		return err
+	}
+
+	return r.CustomerRepository.Save(c)
+}
+
+// nextCall counts a new call and returns how it is affected by the faults,
+// along with the clock their latency elapses on.
+func (r *FaultyCustomerRepository) nextCall(c *Customer) (clock Clock, latency time.Duration, deferred bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+
+	for _, f := range r.faults {
+		if !f.affects(r.calls) {
+			continue
+		}
+
+		latency += f.Latency
+		deferred = deferred || f.Deferred
+
+		if err == nil {
+			err = f.err(c)
+		}
+	}
+
+	return r.clock, latency, deferred, err
+}
@@ -0,0 +1,42 @@
+//go:build test
+
+package customer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtureResolve(t *testing.T) {
+	f := &Fixture{customers: map[string]map[string]any{
+		"alice": {"id": "ALCE-0000-0000", "name": "Alice", "email": "alice@wonderland.com", "phone": "+44 20 7946 0000"},
+		"bob-2": {"id": "BOBB-0000-0000", "name": "Bob", "email": "bob@builder.com", "phone": "+44 20 7946 0001"},
+	}}
+
+	data := map[string]any{
+		"name":    "Didi Dada",
+		"email":   "@alice.email",
+		"phone":   "@bob-2.phone",
+		"handle":  "@@alice.email",
+		"retries": 2,
+		"nested": map[string]any{
+			"ids": []any{"@alice.id", "@bob-2.id", "plain"},
+		},
+	}
+
+	f.Resolve(t, data)
+
+	require.Equal(t, map[string]any{
+		"name":    "Didi Dada",
+		"email":   "alice@wonderland.com",
+		"phone":   "+44 20 7946 0001",
+		"handle":  "@alice.email",
+		"retries": 2,
+		"nested": map[string]any{
+			"ids": []any{"ALCE-0000-0000", "BOBB-0000-0000", "plain"},
+		},
+	}, data)
+
+	require.Equal(t, []string{"alice", "bob-2"}, f.Aliases())
+}
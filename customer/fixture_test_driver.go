@@ -0,0 +1,160 @@
+//go:build test
+
+package customer
+
+import (
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is an initial dataset of customers known by alias, arranged by
+// ArrangeInternalsFixtureIsLoaded.
+//
+// Once a fixture is loaded, the string values of the requests given to the
+// test driver may reference the fields of its customers as `@alias.field`,
+// e.g. `@alice.email`. Values starting with `@@` stand for themselves, without
+// the first `@`.
+type Fixture struct {
+	customers map[string]map[string]any
+}
+
+// fixtureReferenceRegexp matches the references to fixture customer fields.
+var fixtureReferenceRegexp = regexp.MustCompile(`^@([\w-]+)\.(id|name|email|phone)$`)
+
+// LoadFixture reads the fixture file at `path`.
+//
+// The file holds a `customers` map from aliases to customers with the
+// following attributes:
+// - id: string (optional, generated when missing)
+// - name: string
+// - email: string
+// - phone: string
+func LoadFixture(t *testing.T, path string) map[string]any {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var fixture map[string]any
+	require.NoError(t, yaml.Unmarshal(data, &fixture), "invalid fixture file '%s'", path)
+
+	return fixture
+}
+
+// Aliases returns the aliases of the customers of the fixture, sorted.
+func (f *Fixture) Aliases() []string {
+	return slices.Sorted(maps.Keys(f.customers))
+}
+
+// Customer returns a copy of the customer known as `alias`, with its ID.
+func (f *Fixture) Customer(t *testing.T, alias string) map[string]any {
+	t.Helper()
+
+	c, found := f.customers[alias]
+	require.True(t, found, "unknown fixture customer '%s'", alias)
+
+	return maps.Clone(c)
+}
+
+// Resolve replaces, in place, the references to fixture customer fields found
+// in the string values of `data` and of its nested maps and lists.
+func (f *Fixture) Resolve(t *testing.T, data map[string]any) {
+	t.Helper()
+
+	for k, v := range data {
+		data[k] = f.resolveValue(t, v)
+	}
+}
+
+// resolveValue returns `v` with its references resolved.
+func (f *Fixture) resolveValue(t *testing.T, v any) any {
+	t.Helper()
+
+	switch v := v.(type) {
+	case string:
+		if escaped, found := strings.CutPrefix(v, "@@"); found {
+			return "@" + escaped
+		}
+
+		match := fixtureReferenceRegexp.FindStringSubmatch(v)
+		if match == nil {
+			require.NotRegexp(t, `^@`, v, "malformed fixture reference '%s'; use '@@' for a literal '@'", v)
+			return v
+		}
+
+		return GetStringFromMap(t, f.Customer(t, match[1]), match[2])
+
+	case map[string]any:
+		f.Resolve(t, v)
+
+	case []any:
+		for i := range v {
+			v[i] = f.resolveValue(t, v[i])
+		}
+	}
+
+	return v
+}
+
+//
+// Arrange
+
+// ArrangeInternalsFixtureIsLoaded replaces the content of the repository with
+// the customers of `fixture`, as read by LoadFixture, generating the missing
+// IDs. From then on, the requests given to the test driver may reference the
+// fields of the fixture customers.
+func (td *CustomerServiceTestDriver) ArrangeInternalsFixtureIsLoaded(t *testing.T, fixture map[string]any) *Fixture {
+	t.Helper()
+
+	call := td.tracer.begin(t, serviceDriver, "ArrangeInternalsFixtureIsLoaded", fixture)
+	defer call.end()
+
+	customers := driverdata.BindKey[map[string]map[string]any](t, fixture, "customers")
+	require.NotEmpty(t, customers, "the fixture has no customers")
+
+	f := &Fixture{customers: make(map[string]map[string]any, len(customers))}
+
+	usedIDs := make(map[string]bool, len(customers))
+	now := time.Now()
+
+	aliases := slices.Sorted(maps.Keys(customers))
+	arranged := make([]map[string]any, len(aliases))
+
+	for i, alias := range aliases {
+		c := maps.Clone(customers[alias])
+		cd := getCustomerFromMap(t, c)
+
+		if cd.ID == "" {
+			// IDs generated within the same millisecond may collide.
+			for cd.ID == "" || usedIDs[cd.ID] {
+				var err error
+				cd.ID, err = GenerateID(cd.Name, now)
+				require.NoError(t, err, "fixture customer '%s'", alias)
+				now = now.Add(time.Millisecond)
+			}
+			c["id"] = cd.ID
+		}
+
+		require.False(t, usedIDs[cd.ID], "fixture customer '%s' has the duplicated ID '%s'", alias, cd.ID)
+		usedIDs[cd.ID] = true
+
+		f.customers[alias] = c
+		arranged[i] = c
+	}
+
+	td.ArrangeInternalsSomeCustomersAreRegistered(t, arranged...)
+	td.fixture = f
+
+	call.returned(f)
+
+	return f
+}
@@ -0,0 +1,92 @@
+//go:build test
+
+package customer
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/test/property"
+)
+
+func TestGenValidRegisterRequest(t *testing.T) {
+	property.Check(t, GenValidRegisterRequest, nil, func(_ *testing.T, request RegisterRequest) error {
+		return ValidateRegisterRequest(&request)
+	})
+}
+
+func TestGenNearValidRegisterRequest(t *testing.T) {
+	type nearValid struct {
+		Request RegisterRequest
+		Field   string
+	}
+
+	gen := func(r *rand.Rand) nearValid {
+		request, field := GenNearValidRegisterRequest(r)
+		return nearValid{request, field}
+	}
+
+	property.Check(t, gen, nil, func(_ *testing.T, nv nearValid) error {
+		err := ValidateRegisterRequest(&nv.Request)
+		if err == nil {
+			return fmt.Errorf("the broken %s was accepted", nv.Field)
+		}
+
+		for _, field := range NearValidFields {
+			mentioned := strings.Contains(err.Error(), "invalid "+field)
+			if mentioned != (field == nv.Field) {
+				return fmt.Errorf("only the %s should be rejected, got: %w", nv.Field, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func TestGenRegistrations(t *testing.T) {
+	property.Check(t, GenRegistrations, nil, func(_ *testing.T, requests []RegisterRequest) error {
+		if len(requests) == 0 || len(requests) > maxRegistrations {
+			return fmt.Errorf("unexpected length %d", len(requests))
+		}
+
+		if !haveDistinctIDPrefixes(requests, nil) {
+			return fmt.Errorf("distinct names share an ID prefix")
+		}
+
+		return nil
+	})
+
+	t.Run("should shrink to shorter sequences first", func(t *testing.T) {
+		requests := GenRegistrations(rand.New(rand.NewPCG(1, 2)))
+
+		candidates := ShrinkRegistrations(requests)
+		if len(candidates) == 0 {
+			t.Fatal("a sequence should have simpler versions")
+		}
+
+		if len(candidates[0]) >= len(requests) {
+			t.Errorf("the first candidate should be shorter: %+v", candidates[0])
+		}
+
+		for _, candidate := range candidates {
+			if len(candidate) == 0 || !haveDistinctIDPrefixes(candidate, nil) {
+				t.Fatalf("unexpected shrunk sequence %+v", candidate)
+			}
+		}
+	})
+
+	t.Run("should tell the names sharing an ID prefix", func(t *testing.T) {
+		resubmitted := []RegisterRequest{{Name: "John Doe"}, {Name: "John Doe"}}
+		if !haveDistinctIDPrefixes(resubmitted, nil) {
+			t.Error("a resubmitted name should keep its ID prefix")
+		}
+
+		// Both names start with the consonants JHND.
+		sharing := []RegisterRequest{{Name: "John Doe"}, {Name: "Johan Dee"}}
+		if haveDistinctIDPrefixes(sharing, nil) {
+			t.Error("distinct names sharing an ID prefix should be told")
+		}
+	})
+}
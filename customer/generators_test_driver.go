@@ -0,0 +1,307 @@
+//go:build test
+
+package customer
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/test/property"
+)
+
+// The generators below draw the registration requests of the property-based
+// tests. They follow the rules of validation.go, so that the valid requests
+// pass ValidateRegisterRequest, and the near-valid ones break exactly one of
+// its rules.
+
+//
+// Valid Requests
+
+// GenValidRegisterRequest draws a request satisfying every validation rule.
+func GenValidRegisterRequest(r *rand.Rand) RegisterRequest {
+	return RegisterRequest{
+		Name:  genName(r),
+		Email: genEmail(r),
+		Phone: genPhone(r),
+	}
+}
+
+// genName draws a name of two to four parts, e.g. `Xaby O'Rud Qem`.
+func genName(r *rand.Rand) string {
+	parts := make([]string, minimumNameParts+r.IntN(3))
+	for i := range parts {
+		minLength := 1
+		if i == 0 || i == len(parts)-1 {
+			minLength = minimumFirstAndLastNameLength
+		}
+
+		parts[i] = genWord(r, alphabet, minLength, 10, "'-")
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// genEmail draws an email, e.g. `ab.cd@qux-mail.com`.
+func genEmail(r *rand.Rand) string {
+	username := genWord(r, alphanumerics, minimumEmailUsernameLength, 12, "._-")
+	service := genWord(r, alphanumerics, minimumEmailServiceLength, 10, "-")
+	extension := genWord(r, alphabet, minimumEmailExtensionLength, 4, "")
+
+	return username + "@" + service + "." + extension
+}
+
+// genPhone draws a phone number, e.g. `+55 11 9876 5432`.
+func genPhone(r *rand.Rand) string {
+	country := genWord(r, digits, minimumPhoneCountryLength, maximumPhoneCountryLength, "")
+
+	// The number takes what is left of the maximum phone length.
+	maxNumberLength := min(maximumPhoneNumberLength, maximumPhoneLength-len(country)-2)
+	number := genWord(r, digits, minimumPhoneNumberLength, maxNumberLength, " ")
+
+	return "+" + country + " " + number
+}
+
+// Character sets of the generated words.
+const (
+	alphabet      = "abcdefghijklmnopqrstuvwxyz"
+	digits        = "0123456789"
+	alphanumerics = alphabet + digits
+)
+
+// genWord draws a word of `minLength` to `maxLength` characters of `chars`.
+// One of the `separators`, if any, may replace an inner character, never
+// next to the ends.
+func genWord(r *rand.Rand, chars string, minLength, maxLength int, separators string) string {
+	word := make([]byte, minLength+r.IntN(maxLength-minLength+1))
+	for i := range word {
+		word[i] = chars[r.IntN(len(chars))]
+	}
+
+	if separators != "" && len(word) >= 3 && r.IntN(3) == 0 {
+		word[1+r.IntN(len(word)-2)] = separators[r.IntN(len(separators))]
+	}
+
+	return string(word)
+}
+
+//
+// Near-Valid Requests
+
+// mutation breaks a valid value of a field.
+type mutation func(r *rand.Rand, value string) string
+
+// mutations lists, for each field, the ways of breaking one of its rules.
+var mutations = map[string][]mutation{
+	"name": {
+		// too short
+		func(_ *rand.Rand, name string) string { return name[:minimumNameLength-1] },
+		// too long
+		func(_ *rand.Rand, name string) string {
+			return name + " " + strings.Repeat("x", maximumNameLength-len(name))
+		},
+		// invalid character
+		func(r *rand.Rand, name string) string { return replaceAt(r, name, "@#_!?,;") },
+		// consecutive spaces
+		func(_ *rand.Rand, name string) string { return strings.Replace(name, " ", "  ", 1) },
+		// not a full name
+		func(_ *rand.Rand, name string) string { return strings.ReplaceAll(name, " ", "") },
+		// last name too short
+		func(_ *rand.Rand, name string) string { return name[:strings.LastIndex(name, " ")] + " Xy" },
+	},
+	"email": {
+		// missing at
+		func(_ *rand.Rand, email string) string { return strings.Replace(email, "@", "", 1) },
+		// consecutive dots
+		func(_ *rand.Rand, email string) string { return strings.Replace(email, "@", "@.", 1) },
+		// extension too short
+		func(_ *rand.Rand, email string) string { return email[:strings.LastIndex(email, ".")] + ".c" },
+		// numeric extension
+		func(_ *rand.Rand, email string) string { return email[:strings.LastIndex(email, ".")] + ".123" },
+		// too long
+		func(_ *rand.Rand, email string) string {
+			return strings.Repeat("x", maximumEmailLength-len(email)+1) + email
+		},
+	},
+	"phone": {
+		// missing plus
+		func(_ *rand.Rand, phone string) string { return phone[1:] },
+		// country code too long
+		func(_ *rand.Rand, phone string) string { return "+1234" + phone[strings.Index(phone, " "):] },
+		// invalid character
+		func(r *rand.Rand, phone string) string {
+			i := strings.Index(phone, " ") + 1
+			return phone[:i] + replaceAt(r, phone[i:], "x-./")
+		},
+		// consecutive spaces
+		func(_ *rand.Rand, phone string) string { return strings.Replace(phone, " ", "  ", 1) },
+		// missing number
+		func(_ *rand.Rand, phone string) string { return phone[:strings.Index(phone, " ")] },
+	},
+}
+
+// NearValidFields are the fields broken by GenNearValidRegisterRequest.
+var NearValidFields = []string{"name", "email", "phone"}
+
+// GenNearValidRegisterRequest draws a valid request, then breaks one rule of
+// one of its fields. It returns the request and the broken field.
+func GenNearValidRegisterRequest(r *rand.Rand) (RegisterRequest, string) {
+	request := GenValidRegisterRequest(r)
+
+	field := NearValidFields[r.IntN(len(NearValidFields))]
+	mutate := mutations[field][r.IntN(len(mutations[field]))]
+
+	switch field {
+	case "name":
+		request.Name = mutate(r, request.Name)
+	case "email":
+		request.Email = mutate(r, request.Email)
+	case "phone":
+		request.Phone = mutate(r, request.Phone)
+	}
+
+	return request, field
+}
+
+// replaceAt replaces a random character of `s` by one of `chars`.
+func replaceAt(r *rand.Rand, s, chars string) string {
+	i := r.IntN(len(s))
+
+	return s[:i] + string(chars[r.IntN(len(chars))]) + s[i+1:]
+}
+
+//
+// Registration Sequences
+
+// maxRegistrations is the length of the longest sequence drawn by
+// GenRegistrations.
+const maxRegistrations = 12
+
+// GenRegistrations draws a sequence of registrations mixing valid requests,
+// near-valid ones, resubmissions of earlier requests and requests reusing a
+// field of an earlier one, so that the sequences exercise the validation and
+// the duplication checks alike.
+//
+// The distinct names of a sequence never share the prefix of their IDs, see
+// GenerateID. Otherwise, two customers registered within the same millisecond
+// would get the same ID, and the outcome of the sequence would depend on the
+// timing of the SUT.
+func GenRegistrations(r *rand.Rand) []RegisterRequest {
+	n := 1 + r.IntN(maxRegistrations)
+	prefixes := map[string]string{}
+
+	var requests []RegisterRequest
+	for len(requests) < n {
+		var request RegisterRequest
+
+		switch p := r.IntN(10); {
+		case p < 4 || len(requests) == 0:
+			request = GenValidRegisterRequest(r)
+
+		case p < 6:
+			request, _ = GenNearValidRegisterRequest(r)
+
+		case p < 7:
+			request = requests[r.IntN(len(requests))]
+
+		default:
+			request = GenValidRegisterRequest(r)
+
+			earlier := requests[r.IntN(len(requests))]
+			switch r.IntN(3) {
+			case 0:
+				request.Name = earlier.Name
+			case 1:
+				request.Email = earlier.Email
+			case 2:
+				request.Phone = earlier.Phone
+			}
+		}
+
+		if candidate := append(requests, request); haveDistinctIDPrefixes(candidate, prefixes) {
+			requests = candidate
+		}
+	}
+
+	return requests
+}
+
+// ShrinkRegistrations returns simpler versions of a sequence of
+// registrations: shorter sequences, then sequences with a shorter field.
+func ShrinkRegistrations(requests []RegisterRequest) [][]RegisterRequest {
+	prefixes := map[string]string{}
+
+	var candidates [][]RegisterRequest
+	for _, candidate := range property.ShrinkSlice(requests, shrinkRegisterRequest) {
+		if len(candidate) > 0 && haveDistinctIDPrefixes(candidate, prefixes) {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates
+}
+
+// shrinkRegisterRequest returns the request with one of its fields shortened.
+func shrinkRegisterRequest(request RegisterRequest) []RegisterRequest {
+	var candidates []RegisterRequest
+
+	for _, name := range property.ShrinkString(request.Name) {
+		candidates = append(candidates, RegisterRequest{Name: name, Email: request.Email, Phone: request.Phone})
+	}
+
+	for _, email := range property.ShrinkString(request.Email) {
+		candidates = append(candidates, RegisterRequest{Name: request.Name, Email: email, Phone: request.Phone})
+	}
+
+	for _, phone := range property.ShrinkString(request.Phone) {
+		candidates = append(candidates, RegisterRequest{Name: request.Name, Email: request.Email, Phone: phone})
+	}
+
+	return candidates
+}
+
+// haveDistinctIDPrefixes checks that the distinct valid names of the requests
+// have distinct ID prefixes. The prefixes are cached in `prefixes`, mapping
+// the names to their prefix, or to "" for the invalid names, when not nil.
+func haveDistinctIDPrefixes(requests []RegisterRequest, prefixes map[string]string) bool {
+	names := make(map[string]string, len(requests))
+
+	for _, request := range requests {
+		prefix, found := prefixes[request.Name]
+		if !found {
+			prefix = idPrefix(request.Name)
+			if prefixes != nil {
+				prefixes[request.Name] = prefix
+			}
+		}
+
+		if prefix == "" {
+			continue
+		}
+
+		if name, found := names[prefix]; found && name != request.Name {
+			return false
+		}
+		names[prefix] = request.Name
+	}
+
+	return true
+}
+
+// idPrefix returns the prefix of the IDs generated for `name`, or "" when the
+// name is invalid.
+func idPrefix(name string) string {
+	if ValidateName(name) != nil {
+		return ""
+	}
+
+	id, err := GenerateID(name, time.UnixMilli(0))
+	if err != nil {
+		return ""
+	}
+
+	prefix, _, _ := strings.Cut(id, "-")
+
+	return prefix
+}
@@ -0,0 +1,38 @@
+//go:build test
+
+package customer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteRepositoryTestDriver(t *testing.T) {
+	td := NewRemoteRepositoryTestDriver("http://crm.example.com")
+	alice, _, _ := conformanceCustomers()
+
+	for name, assertion := range map[string]func(tb testing.TB){
+		"AssertInternalsCustomerShouldBeProperlyRegistered": func(tb testing.TB) { td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, alice) },
+		"AssertInternalsCustomerShouldNotBeRegistered":      func(tb testing.TB) { td.AssertInternalsCustomerShouldNotBeRegistered(tb, alice) },
+		"AssertInternalsCustomerShouldNotBeDuplicated":      func(tb testing.TB) { td.AssertInternalsCustomerShouldNotBeDuplicated(tb, alice) },
+	} {
+		t.Run(name+" should skip", func(t *testing.T) {
+			spy := &spyTB{TB: t}
+			spy.run(assertion)
+
+			require.True(t, spy.Skipped(), "the assertion passed without checking anything")
+			require.Contains(t, spy.logs[0], "repository behind http://crm.example.com")
+		})
+	}
+
+	t.Run("eventual assertions should skip at once", func(t *testing.T) {
+		eventual := NewEventualCustomerRepositoryTestDriver(td, NewVirtualClock(time.Now()), DefaultPolling)
+
+		spy := &spyTB{TB: t}
+		spy.run(func(tb testing.TB) { eventual.AssertInternalsCustomerShouldBeProperlyRegistered(tb, alice) })
+
+		require.True(t, spy.Skipped())
+	})
+}
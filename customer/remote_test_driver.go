@@ -0,0 +1,108 @@
+//go:build test
+
+package customer
+
+import (
+	"testing"
+)
+
+// RemoteRepository is the option of the RepositoryLayer of the client-only
+// variants, which drive an external server through its presentation layer and
+// whose repository is the one behind that server.
+const RemoteRepository = "remote"
+
+// RemoteRepositoryTestDriver is the test driver of the repository behind an
+// external server, out of reach of the tests.
+//
+// The server is assumed to start without any customer, so arranging a clean
+// state does nothing. The tests arranging or asserting the internals are
+// skipped, rather than reported as passing without checking anything.
+type RemoteRepositoryTestDriver struct {
+	// Server names the external server in the skip messages, e.g. its URL.
+	Server string
+}
+
+// Ensure RemoteRepositoryTestDriver implements the CustomerRepositoryTestDriver
+// interface.
+var _ CustomerRepositoryTestDriver = (*RemoteRepositoryTestDriver)(nil)
+
+// NewRemoteRepositoryTestDriver creates a new test driver for the repository
+// behind the external server `server`.
+func NewRemoteRepositoryTestDriver(server string) *RemoteRepositoryTestDriver {
+	return &RemoteRepositoryTestDriver{Server: server}
+}
+
+//
+// Arrange
+
+func (td *RemoteRepositoryTestDriver) ArrangeInternalsNoCustomerIsRegistered(t testing.TB) {
+	t.Helper()
+
+	t.Logf("the repository behind %s is assumed to hold no customer", td.Server)
+}
+
+func (td *RemoteRepositoryTestDriver) ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, _ []*Customer) {
+	t.Helper()
+
+	td.skip(t, "register customers into")
+}
+
+func (td *RemoteRepositoryTestDriver) ArrangeInternalsSomethingCausingAProblem(t testing.TB) {
+	t.Helper()
+
+	td.skip(t, "corrupt")
+}
+
+func (td *RemoteRepositoryTestDriver) ArrangeInternalsCustomerIsDuplicated(t testing.TB, _ *Customer) {
+	t.Helper()
+
+	td.skip(t, "duplicate a customer in")
+}
+
+//
+// State
+
+func (td *RemoteRepositoryTestDriver) Snapshot(t testing.TB) Snapshot {
+	t.Helper()
+
+	td.skip(t, "take a snapshot of")
+
+	return Snapshot{}
+}
+
+func (td *RemoteRepositoryTestDriver) Restore(t testing.TB, _ Snapshot) {
+	t.Helper()
+
+	td.skip(t, "restore")
+}
+
+//
+// Assert
+
+func (td *RemoteRepositoryTestDriver) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, _ *Customer) {
+	t.Helper()
+
+	td.skip(t, "check the registration of a customer in")
+}
+
+func (td *RemoteRepositoryTestDriver) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, _ *Customer) {
+	t.Helper()
+
+	td.skip(t, "check the absence of a customer in")
+}
+
+func (td *RemoteRepositoryTestDriver) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, _ *Customer) {
+	t.Helper()
+
+	td.skip(t, "check the uniqueness of a customer in")
+}
+
+//
+// Helpers
+
+// skip skips the test, which needs to `action` the repository.
+func (td *RemoteRepositoryTestDriver) skip(t testing.TB, action string) {
+	t.Helper()
+
+	t.Skipf("the tests can't %s the repository behind %s", action, td.Server)
+}
@@ -0,0 +1,68 @@
+//go:build test
+
+package customer
+
+import (
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/test/harness"
+)
+
+// Layers of the customer SUT variants.
+const (
+	RepositoryLayer   = "repository"
+	PresentationLayer = "presentation"
+)
+
+// SUT is a customer system under test, assembled by the SUTHarness.
+type SUT struct {
+	// Set by the repository layer, then wrapped by Faults. The repository
+	// layer of the client-only variants sets RepositoryTD only, see
+	// RemoteRepository.
+	Repository   CustomerRepository
+	RepositoryTD CustomerRepositoryTestDriver
+
+	Faults *FaultyCustomerRepository
+
+	Service *CustomerService
+
+	// Set by the presentation layer, if any.
+	UpperLayerTD CustomerUpperLayerTestDriver
+
+	TestDriver *CustomerServiceTestDriver
+}
+
+// SUTHarness builds the customer SUT variants. Repository adapters register
+// themselves on the RepositoryLayer, and presentation adapters on the
+// PresentationLayer.
+var SUTHarness = harness.New[SUT]().
+	Layer(RepositoryLayer).
+	Step(func(_ *testing.T, sut *SUT) {
+		if sut.Repository == nil {
+			return
+		}
+
+		sut.Faults = NewFaultyCustomerRepository(sut.Repository)
+		sut.Repository = sut.Faults
+		sut.Service = NewCustomerService(sut.Repository)
+	}).
+	Layer(PresentationLayer).
+	Step(func(t *testing.T, sut *SUT) {
+		if sut.UpperLayerTD == nil {
+			sut.TestDriver = NewCustomerServiceTestDriver(sut.Service, sut.RepositoryTD)
+		} else {
+			sut.TestDriver = NewCustomerServiceTestDriverWithPresentation(sut.Service, sut.RepositoryTD, sut.UpperLayerTD)
+		}
+
+		if sut.Faults != nil {
+			sut.TestDriver.WithFaultInjection(sut.Faults)
+			sut.TestDriver.DumpOnFailure(t)
+		}
+
+		sut.TestDriver.TraceOnFailure(t)
+	})
+
+func init() {
+	// Without a presentation layer, the service is driven directly.
+	SUTHarness.Register(PresentationLayer, "", func(*testing.T, *SUT) {})
+}
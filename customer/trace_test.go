@@ -0,0 +1,88 @@
+//go:build test
+
+package customer
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepositoryTD is a repository test driver whose assertions fail for the
+// customers named `Nobody`.
+type fakeRepositoryTD struct {
+	CustomerRepositoryTestDriver
+}
+
+func (fakeRepositoryTD) ArrangeInternalsNoCustomerIsRegistered(testing.TB) {}
+
+func (fakeRepositoryTD) AssertInternalsCustomerShouldBeProperlyRegistered(tb testing.TB, c *Customer) {
+	require.NotEqual(tb, "Nobody", c.Name)
+}
+
+func TestTracer(t *testing.T) {
+	r := require.New(t)
+
+	tracer := &Tracer{}
+	td := traceRepositoryTD(fakeRepositoryTD{}, tracer)
+
+	func() {
+		call := tracer.begin(t, serviceDriver, "ActTryToRegisterACustomer",
+			map[string]any{"name": "John Due"}, map[string]any{})
+		defer call.end()
+
+		td.ArrangeInternalsNoCustomerIsRegistered(t)
+		call.returned(map[string]any{"id": "", "err": errors.New("system error")})
+	}()
+
+	spy := &spyTB{TB: t}
+	spy.run(func(tb testing.TB) {
+		td.AssertInternalsCustomerShouldBeProperlyRegistered(tb, &Customer{Name: "Nobody"})
+	})
+	r.True(spy.Failed())
+
+	calls := tracer.Calls()
+	r.Len(calls, 3)
+
+	r.Equal(TracedCall{
+		Driver:   "service",
+		Method:   "ActTryToRegisterACustomer",
+		Args:     []any{map[string]any{"name": "John Due"}, map[string]any{}},
+		Results:  []any{map[string]any{"id": "", "err": "system error"}},
+		Duration: calls[0].Duration,
+	}, calls[0])
+
+	r.Equal("customer.fakeRepositoryTD", calls[1].Driver)
+	r.Equal(1, calls[1].Depth, "the delegated calls should be nested")
+
+	r.Equal(0, calls[2].Depth)
+	r.True(calls[2].Failed)
+	r.Equal([]any{Customer{Name: "Nobody"}}, calls[2].Args)
+
+	t.Run("should write the calls as text", func(t *testing.T) {
+		var b strings.Builder
+		tracer.WriteText(&b)
+
+		durations := regexp.MustCompile(`\([0-9.]+[µnm]?s\)`)
+		require.Equal(t,
+			`  1. service.ActTryToRegisterACustomer({"name":"John Due"}, {}) -> {"err":"system error","id":""} (D)`+"\n"+
+				`  2.   customer.fakeRepositoryTD.ArrangeInternalsNoCustomerIsRegistered() (D)`+"\n"+
+				`  3. customer.fakeRepositoryTD.AssertInternalsCustomerShouldBeProperlyRegistered({"ID":"","Name":"Nobody","Email":"","Phone":""}) (D) FAILED`+"\n",
+			durations.ReplaceAllString(b.String(), "(D)"))
+	})
+}
+
+func TestTraceValue(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("json snapshot of 3 bytes", traceValue(Snapshot{Format: "json", Data: []byte("{ }")}))
+	r.Equal([]any{Customer{ID: "AB12-CD34-EF56"}}, traceValue([]*Customer{{ID: "AB12-CD34-EF56"}}))
+
+	request := map[string]any{"name": "John Due"}
+	traced := traceValue(request)
+	request["id"] = "AB12-CD34-EF56"
+	r.Equal(map[string]any{"name": "John Due"}, traced, "the traced values should be copies")
+}
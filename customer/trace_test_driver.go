@@ -0,0 +1,444 @@
+//go:build test
+
+package customer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// Trace
+
+// TracedCall is a call to a method of a test driver, as recorded by a Tracer.
+type TracedCall struct {
+	// Driver names the test driver: `service` for the CustomerServiceTestDriver,
+	// and the type of the layer test drivers it delegates to.
+	Driver string `json:"driver"`
+	Method string `json:"method"`
+
+	// Depth is the number of calls the call is nested in: the calls delegated
+	// by the service test driver to a layer are one level deeper.
+	Depth int `json:"depth"`
+
+	Args    []any `json:"args,omitempty"`
+	Results []any `json:"results,omitempty"`
+
+	Duration time.Duration `json:"duration_ns"`
+
+	// Failed tells if the test failed during the call.
+	Failed bool `json:"failed,omitempty"`
+}
+
+// Tracer records the calls of the test drivers, in the order they start, for
+// the tests to tell the story of a failure. It is safe for concurrent use.
+type Tracer struct {
+	mu    sync.Mutex
+	calls []TracedCall
+	depth int
+}
+
+// serviceDriver is the name of the CustomerServiceTestDriver in the traces.
+const serviceDriver = "service"
+
+// Calls returns the calls recorded so far.
+func (tr *Tracer) Calls() []TracedCall {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	calls := make([]TracedCall, len(tr.calls))
+	copy(calls, tr.calls)
+
+	return calls
+}
+
+// tracedCall is a call being recorded.
+type tracedCall struct {
+	tracer        *Tracer
+	tb            testing.TB
+	index         int
+	start         time.Time
+	failedAlready bool
+}
+
+// begin records the start of a call of `method` of `driver` with `args`. The
+// call must be ended with `end`, usually deferred so that it records the
+// assertions stopping the test too.
+func (tr *Tracer) begin(tb testing.TB, driver, method string, args ...any) *tracedCall {
+	traced := make([]any, len(args))
+	for i, arg := range args {
+		traced[i] = traceValue(arg)
+	}
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.calls = append(tr.calls, TracedCall{Driver: driver, Method: method, Depth: tr.depth, Args: traced})
+	tr.depth++
+
+	return &tracedCall{tracer: tr, tb: tb, index: len(tr.calls) - 1, start: time.Now(), failedAlready: tb.Failed()}
+}
+
+// returned records the results of the call.
+func (c *tracedCall) returned(results ...any) {
+	traced := make([]any, len(results))
+	for i, result := range results {
+		traced[i] = traceValue(result)
+	}
+
+	c.tracer.mu.Lock()
+	defer c.tracer.mu.Unlock()
+
+	c.tracer.calls[c.index].Results = traced
+}
+
+// end records the end of the call.
+func (c *tracedCall) end() {
+	failed := !c.failedAlready && c.tb.Failed()
+
+	c.tracer.mu.Lock()
+	defer c.tracer.mu.Unlock()
+
+	c.tracer.calls[c.index].Duration = time.Since(c.start)
+	c.tracer.calls[c.index].Failed = failed
+	c.tracer.depth--
+}
+
+// traceValue returns a copy of `v` fit for the trace: errors become their
+// messages, and the snapshots and fixtures are summarized.
+func traceValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+
+	case time.Duration:
+		return v.String()
+
+	case Snapshot:
+		return fmt.Sprintf("%s snapshot of %d bytes", v.Format, len(v.Data))
+
+	case *Fixture:
+		return "fixture of " + strings.Join(v.Aliases(), ", ")
+
+	case map[string]any:
+		traced := make(map[string]any, len(v))
+		for key, item := range v {
+			traced[key] = traceValue(item)
+		}
+
+		return traced
+
+	case []map[string]any:
+		traced := make([]any, len(v))
+		for i, item := range v {
+			traced[i] = traceValue(item)
+		}
+
+		return traced
+
+	case []any:
+		traced := make([]any, len(v))
+		for i, item := range v {
+			traced[i] = traceValue(item)
+		}
+
+		return traced
+
+	case *Customer:
+		if v == nil {
+			return nil
+		}
+
+		return *v
+
+	case []*Customer:
+		traced := make([]any, len(v))
+		for i, item := range v {
+			traced[i] = traceValue(item)
+		}
+
+		return traced
+
+	default:
+		return v
+	}
+}
+
+//
+// Formats
+
+// Trace formats.
+const (
+	// TraceText prints the trace as an indented list of calls.
+	TraceText = "text"
+
+	// TraceJSON prints the trace as a JSON array of TracedCall.
+	TraceJSON = "json"
+
+	// TraceOff prints no trace.
+	TraceOff = "off"
+)
+
+// TraceFormatEnv is the environment variable naming the format of the traces
+// printed by TraceOnFailure: TraceText, the default, TraceJSON or TraceOff.
+const TraceFormatEnv = "GTD_TRACE_FORMAT"
+
+// WriteText writes the calls as an indented list, one call per line, e.g.
+// `3. service.ActTryToRegisterACustomer({...}, {}) -> {...} (1.2ms)`.
+func (tr *Tracer) WriteText(w io.Writer) {
+	for i, call := range tr.Calls() {
+		args := make([]string, len(call.Args))
+		for j, arg := range call.Args {
+			args[j] = traceString(arg)
+		}
+
+		fmt.Fprintf(w, "%3d. %s%s.%s(%s)", i+1, strings.Repeat("  ", call.Depth), call.Driver, call.Method,
+			strings.Join(args, ", "))
+
+		if len(call.Results) > 0 {
+			results := make([]string, len(call.Results))
+			for j, result := range call.Results {
+				results[j] = traceString(result)
+			}
+			fmt.Fprintf(w, " -> %s", strings.Join(results, ", "))
+		}
+
+		fmt.Fprintf(w, " (%s)", call.Duration.Round(time.Microsecond))
+
+		if call.Failed {
+			fmt.Fprintf(w, " FAILED")
+		}
+
+		fmt.Fprintln(w)
+	}
+}
+
+// traceString formats a traced value as compact JSON.
+func traceString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return string(data)
+}
+
+// TraceOnFailure prints the trace of the calls to the test driver, and to the
+// layer test drivers it delegates to, when the test fails, in the format
+// named by the GTD_TRACE_FORMAT environment variable.
+func (td *CustomerServiceTestDriver) TraceOnFailure(t *testing.T) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		if !t.Failed() {
+			return
+		}
+
+		switch format := os.Getenv(TraceFormatEnv); format {
+		case TraceOff:
+			// Nothing to print.
+
+		case TraceJSON:
+			data, err := json.MarshalIndent(td.tracer.Calls(), "", "  ")
+			if err != nil {
+				t.Logf("test driver trace: %v", err)
+				return
+			}
+
+			t.Logf("test driver trace:\n%s", data)
+
+		case "", TraceText:
+			var b strings.Builder
+			td.tracer.WriteText(&b)
+
+			t.Logf("test driver trace:\n%s", b.String())
+
+		default:
+			t.Logf("test driver trace: unknown %s '%s'", TraceFormatEnv, format)
+		}
+	})
+}
+
+//
+// Traced Layers
+
+// tracedRepositoryTD records the calls to a repository test driver.
+type tracedRepositoryTD struct {
+	CustomerRepositoryTestDriver
+
+	tracer *Tracer
+	name   string
+}
+
+// Ensure tracedRepositoryTD implements the CustomerRepositoryTestDriver interface.
+var _ CustomerRepositoryTestDriver = (*tracedRepositoryTD)(nil)
+
+// traceRepositoryTD wraps `td` to record its calls into `tracer`, under the
+// name of its type.
+func traceRepositoryTD(td CustomerRepositoryTestDriver, tracer *Tracer) *tracedRepositoryTD {
+	return &tracedRepositoryTD{CustomerRepositoryTestDriver: td, tracer: tracer, name: fmt.Sprintf("%T", td)}
+}
+
+func (td *tracedRepositoryTD) ArrangeInternalsNoCustomerIsRegistered(t testing.TB) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "ArrangeInternalsNoCustomerIsRegistered").end()
+
+	td.CustomerRepositoryTestDriver.ArrangeInternalsNoCustomerIsRegistered(t)
+}
+
+func (td *tracedRepositoryTD) ArrangeInternalsSomeCustomersAreRegistered(t testing.TB, customers []*Customer) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "ArrangeInternalsSomeCustomersAreRegistered", customers).end()
+
+	td.CustomerRepositoryTestDriver.ArrangeInternalsSomeCustomersAreRegistered(t, customers)
+}
+
+func (td *tracedRepositoryTD) ArrangeInternalsSomethingCausingAProblem(t testing.TB) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "ArrangeInternalsSomethingCausingAProblem").end()
+
+	td.CustomerRepositoryTestDriver.ArrangeInternalsSomethingCausingAProblem(t)
+}
+
+func (td *tracedRepositoryTD) ArrangeInternalsCustomerIsDuplicated(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "ArrangeInternalsCustomerIsDuplicated", customer).end()
+
+	td.CustomerRepositoryTestDriver.ArrangeInternalsCustomerIsDuplicated(t, customer)
+}
+
+func (td *tracedRepositoryTD) Snapshot(t testing.TB) Snapshot {
+	t.Helper()
+
+	call := td.tracer.begin(t, td.name, "Snapshot")
+	defer call.end()
+
+	snapshot := td.CustomerRepositoryTestDriver.Snapshot(t)
+	call.returned(snapshot)
+
+	return snapshot
+}
+
+func (td *tracedRepositoryTD) Restore(t testing.TB, snapshot Snapshot) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "Restore", snapshot).end()
+
+	td.CustomerRepositoryTestDriver.Restore(t, snapshot)
+}
+
+func (td *tracedRepositoryTD) AssertInternalsCustomerShouldBeProperlyRegistered(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertInternalsCustomerShouldBeProperlyRegistered", customer).end()
+
+	td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldBeProperlyRegistered(t, customer)
+}
+
+func (td *tracedRepositoryTD) AssertInternalsCustomerShouldNotBeRegistered(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertInternalsCustomerShouldNotBeRegistered", customer).end()
+
+	td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldNotBeRegistered(t, customer)
+}
+
+func (td *tracedRepositoryTD) AssertInternalsCustomerShouldNotBeDuplicated(t testing.TB, customer *Customer) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertInternalsCustomerShouldNotBeDuplicated", customer).end()
+
+	td.CustomerRepositoryTestDriver.AssertInternalsCustomerShouldNotBeDuplicated(t, customer)
+}
+
+// tracedUpperLayerTD records the calls to an upper layer test driver.
+type tracedUpperLayerTD struct {
+	CustomerUpperLayerTestDriver
+
+	tracer *Tracer
+	name   string
+}
+
+// Ensure tracedUpperLayerTD implements the CustomerUpperLayerTestDriver interface.
+var _ CustomerUpperLayerTestDriver = (*tracedUpperLayerTD)(nil)
+
+// traceUpperLayerTD wraps `td` to record its calls into `tracer`, under the
+// name of its type.
+func traceUpperLayerTD(td CustomerUpperLayerTestDriver, tracer *Tracer) *tracedUpperLayerTD {
+	return &tracedUpperLayerTD{CustomerUpperLayerTestDriver: td, tracer: tracer, name: fmt.Sprintf("%T", td)}
+}
+
+func (td *tracedUpperLayerTD) ActTryToRegisterACustomer(
+	t *testing.T,
+	request map[string]any,
+	extraArgs map[string]any,
+) map[string]any {
+	t.Helper()
+
+	call := td.tracer.begin(t, td.name, "ActTryToRegisterACustomer", request, extraArgs)
+	defer call.end()
+
+	result := td.CustomerUpperLayerTestDriver.ActTryToRegisterACustomer(t, request, extraArgs)
+	call.returned(result)
+
+	return result
+}
+
+func (td *tracedUpperLayerTD) ObserveRegistrationError(t *testing.T, result map[string]any) error {
+	t.Helper()
+
+	call := td.tracer.begin(t, td.name, "ObserveRegistrationError", result)
+	defer call.end()
+
+	err := td.CustomerUpperLayerTestDriver.ObserveRegistrationError(t, result)
+	call.returned(err)
+
+	return err
+}
+
+func (td *tracedUpperLayerTD) AssertRegistrationShouldSucceed(
+	t *testing.T,
+	result map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertRegistrationShouldSucceed", result, extraArgs).end()
+
+	td.CustomerUpperLayerTestDriver.AssertRegistrationShouldSucceed(t, result, extraArgs)
+}
+
+func (td *tracedUpperLayerTD) AssertRegistrationShouldFail(
+	t *testing.T,
+	result map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertRegistrationShouldFail", result, extraArgs).end()
+
+	td.CustomerUpperLayerTestDriver.AssertRegistrationShouldFail(t, result, extraArgs)
+}
+
+func (td *tracedUpperLayerTD) AssertRegistrationShouldFailWithMessage(
+	t *testing.T,
+	result map[string]any,
+	extraArgs map[string]any,
+	targetMessages ...string,
+) {
+	t.Helper()
+
+	defer td.tracer.begin(t, td.name, "AssertRegistrationShouldFailWithMessage", result, extraArgs, targetMessages).end()
+
+	td.CustomerUpperLayerTestDriver.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, targetMessages...)
+}
@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/maniosgrivei/go-test-drivers/customer"
-	"github.com/maniosgrivei/go-test-drivers/customer/adapters/presentation/rest"
-	badgerpoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/badger-poc"
-	reference "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/reference"
-	sqlitepoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/sqlite-poc"
+	_ "github.com/maniosgrivei/go-test-drivers/customer/adapters/all"
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
+	"github.com/maniosgrivei/go-test-drivers/test/dsl"
+	"github.com/maniosgrivei/go-test-drivers/test/report"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -24,125 +25,200 @@ import (
 // customer.
 func shouldRegisterACustomerWithValidData(
 	t *testing.T,
-	testDriver *customer.CustomerServiceTestDriver,
+	crm *dsl.CRMDSL,
 	request map[string]any,
 	extraArgs map[string]any,
 ) {
 	t.Helper()
 
-	// Given that
-	testDriver.ArrangeInternalsNoCustomerIsRegistered(t)
+	crm.GivenNoCustomers(t)
 
-	// When we
-	result := testDriver.ActTryToRegisterACustomer(t, request, extraArgs)
-	// with valid data
+	crm.RegisterCustomer(t, "new customer", request, dsl.WithExtraArgs(extraArgs))
 
-	// Then the
-	testDriver.AssertRegistrationShouldSucceed(t, result, extraArgs)
-
-	// And the
-	testDriver.AssertInternalsCustomerShouldBeProperlyRegistered(t, request)
+	crm.ExpectRegistered(t)
 }
 
 // shouldRejectARegistrationWithInvalidData tests the rejection of a customer
 // registration due to invalid data.
 func shouldRejectARegistrationWithInvalidData(
 	t *testing.T,
-	testDriver *customer.CustomerServiceTestDriver,
+	crm *dsl.CRMDSL,
 	request map[string]any,
 	extraArgs map[string]any,
 	findOnError []string,
 ) {
 	t.Helper()
 
-	// Given that
-	testDriver.ArrangeInternalsNoCustomerIsRegistered(t)
-
-	// When we
-	result := testDriver.ActTryToRegisterACustomer(t, request, extraArgs)
-	// with invalid data
+	crm.GivenNoCustomers(t)
 
-	// Then the
-	testDriver.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, findOnError...)
+	crm.RegisterCustomer(t, "", request, dsl.WithExtraArgs(extraArgs))
 
-	// And the
-	testDriver.AssertInternalsCustomerShouldNotBeRegistered(t, request)
+	crm.ExpectRejectedBecause(t, dsl.InvalidData, findOnError...)
+	crm.ExpectNotRegistered(t)
 }
 
 // shouldRejectARegistrationWithDuplicatedData tests the rejection of a customer
-// registration due to duplicated data.
+// registration due to duplicated data, the request referencing the customers
+// of the fixture file at `fixture`.
 func shouldRejectARegistrationWithDuplicatedData(
 	t *testing.T,
-	testDriver *customer.CustomerServiceTestDriver,
-	referenceRequest, request map[string]any,
+	crm *dsl.CRMDSL,
+	fixture string,
+	request map[string]any,
 	extraArgs map[string]any,
 	findOnError []string,
 ) {
 	t.Helper()
 
-	// Given that
-	testDriver.ArrangeInternalsSomeCustomersAreRegistered(t, referenceRequest)
+	crm.GivenFixture(t, fixture)
 
-	// When we
-	result := testDriver.ActTryToRegisterACustomer(t, request, extraArgs)
-	// with duplicated data
+	crm.RegisterCustomer(t, "", request, dsl.WithExtraArgs(extraArgs))
 
-	// Then the
-	testDriver.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, findOnError...)
-
-	// And the
-	testDriver.AssertInternalsCustomerShouldNotBeRegistered(t, request)
+	crm.ExpectRejectedBecause(t, dsl.DuplicatedData, findOnError...)
+	crm.ExpectNotRegistered(t)
 }
 
 // shouldNotRegisterTheSameUserTwice tests that the same user cannot be
 // registered twice.
 func shouldNotRegisterTheSameUserTwice(
 	t *testing.T,
-	testDriver *customer.CustomerServiceTestDriver,
+	crm *dsl.CRMDSL,
 	referenceCustomer map[string]any,
 	extraArgs map[string]any,
 ) {
 	t.Helper()
 
-	// Given that
-	testDriver.ArrangeInternalsSomeCustomersAreRegistered(t, referenceCustomer)
+	crm.GivenRegisteredCustomer(t, "existing customer", referenceCustomer)
 
-	// When we
-	result := testDriver.ActTryToRegisterACustomer(t, referenceCustomer, extraArgs)
-	// twice
+	crm.RegisterCustomer(t, "", referenceCustomer, dsl.WithExtraArgs(extraArgs))
 
-	// Then the
-	testDriver.AssertRegistrationShouldFailWithMessage(
-		t, result, extraArgs,
+	crm.ExpectRejectedBecause(
+		t, dsl.DuplicatedData,
 		customer.ErrDuplication.Error(), "duplicated name", "duplicated email", "duplicated phone",
 	)
-
-	// And
-	testDriver.AssertInternalsCustomerShouldNotBeDuplicated(t, referenceCustomer)
+	crm.ExpectRegisteredOnlyOnce(t, "existing customer")
 }
 
 // shouldReturnAGenericSystemErrorOnFailure tests that a generic system error is
 // returned on failure.
 func shouldReturnAGenericSystemErrorOnFailure(
 	t *testing.T,
-	customerTestDriver *customer.CustomerServiceTestDriver,
+	crm *dsl.CRMDSL,
 	referenceCustomer map[string]any,
 	extraArgs map[string]any,
 ) {
 	t.Helper()
 
-	// Given that
-	customerTestDriver.ArrangeInternalsNoCustomerIsRegistered(t)
+	crm.GivenNoCustomers(t)
+	crm.GivenTheSystemIsFailing(t)
+
+	crm.RegisterCustomer(t, "", referenceCustomer, dsl.WithExtraArgs(extraArgs))
+
+	crm.ExpectRejectedBecause(t, dsl.SystemFailure, "system error", "contact support")
+}
+
+// backgroundSaveLag is the time taken by the background saves of
+// shouldEventuallyStoreACustomerSavedInTheBackground.
+const backgroundSaveLag = 500 * time.Millisecond
+
+// shouldEventuallyStoreACustomerSavedInTheBackground tests the registration of
+// a customer by a repository saving in the background, as an eventually
+// consistent backend would: the registration succeeds at once, but the
+// customer is only stored after a lag. The lag elapses on a virtual clock, so
+// that the customer is deterministically not stored before the expectation
+// waits for it.
+func shouldEventuallyStoreACustomerSavedInTheBackground(t *testing.T, crm *dsl.CRMDSL, referenceCustomer map[string]any) {
+	t.Helper()
+
+	crm.GivenNoCustomers(t)
+	crm.GivenAVirtualClock(t)
+
+	crm.RegisterCustomer(t, "new customer", referenceCustomer,
+		dsl.WithFaults(customer.Fault{Latency: backgroundSaveLag, Deferred: true}),
+		dsl.WithPolling(customer.Polling{Timeout: 2 * backgroundSaveLag}),
+	)
+
+	crm.ExpectNotRegistered(t)
+	crm.ExpectEventuallyRegistered(t)
+	crm.ExpectRegisteredOnlyOnce(t, "new customer")
+}
+
+// shouldRejectAnUnauthorizedRegistration tests the rejection of a customer
+// registration made by a caller who is not allowed to register customers.
+func shouldRejectAnUnauthorizedRegistration(
+	t *testing.T,
+	crm *dsl.CRMDSL,
+	request map[string]any,
+	extraArgs map[string]any,
+	caller dsl.Caller,
+	reason dsl.Reason,
+	findOnError []string,
+) {
+	t.Helper()
+
+	crm.GivenNoCustomers(t)
+
+	crm.RegisterCustomer(t, "", request, dsl.WithExtraArgs(extraArgs), dsl.As(caller))
+
+	crm.ExpectRejectedBecause(t, reason, findOnError...)
+	crm.ExpectNotRegistered(t)
+}
+
+// shouldRestoreASeededSnapshot tests that restoring a snapshot of a seeded
+// dataset undoes the registrations made since it was taken.
+func shouldRestoreASeededSnapshot(t *testing.T, crm *dsl.CRMDSL, seed *dsl.Snapshot, request map[string]any) {
+	t.Helper()
+
+	crm.GivenSnapshot(t, seed)
+
+	crm.RegisterCustomer(t, "new customer", request)
+
+	crm.ExpectRegistered(t)
+	crm.ExpectStored(t, seededCustomerAlias(1))
+
+	crm.GivenSnapshot(t, seed)
+
+	crm.ExpectNotRegistered(t)
+	crm.ExpectStored(t, seededCustomerAlias(seededCustomers))
+}
+
+// faultCase is a case of `fault-cases.yaml`.
+type faultCase struct {
+	Request           map[string]any   `driver:"request,required"`
+	PrecedingRequests []map[string]any `driver:"preceding_requests"`
+	Faults            []customer.Fault `driver:"faults,required"`
+	Retries           int              `driver:"retries"`
+	RejectedBecause   dsl.Reason       `driver:"rejected_because"`
+	FindOnError       []string         `driver:"find_on_error"`
+}
+
+// shouldCopeWithRepositoryFaults tests the registration of a customer while
+// the repository misbehaves. The faults are injected before the preceding
+// registrations, if any, which are expected to succeed.
+func shouldCopeWithRepositoryFaults(t *testing.T, crm *dsl.CRMDSL, fc faultCase) {
+	t.Helper()
+
+	crm.GivenNoCustomers(t)
+
+	opts := []dsl.Option{dsl.WithFaults(fc.Faults...)}
+
+	for i, request := range fc.PrecedingRequests {
+		crm.RegisterCustomer(t, fmt.Sprintf("preceding customer #%d", i+1), request, opts...)
+		crm.ExpectRegistered(t)
+
+		opts = nil
+	}
 
-	// And
-	customerTestDriver.ArrangeInternalsSomethingCausingAProblem(t)
+	crm.RegisterCustomerRetrying(t, "new customer", fc.Request, fc.Retries, opts...)
 
-	// When we
-	result := customerTestDriver.ActTryToRegisterACustomer(t, referenceCustomer, extraArgs)
-	// with valid data
+	if fc.RejectedBecause == "" {
+		crm.ExpectRegistered(t)
+		crm.ExpectRegisteredOnlyOnce(t, "new customer")
+		return
+	}
 
-	// Them the
-	customerTestDriver.AssertRegistrationShouldFailWithMessage(t, result, extraArgs, "system error", "contact support")
+	crm.ExpectRejectedBecause(t, fc.RejectedBecause, fc.FindOnError...)
+	crm.ExpectNotRegistered(t)
 }
 
 //
@@ -150,21 +226,34 @@ func shouldReturnAGenericSystemErrorOnFailure(
 //
 //
 
+// registrationStory is the user story of TestRegisterCustomer, as reported.
+const registrationStory = "Register a customer"
+
 // TestRegisterCustomer is the acceptance test suite for the customer registration
 // use case.
 func TestRegisterCustomer(t *testing.T) {
-	for _, variant := range []string{
-		referenceSUTVariant,
-		sqliteSUTVariant,
-		badgerSUTVariant,
-		referenceRESTSUTVariant,
-		sqliteRESTSUTVariant,
-		badgerRESTSUTVariant,
-	} {
-		t.Run(fmt.Sprintf("with system variant %s", variant), func(t *testing.T) {
-			customerTestDriver := sutSetup(t, variant)
+	for _, variant := range customer.SUTHarness.SelectedVariants(t) {
+		t.Run(fmt.Sprintf("with system variant %s", variant.Name), func(t *testing.T) {
+			t.Parallel()
+
+			// newCRM drives a new SUT, isolated from the other subtests, and
+			// reports the case `title` of the `scenario`.
+			newCRM := func(t *testing.T, scenario, title string) *dsl.CRMDSL {
+				entry := acceptanceReport.Record(t, report.Key{
+					Story:    registrationStory,
+					Scenario: scenario,
+					Case:     title,
+					Variant:  variant.Name,
+				})
+
+				return dsl.NewCRMDSL(variant.Setup(t).TestDriver).WithReport(entry)
+			}
 
 			t.Run("should register a customer with valid data", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should register a customer with valid data"
+
 				testData := loadYAMLTestData(t, "./data/valid-cases.yaml")
 
 				cases := extractCases(t, testData)
@@ -174,12 +263,19 @@ func TestRegisterCustomer(t *testing.T) {
 					extraArgs := extractExtraArgs(t, caseData)
 
 					t.Run(title, func(t *testing.T) {
-						shouldRegisterACustomerWithValidData(t, customerTestDriver, request, extraArgs)
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldRegisterACustomerWithValidData(t, crm, request, extraArgs)
 					})
 				}
 			})
 
 			t.Run("should reject a registration with invalid data", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should reject a registration with invalid data"
+
 				testData := loadYAMLTestData(t, "./data/invalidation-cases.yaml")
 
 				cases := extractCases(t, testData)
@@ -190,15 +286,22 @@ func TestRegisterCustomer(t *testing.T) {
 					findOnError := extractFindOnError(t, caseData)
 
 					t.Run(title, func(t *testing.T) {
-						shouldRejectARegistrationWithInvalidData(t, customerTestDriver, request, extraArgs, findOnError)
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldRejectARegistrationWithInvalidData(t, crm, request, extraArgs, findOnError)
 					})
 				}
 			})
 
 			t.Run("should reject a registration with duplicated data", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should reject a registration with duplicated data"
+
 				testData := loadYAMLTestData(t, "./data/duplication-cases.yaml")
 
-				referenceRequest := extractReferenceRequest(t, testData)
+				fixture := extractFixture(t, testData)
 
 				cases := extractCases(t, testData)
 				for title, bundle := range cases {
@@ -208,95 +311,151 @@ func TestRegisterCustomer(t *testing.T) {
 					findOnError := extractFindOnError(t, caseData)
 
 					t.Run(title, func(t *testing.T) {
-						shouldRejectARegistrationWithDuplicatedData(
-							t, customerTestDriver, referenceRequest, request, extraArgs, findOnError,
-						)
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldRejectARegistrationWithDuplicatedData(t, crm, fixture, request, extraArgs, findOnError)
 					})
 				}
 			})
 
 			t.Run("should not register the same user twice", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should not register the same user twice"
+
 				referenceCustomer := loadYAMLTestData(t, "./data/reference-customer.yaml")
 				extraArgs := loadYAMLTestData(t, "./data/conflict-extra-args.yaml")
 
-				shouldNotRegisterTheSameUserTwice(t, customerTestDriver, referenceCustomer, extraArgs)
+				crm := newCRM(t, scenario, "")
+				shouldNotRegisterTheSameUserTwice(t, crm, referenceCustomer, extraArgs)
+			})
+
+			t.Run("should reject an unauthorized registration", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should reject an unauthorized registration"
+
+				if variant.Options[customer.PresentationLayer] == "" {
+					t.Skip("callers are only authenticated by the REST presentation layer")
+				}
+
+				testData := loadYAMLTestData(t, "./data/unauthorized-cases.yaml")
+
+				cases := extractCases(t, testData)
+				for title, bundle := range cases {
+					caseData := bundleToCaseData(t, bundle)
+					request := extractRequest(t, caseData)
+					extraArgs := extractExtraArgs(t, caseData)
+					caller := extractCaller(t, caseData)
+					reason := extractRejectionReason(t, caseData)
+					findOnError := extractFindOnError(t, caseData)
+
+					t.Run(title, func(t *testing.T) {
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldRejectAnUnauthorizedRegistration(t, crm, request, extraArgs, caller, reason, findOnError)
+					})
+				}
+			})
+
+			t.Run("should restore a seeded snapshot", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should restore a seeded snapshot"
+
+				seed := seedSnapshot(t, dsl.NewCRMDSL(variant.Setup(t).TestDriver))
+
+				testData := loadYAMLTestData(t, "./data/valid-cases.yaml")
+
+				cases := extractCases(t, testData)
+				for title, bundle := range cases {
+					caseData := bundleToCaseData(t, bundle)
+					request := extractRequest(t, caseData)
+
+					t.Run(title, func(t *testing.T) {
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldRestoreASeededSnapshot(t, crm, seed, request)
+					})
+				}
+			})
+
+			t.Run("should cope with repository faults", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should cope with repository faults"
+
+				testData := loadYAMLTestData(t, "./data/fault-cases.yaml")
+
+				cases := extractCases(t, testData)
+				for title, bundle := range cases {
+					fc := driverdata.Bind[faultCase](t, bundleToCaseData(t, bundle))
+
+					t.Run(title, func(t *testing.T) {
+						t.Parallel()
+
+						crm := newCRM(t, scenario, title)
+						shouldCopeWithRepositoryFaults(t, crm, fc)
+					})
+				}
 			})
 
 			t.Run("should return a generic system error on failure", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should return a generic system error on failure"
+
 				referenceCustomer := loadYAMLTestData(t, "./data/reference-customer.yaml")
 				extraArgs := loadYAMLTestData(t, "./data/server-error-extra-args.yaml")
 
-				shouldReturnAGenericSystemErrorOnFailure(t, customerTestDriver, referenceCustomer, extraArgs)
+				crm := newCRM(t, scenario, "")
+				shouldReturnAGenericSystemErrorOnFailure(t, crm, referenceCustomer, extraArgs)
+			})
+
+			t.Run("should eventually store a customer saved in the background", func(t *testing.T) {
+				t.Parallel()
+
+				const scenario = "should eventually store a customer saved in the background"
+
+				referenceCustomer := loadYAMLTestData(t, "./data/reference-customer.yaml")
+
+				crm := newCRM(t, scenario, "")
+				shouldEventuallyStoreACustomerSavedInTheBackground(t, crm, referenceCustomer)
 			})
 		})
 	}
 }
 
 //
-// SUT Setup
-
-const (
-	referenceSUTVariant     = "reference"
-	referenceRESTSUTVariant = "reference-rest"
+// Seeding Helpers
 
-	sqliteSUTVariant     = "sqlite"
-	sqliteRESTSUTVariant = "sqlite-rest"
+// seededCustomers is the number of customers registered by seedSnapshot.
+const seededCustomers = 30
 
-	badgerSUTVariant     = "badger"
-	badgerRESTSUTVariant = "badger-rest"
-)
-
-// sutSetup creates a new CustomerService and CustomerServiceTestDriver for the
-// given SUT variant.
-func sutSetup(t *testing.T, variant string) *customer.CustomerServiceTestDriver {
+// seedSnapshot registers the seeded customers and snapshots the result.
+func seedSnapshot(t *testing.T, crm *dsl.CRMDSL) *dsl.Snapshot {
 	t.Helper()
 
-	var (
-		customerRepository        customer.CustomerRepository
-		repositoryTestDriver      customer.CustomerRepositoryTestDriver
-		customerService           *customer.CustomerService
-		customerServiceTestDriver *customer.CustomerServiceTestDriver
-	)
+	crm.GivenNoCustomers(t)
 
-	// Setup repository
-	switch variant {
-	case referenceSUTVariant, referenceRESTSUTVariant:
-		repo := reference.NewReferenceCustomerRepository()
-		customerRepository = repo
-		repositoryTestDriver = reference.NewReferenceCustomerRepositoryTestDriver(repo)
-
-	case sqliteSUTVariant, sqliteRESTSUTVariant:
-		repo := sqlitepoc.NewSQLiteCustomerRepository()
-		customerRepository = repo
-		repositoryTestDriver = sqlitepoc.NewSQLiteCustomerRepositoryTestDriver(repo)
-
-	case badgerSUTVariant, badgerRESTSUTVariant:
-		repo := badgerpoc.NewBadgerCustomerRepository()
-		customerRepository = repo
-		repositoryTestDriver = badgerpoc.NewBadgerCustomerRepositoryTestDriver(repo)
-
-	default:
-		t.Fatalf("unknown SUT variant: %s", variant)
+	for i := 1; i <= seededCustomers; i++ {
+		crm.GivenRegisteredCustomer(t, seededCustomerAlias(i), map[string]any{
+			"id":    fmt.Sprintf("SEED-%04d-0000", i),
+			"name":  "Seeded Customer " + string(rune('A'+(i-1)/26)) + string(rune('A'+(i-1)%26)),
+			"email": fmt.Sprintf("seeded.customer.%d@somecompany.com", i),
+			"phone": fmt.Sprintf("+1 555 000 %03d", i),
+		})
 	}
 
-	customerService = customer.NewCustomerService(customerRepository)
-
-	// Setup presentation
-	switch variant {
-	case referenceRESTSUTVariant, sqliteRESTSUTVariant, badgerRESTSUTVariant:
-		restAPIHandler := rest.NewCustomerRESTAPIHandler(customerService)
-
-		restPresentationTestDriver := rest.NewCustomerRESTAPIHandlerTestDriver(restAPIHandler)
-
-		customerServiceTestDriver = customer.NewCustomerServiceTestDriverWithPresentation(
-			customerService, repositoryTestDriver, restPresentationTestDriver,
-		)
-
-	default:
-		customerServiceTestDriver = customer.NewCustomerServiceTestDriver(customerService, repositoryTestDriver)
-	}
+	return crm.Snapshot(t)
+}
 
-	return customerServiceTestDriver
+// seededCustomerAlias is the alias of the i-th seeded customer, from 1.
+func seededCustomerAlias(i int) string {
+	return fmt.Sprintf("seeded customer #%d", i)
 }
 
 //
@@ -322,21 +481,18 @@ func loadYAMLTestData(t *testing.T, path string) map[string]any {
 	return td
 }
 
-// extractReferenceRequest extracts the reference request from the given test
+// extractFixture extracts the path of the fixture file from the given test
 // data.
 //
 // It looks for the following attributes:
-// - reference_request: map[string]any
-func extractReferenceRequest(t *testing.T, testData map[string]any) map[string]any {
+// - fixture: string
+func extractFixture(t *testing.T, testData map[string]any) string {
 	t.Helper()
 
-	r := require.New(t)
-
-	r.Contains(testData, "reference_request")
-	r.NotNil(testData["reference_request"])
-	r.IsType(map[string]any{}, testData["reference_request"])
+	fixture := driverdata.BindKey[string](t, testData, "fixture")
+	require.NotEmpty(t, fixture)
 
-	return testData["reference_request"].(map[string]any)
+	return fixture
 }
 
 // extractCases extracts the test cases from the given test data.
@@ -344,13 +500,12 @@ func extractReferenceRequest(t *testing.T, testData map[string]any) map[string]a
 // It looks for the following attributes:
 // - cases: map[string]any
 func extractCases(t *testing.T, testData map[string]any) map[string]any {
-	r := require.New(t)
+	t.Helper()
 
-	r.Contains(testData, "cases")
-	r.NotNil(testData["cases"])
-	r.IsType(map[string]any{}, testData["cases"])
+	cases := driverdata.BindKey[map[string]any](t, testData, "cases")
+	require.NotNil(t, cases)
 
-	return testData["cases"].(map[string]any)
+	return cases
 }
 
 // bundleToCaseData converts a bundle (any) to a map[string]any.
@@ -369,27 +524,47 @@ func bundleToCaseData(t *testing.T, bundle any) map[string]any {
 // It looks for the following attributes:
 // - request: map[string]any
 func extractRequest(t *testing.T, caseData map[string]any) map[string]any {
-	r := require.New(t)
+	t.Helper()
 
-	r.Contains(caseData, "request")
-	r.NotNil(caseData["request"])
-	r.IsType(map[string]any{}, caseData["request"])
+	request := driverdata.BindKey[map[string]any](t, caseData, "request")
+	require.NotNil(t, request)
 
-	return caseData["request"].(map[string]any)
+	return request
 }
 
-// extractExtraArgs extracts the extra args map from the given case data.
+// extractExtraArgs extracts the extra arguments given as is to the test
+// drivers from the given case data.
 //
 // It looks for the following attributes:
 // - extra_args: map[string]any
 func extractExtraArgs(t *testing.T, caseData map[string]any) map[string]any {
-	r := require.New(t)
+	t.Helper()
 
-	r.Contains(caseData, "extra_args")
-	r.NotNil(caseData["extra_args"])
-	r.IsType(map[string]any{}, caseData["extra_args"])
+	extraArgs := driverdata.BindKey[map[string]any](t, caseData, "extra_args")
+	require.NotNil(t, extraArgs)
 
-	return caseData["extra_args"].(map[string]any)
+	return extraArgs
+}
+
+// extractCaller extracts the caller from the given case data.
+//
+// It looks for the following attributes:
+// - caller: map[string]any
+func extractCaller(t *testing.T, caseData map[string]any) dsl.Caller {
+	t.Helper()
+
+	return driverdata.BindKey[dsl.Caller](t, caseData, "caller")
+}
+
+// extractRejectionReason extracts the reason why the registration is expected
+// to be rejected from the given case data.
+//
+// It looks for the following attributes:
+// - rejected_because: string
+func extractRejectionReason(t *testing.T, caseData map[string]any) dsl.Reason {
+	t.Helper()
+
+	return driverdata.BindKey[dsl.Reason](t, caseData, "rejected_because")
 }
 
 // extractFindOnError extracts the `find_on_error` attribute from the given case
@@ -398,19 +573,7 @@ func extractExtraArgs(t *testing.T, caseData map[string]any) map[string]any {
 // It looks for the following attributes:
 // - find_on_error: []string
 func extractFindOnError(t *testing.T, caseData map[string]any) []string {
-	r := require.New(t)
-
-	r.Contains(caseData, "find_on_error")
-	r.NotNil(caseData["find_on_error"])
-	r.IsType([]any{}, caseData["find_on_error"])
-
-	vals := caseData["find_on_error"].([]any)
-
-	findOnErrors := make([]string, len(vals))
-	for i, val := range vals {
-		r.IsType("", val)
-		findOnErrors[i] = val.(string)
-	}
+	t.Helper()
 
-	return findOnErrors
+	return driverdata.BindKey[[]string](t, caseData, "find_on_error")
 }
@@ -0,0 +1,59 @@
+Feature: Register a customer
+  As a sales person
+  I want to register the customers of my company
+  So that I can keep track of my relationship with them
+
+  Scenario Outline: should register a customer with valid data
+    Given that no customer is registered
+    When we try to register the customer
+    Then the registration should succeed
+    And the customer should be properly registered
+
+    @data(../data/valid-cases.yaml)
+    Examples: valid cases
+
+  Scenario Outline: should reject a registration with invalid data
+    Given that no customer is registered
+    When we try to register the customer
+    Then the registration should be rejected as "invalid data"
+    And the customer should not be registered
+
+    @data(../data/invalidation-cases.yaml)
+    Examples: invalid cases
+
+  Scenario Outline: should reject a registration with duplicated data
+    Given that the customers of the fixture are already registered
+    When we try to register the customer
+    Then the registration should be rejected as "duplicated data"
+    And the customer should not be registered
+
+    @data(../data/duplication-cases.yaml)
+    Examples: duplication cases
+
+  Scenario: should not register the same user twice
+    Given that the following customer is already registered:
+      | id             | name     | email                    | phone          |
+      | JHND-06A0-2UOA | John Due | john.due@somecompany.com | +1 234 567 890 |
+    When we try to register the same customer again
+    Then the registration should be rejected as "duplicated data"
+    And the error message should mention "duplicated name", "duplicated email" and "duplicated phone"
+    And the reference customer should not be duplicated
+
+  @rest
+  Scenario Outline: should reject an unauthorized registration
+    Given that no customer is registered
+    When we try to register the customer as the given caller
+    Then the registration should be rejected as "<rejected_because>"
+    And the customer should not be registered
+
+    @data(../data/unauthorized-cases.yaml)
+    Examples: unauthorized cases
+
+  Scenario: should return a generic system error on failure
+    Given that no customer is registered
+    And something is causing a problem
+    When we try to register the following customer:
+      | name     | email                    | phone          |
+      | John Due | john.due@somecompany.com | +1 234 567 890 |
+    Then the registration should be rejected as "system failure"
+    And the error message should mention "system error" and "contact support"
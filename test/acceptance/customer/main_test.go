@@ -0,0 +1,77 @@
+package customer_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/test/dataschema"
+	"github.com/maniosgrivei/go-test-drivers/test/report"
+)
+
+// dataSchemas maps the test data files, as glob patterns, to the schema they
+// must match.
+var dataSchemas = map[string]string{
+	"./data/*-cases.yaml":            "./data/schema/cases.schema.json",
+	"./data/*-scenarios.yaml":        "./data/schema/scenarios.schema.json",
+	"./data/reference-customer.yaml": "./data/schema/customer.schema.json",
+	"./data/fixtures/*.yaml":         "./data/schema/fixture.schema.json",
+}
+
+// acceptanceReport collects the outcomes of the suites, written as a living
+// documentation to the directory named by the GTD_REPORT_DIR environment
+// variable, if any.
+var acceptanceReport = report.New("Customer acceptance tests")
+
+// TestMain validates the test data files before running the suites, so that a
+// malformed file is reported with its line numbers instead of as a confusing
+// test failure. Then it writes the report of the suites, if asked to.
+func TestMain(m *testing.M) {
+	if err := validateTestData(); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid test data:")
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+
+	if dir := os.Getenv(report.DirEnv); dir != "" {
+		if err := acceptanceReport.WriteFiles(dir); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = max(code, 1)
+		}
+	}
+
+	os.Exit(code)
+}
+
+// validateTestData validates every test data file against its schema.
+func validateTestData() error {
+	var errs []error
+
+	for pattern, schemaPath := range dataSchemas {
+		schema, err := dataschema.LoadSchema(schemaPath)
+		if err != nil {
+			return err
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+
+		if len(paths) == 0 {
+			errs = append(errs, fmt.Errorf("no test data file matches '%s'", pattern))
+		}
+
+		for _, path := range paths {
+			if err := schema.ValidateFile(path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,248 @@
+package customer_test
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/dsl"
+	"github.com/maniosgrivei/go-test-drivers/test/gherkin"
+
+	"github.com/stretchr/testify/require"
+)
+
+//
+// World
+
+// registrationWorld holds the state of a registration scenario run from a
+// feature file.
+type registrationWorld struct {
+	testDriver *customer.CustomerServiceTestDriver
+
+	reference map[string]any
+	request   map[string]any
+	extraArgs map[string]any
+	result    map[string]any
+
+	// expectation is the extra arguments describing the expected response,
+	// set by the step checking the outcome of the registration.
+	expectation map[string]any
+
+	// outcome is the expected outcome of the registration, as reported.
+	outcome string
+}
+
+//
+// Steps
+
+// newRegistrationRunner binds the steps of the registration feature to the
+// test drivers created by `newTestDriver`, one per scenario.
+func newRegistrationRunner(
+	newTestDriver func(t *testing.T) *customer.CustomerServiceTestDriver,
+) *gherkin.Runner[*registrationWorld] {
+	runner := gherkin.NewRunner(func(t *testing.T) *registrationWorld {
+		return &registrationWorld{testDriver: newTestDriver(t)}
+	})
+
+	//
+	// Given
+
+	runner.Step(`that no customer is registered`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.testDriver.ArrangeInternalsNoCustomerIsRegistered(t)
+		})
+
+	runner.Step(`that the customers of the fixture are already registered`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			fixture := customer.LoadFixture(t, extractFixture(t, sc.Data))
+			w.testDriver.ArrangeInternalsFixtureIsLoaded(t, fixture)
+		})
+
+	runner.Step(`that the following customers? (?:is|are) already registered:`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			customers := tableToCustomers(t, sc.Table)
+			w.reference = customers[0]
+			w.testDriver.ArrangeInternalsSomeCustomersAreRegistered(t, customers...)
+		})
+
+	runner.Step(`something is causing a problem`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.testDriver.ArrangeInternalsSomethingCausingAProblem(t)
+		})
+
+	//
+	// When
+
+	runner.Step(`we try to register the customer`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.register(t, sc, extractRequest(t, sc.Example), nil)
+		})
+
+	runner.Step(`we try to register the customer as the given caller`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.register(t, sc, extractRequest(t, sc.Example), map[string]any{"caller": sc.Example["caller"]})
+		})
+
+	runner.Step(`we try to register the following customer:`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			customers := tableToCustomers(t, sc.Table)
+			require.Len(t, customers, 1)
+			w.register(t, sc, customers[0], nil)
+		})
+
+	runner.Step(`we try to register the same customer again`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			require.NotNil(t, w.reference, "no customer was registered before")
+			w.register(t, sc, w.reference, nil)
+		})
+
+	//
+	// Then
+
+	runner.Step(`the registration should succeed`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.expect("")
+			sc.Report.SetOutcome("registered")
+			w.testDriver.AssertRegistrationShouldSucceed(t, w.result, w.expectation)
+		})
+
+	runner.Step(`the registration should be rejected as "(.+)"`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			reason := dsl.Reason(sc.Args[0])
+			require.True(t, reason.Known(), "unknown rejection reason '%s'", reason)
+
+			var findOnError []string
+			if _, found := sc.Example["find_on_error"]; found {
+				findOnError = extractFindOnError(t, sc.Example)
+			}
+
+			w.expect(reason)
+			w.outcome = "rejected as " + string(reason)
+			sc.Report.SetOutcome(w.outcome, findOnError...)
+			w.testDriver.AssertRegistrationShouldFailWithMessage(t, w.result, w.expectation, findOnError...)
+		})
+
+	runner.Step(`the error message should mention (".+")`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			require.NotNil(t, w.expectation, "the outcome of the registration was not checked yet")
+
+			details := quotedStrings(sc.Args[0])
+			sc.Report.SetOutcome(w.outcome, details...)
+
+			w.testDriver.AssertRegistrationShouldFailWithMessage(t, w.result, w.expectation, details...)
+		})
+
+	runner.Step(`the customer should be properly registered`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.testDriver.AssertInternalsCustomerShouldBeProperlyRegistered(t, w.request)
+		})
+
+	runner.Step(`the customer should not be registered`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.testDriver.AssertInternalsCustomerShouldNotBeRegistered(t, w.request)
+		})
+
+	runner.Step(`the reference customer should not be duplicated`,
+		func(t *testing.T, w *registrationWorld, sc *gherkin.StepContext) {
+			w.testDriver.AssertInternalsCustomerShouldNotBeDuplicated(t, w.reference)
+		})
+
+	return runner
+}
+
+// register tries to register a copy of `request`, without its ID, and reports
+// it as sent.
+func (w *registrationWorld) register(
+	t *testing.T,
+	sc *gherkin.StepContext,
+	request map[string]any,
+	extraArgs map[string]any,
+) {
+	t.Helper()
+
+	w.request = maps.Clone(request)
+	delete(w.request, "id")
+
+	w.extraArgs = map[string]any{}
+	maps.Copy(w.extraArgs, extraArgs)
+
+	w.result = w.testDriver.ActTryToRegisterACustomer(t, w.request, w.extraArgs)
+
+	sent := maps.Clone(w.request)
+	delete(sent, "id")
+	sc.Report.SetRequest(sent)
+}
+
+// expect sets the expected outcome of the registration: a rejection for
+// `reason`, or a success when empty.
+func (w *registrationWorld) expect(reason dsl.Reason) {
+	w.expectation = maps.Clone(w.extraArgs)
+	if reason != "" {
+		w.expectation["rejected_because"] = string(reason)
+	}
+}
+
+//
+// Step Helpers
+
+// tableToCustomers converts a data table with `name`, `email`, `phone` and
+// optional `id` columns to customer maps.
+func tableToCustomers(t *testing.T, table *gherkin.Table) []map[string]any {
+	t.Helper()
+
+	rows := table.Maps()
+	require.NotEmpty(t, rows, "the customers table should have a header and at least one row")
+
+	customers := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		customers[i] = make(map[string]any, len(row))
+		for k, v := range row {
+			customers[i][k] = v
+		}
+	}
+
+	return customers
+}
+
+// quotedStringRegexp matches the double-quoted strings of a step.
+var quotedStringRegexp = regexp.MustCompile(`"([^"]*)"`)
+
+// quotedStrings extracts the double-quoted strings from `s`.
+func quotedStrings(s string) []string {
+	var values []string
+	for _, match := range quotedStringRegexp.FindAllStringSubmatch(s, -1) {
+		values = append(values, match[1])
+	}
+
+	return values
+}
+
+//
+// Test Suite
+
+// TestRegisterCustomerFeature runs the registration feature file against each
+// SUT variant.
+func TestRegisterCustomerFeature(t *testing.T) {
+	for _, variant := range customer.SUTHarness.SelectedVariants(t) {
+		t.Run(fmt.Sprintf("with system variant %s", variant.Name), func(t *testing.T) {
+			t.Parallel()
+
+			runner := newRegistrationRunner(func(t *testing.T) *customer.CustomerServiceTestDriver {
+				return variant.Setup(t).TestDriver
+			})
+			runner.Parallel()
+			runner.Report(acceptanceReport, variant.Name)
+
+			runner.BeforeScenario(func(t *testing.T, tags []string) {
+				if slices.Contains(tags, "@rest") && variant.Options[customer.PresentationLayer] == "" {
+					t.Skip("callers are only authenticated by the REST presentation layer")
+				}
+			})
+
+			runner.Run(t, "./features/register_customer.feature")
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package customer_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/dsl"
+	"github.com/maniosgrivei/go-test-drivers/test/harness"
+	"github.com/maniosgrivei/go-test-drivers/test/property"
+	"github.com/maniosgrivei/go-test-drivers/test/report"
+)
+
+// oracleVariant finds the variant used as the oracle of the model-based tests:
+// the reference repository, driven without a presentation layer.
+func oracleVariant(t *testing.T) harness.Variant[customer.SUT] {
+	t.Helper()
+
+	for _, variant := range customer.SUTHarness.Variants() {
+		if variant.Options[customer.RepositoryLayer] == "reference" && variant.Options[customer.PresentationLayer] == "" {
+			return variant
+		}
+	}
+
+	t.Fatal("the reference variant is not registered")
+
+	return harness.Variant[customer.SUT]{}
+}
+
+// registrationOutcomes registers the `requests` in order, starting from a CRM
+// without any customer, and returns the outcome of each registration.
+func registrationOutcomes(t *testing.T, td *customer.CustomerServiceTestDriver, requests []customer.RegisterRequest) []string {
+	t.Helper()
+
+	crm := dsl.NewCRMDSL(td)
+	crm.GivenNoCustomers(t)
+
+	outcomes := make([]string, len(requests))
+	for i, request := range requests {
+		crm.RegisterCustomer(t, "", map[string]any{
+			"name":  request.Name,
+			"email": request.Email,
+			"phone": request.Phone,
+		})
+
+		outcomes[i] = crm.LastOutcome(t)
+	}
+
+	return outcomes
+}
+
+// TestRegisterCustomerProperties issues random sequences of registrations
+// against every SUT variant, and checks that each registration has the same
+// outcome as with the reference repository. The failing sequences are shrunk
+// to minimal ones.
+func TestRegisterCustomerProperties(t *testing.T) {
+	oracle := oracleVariant(t)
+
+	for _, variant := range customer.SUTHarness.SelectedVariants(t) {
+		if variant.Name == oracle.Name {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("with system variant %s", variant.Name), func(t *testing.T) {
+			t.Parallel()
+
+			const scenario = "should agree with the reference repository on random registrations"
+
+			if variant.Options[customer.RepositoryLayer] == customer.RemoteRepository {
+				t.Skip("the repository behind an external server can't be emptied between the sequences")
+			}
+
+			entry := acceptanceReport.Record(t, report.Key{
+				Story:    registrationStory,
+				Scenario: scenario,
+				Variant:  variant.Name,
+			})
+			entry.SetOutcome("the same outcome as " + oracle.Name + " for every registration")
+
+			// Both SUTs are reset by each sequence, rather than set up again.
+			sut := variant.Setup(t).TestDriver
+			reference := oracle.Setup(t).TestDriver
+
+			property.Check(t, customer.GenRegistrations, customer.ShrinkRegistrations,
+				func(t *testing.T, requests []customer.RegisterRequest) error {
+					expected := registrationOutcomes(t, reference, requests)
+					actual := registrationOutcomes(t, sut, requests)
+
+					var diffs []string
+					for i := range requests {
+						if actual[i] != expected[i] {
+							diffs = append(diffs, fmt.Sprintf("registration #%d %+v: %s instead of %s",
+								i, requests[i], actual[i], expected[i]))
+						}
+					}
+
+					if len(diffs) > 0 {
+						return fmt.Errorf("the outcomes differ from %s:\n%s", oracle.Name, strings.Join(diffs, "\n"))
+					}
+
+					return nil
+				},
+			)
+		})
+	}
+}
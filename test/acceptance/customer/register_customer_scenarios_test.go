@@ -0,0 +1,25 @@
+package customer_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/scenario"
+)
+
+// TestRegisterCustomerScenarios runs the multi-step registration scenarios
+// against each SUT variant.
+func TestRegisterCustomerScenarios(t *testing.T) {
+	for _, variant := range customer.SUTHarness.SelectedVariants(t) {
+		t.Run(fmt.Sprintf("with system variant %s", variant.Name), func(t *testing.T) {
+			t.Parallel()
+
+			runner := scenario.NewRunner(variant)
+			runner.Parallel()
+			runner.Report(acceptanceReport, registrationStory)
+
+			runner.Run(t, "./data/registration-scenarios.yaml")
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package dataschema validates YAML test data files against JSON Schemas,
+// reporting every violation with the line and column where it was found.
+//
+// It implements the subset of JSON Schema (draft 2020-12) needed by the test
+// data of this repository: `type`, `properties`, `required`,
+// `additionalProperties`, `minProperties`, `items`, `minItems`, `minLength`,
+// `pattern`, `enum`, and local `$ref`s to `#/$defs/...`. The annotations
+// `title`, `description`, `$schema` and `$id` are accepted and ignored. Any
+// other keyword is rejected, so that a typo or a keyword outside the subset
+// does not silently turn a validation off.
+package dataschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Ref  string             `json:"$ref"`
+	Defs map[string]*Schema `json:"$defs"`
+
+	Type                 Types              `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *Additional        `json:"additionalProperties"`
+	MinProperties        *int               `json:"minProperties"`
+	Items                *Schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+
+	pattern *regexp.Regexp
+	root    *Schema
+}
+
+// keywords are the keywords of the supported subset, and the annotations
+// accepted besides.
+var keywords = []string{
+	"$ref", "$defs",
+	"type", "enum", "properties", "required", "additionalProperties", "minProperties",
+	"items", "minItems", "minLength", "pattern",
+	"title", "description", "$schema", "$id",
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, rejecting the
+// keywords outside the supported subset.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	var unsupported []string
+	for keyword := range fields {
+		if !slices.Contains(keywords, keyword) {
+			unsupported = append(unsupported, keyword)
+		}
+	}
+
+	if len(unsupported) > 0 {
+		slices.Sort(unsupported)
+		return fmt.Errorf("unsupported keywords '%s'", strings.Join(unsupported, "', '"))
+	}
+
+	// plain has the fields of Schema without its UnmarshalJSON.
+	type plain Schema
+
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// Types is the `type` keyword, given either as a single type or as a list.
+type Types []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (ts *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*ts = Types{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("type should be a string or a list of strings")
+	}
+
+	*ts = list
+
+	return nil
+}
+
+// Additional is the `additionalProperties` keyword, given either as a boolean
+// or as a schema.
+type Additional struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (a *Additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+	a.Schema = &Schema{}
+
+	return json.Unmarshal(data, a.Schema)
+}
+
+// LoadSchema reads and compiles the JSON Schema at `path`.
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := ParseSchema(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// ParseSchema parses and compiles a JSON Schema.
+func ParseSchema(data []byte) (*Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	if err := s.compile(&s); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+
+	return &s, nil
+}
+
+// compile checks the schema, compiles its patterns and resolves its
+// references against `root`.
+func (s *Schema) compile(root *Schema) error {
+	s.root = root
+
+	if s.Ref != "" {
+		if _, err := s.resolve(); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range s.Type {
+		switch t {
+		case "object", "array", "string", "integer", "number", "boolean", "null":
+		default:
+			return fmt.Errorf("unknown type '%s'", t)
+		}
+	}
+
+	if s.Pattern != "" {
+		var err error
+		if s.pattern, err = regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", s.Pattern, err)
+		}
+	}
+
+	children := []*Schema{s.Items}
+	for _, def := range s.Defs {
+		children = append(children, def)
+	}
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	if s.AdditionalProperties != nil {
+		children = append(children, s.AdditionalProperties.Schema)
+	}
+
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if err := child.compile(root); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve follows the `$ref` of the schema, if any.
+func (s *Schema) resolve() (*Schema, error) {
+	if s.Ref == "" {
+		return s, nil
+	}
+
+	name, found := strings.CutPrefix(s.Ref, "#/$defs/")
+	if !found {
+		return nil, fmt.Errorf("unsupported reference '%s': only '#/$defs/...' is supported", s.Ref)
+	}
+
+	def, found := s.root.Defs[name]
+	if !found {
+		return nil, fmt.Errorf("unknown reference '%s'", s.Ref)
+	}
+
+	return def, nil
+}
@@ -0,0 +1,337 @@
+package dataschema
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Violation is a place where a document does not match its schema.
+type Violation struct {
+	File   string
+	Line   int
+	Column int
+
+	// Path locates the offending value, e.g. `cases."when missing name".request`.
+	Path    string
+	Message string
+}
+
+// String formats the violation as `file:line:column: path: message`.
+func (v Violation) String() string {
+	path := v.Path
+	if path == "" {
+		path = "(root)"
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %s: %s", v.File, v.Line, v.Column, path, v.Message)
+}
+
+// ValidationError lists all the violations found in a document.
+type ValidationError struct {
+	Violations []Violation
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ValidateFile validates the YAML file at `path` against the schema. It
+// returns a *ValidationError when the file does not match it.
+func (s *Schema) ValidateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	return s.Validate(path, data)
+}
+
+// Validate validates a YAML document against the schema. The `file` is only
+// used to report violations. It returns a *ValidationError when the document
+// does not match the schema.
+func (s *Schema) Validate(file string, data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	v := &validator{file: file}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			v.report(root, "", "the document is empty")
+			return &ValidationError{Violations: v.violations}
+		}
+		root = root.Content[0]
+	}
+
+	v.validate(s, root, "")
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+
+	return nil
+}
+
+//
+// Validator
+
+// validator collects the violations found in a document.
+type validator struct {
+	file       string
+	violations []Violation
+}
+
+// report records a violation found at `node`.
+func (v *validator) report(node *yaml.Node, path, format string, args ...any) {
+	v.violations = append(v.violations, Violation{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks `node` against `s`.
+func (v *validator) validate(s *Schema, node *yaml.Node, path string) {
+	s, err := s.resolve()
+	if err != nil {
+		v.report(node, path, "%v", err)
+		return
+	}
+
+	node = deref(node)
+	typ := nodeType(node)
+
+	if len(s.Type) > 0 && !slices.ContainsFunc(s.Type, func(t string) bool { return typeMatches(t, typ) }) {
+		v.report(node, path, "should be %s, got %s", strings.Join(s.Type, " or "), typ)
+		return
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return enumMatches(e, node) }) {
+		v.report(node, path, "should be one of %s, got %s", formatEnum(s.Enum), node.Value)
+	}
+
+	switch typ {
+	case "object":
+		v.validateObject(s, node, path)
+
+	case "array":
+		v.validateArray(s, node, path)
+
+	case "string":
+		if s.MinLength != nil && len([]rune(node.Value)) < *s.MinLength {
+			v.report(node, path, "should have at least %d characters", *s.MinLength)
+		}
+
+		if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+			v.report(node, path, "should match the pattern '%s'", s.Pattern)
+		}
+	}
+}
+
+// validateObject checks the properties of a mapping node.
+func (v *validator) validateObject(s *Schema, node *yaml.Node, path string) {
+	pairs := mappingPairs(node)
+
+	if s.MinProperties != nil && len(pairs) < *s.MinProperties {
+		v.report(node, path, "should have at least %d entries", *s.MinProperties)
+	}
+
+	present := make(map[string]bool, len(pairs))
+
+	for _, pair := range pairs {
+		key := pair.key.Value
+		present[key] = true
+		keyPath := joinPath(path, key)
+
+		if prop, found := s.Properties[key]; found {
+			v.validate(prop, pair.value, keyPath)
+			continue
+		}
+
+		switch {
+		case s.AdditionalProperties == nil:
+		case s.AdditionalProperties.Schema != nil:
+			v.validate(s.AdditionalProperties.Schema, pair.value, keyPath)
+		case !s.AdditionalProperties.Allowed:
+			v.report(pair.key, keyPath, "unknown key '%s'", key)
+		}
+	}
+
+	for _, key := range s.Required {
+		if !present[key] {
+			v.report(node, path, "missing required key '%s'", key)
+		}
+	}
+}
+
+// validateArray checks the items of a sequence node.
+func (v *validator) validateArray(s *Schema, node *yaml.Node, path string) {
+	if s.MinItems != nil && len(node.Content) < *s.MinItems {
+		v.report(node, path, "should have at least %d items", *s.MinItems)
+	}
+
+	if s.Items == nil {
+		return
+	}
+
+	for i, item := range node.Content {
+		v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+//
+// YAML Helpers
+
+// pair is a key-value pair of a mapping node.
+type pair struct {
+	key, value *yaml.Node
+}
+
+// mappingPairs returns the pairs of a mapping node, expanding the `<<` merge
+// keys. Explicit keys take precedence over merged ones.
+func mappingPairs(node *yaml.Node) []pair {
+	var (
+		explicit []pair
+		merged   []pair
+	)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if key.ShortTag() != "!!merge" {
+			explicit = append(explicit, pair{key, value})
+			continue
+		}
+
+		value = deref(value)
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+
+		for _, src := range sources {
+			if src = deref(src); src.Kind == yaml.MappingNode {
+				merged = append(merged, mappingPairs(src)...)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(explicit)+len(merged))
+	pairs := make([]pair, 0, len(explicit)+len(merged))
+
+	for _, p := range append(explicit, merged...) {
+		if seen[p.key.Value] {
+			continue
+		}
+		seen[p.key.Value] = true
+		pairs = append(pairs, p)
+	}
+
+	return pairs
+}
+
+// deref follows aliases to the node they refer to.
+func deref(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+
+	return node
+}
+
+// nodeType returns the JSON Schema type of a node.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+
+	return "string"
+}
+
+// typeMatches checks if a node of type `actual` satisfies the schema type
+// `expected`. Integers are numbers too.
+func typeMatches(expected, actual string) bool {
+	return expected == actual || (expected == "number" && actual == "integer")
+}
+
+// enumMatches checks if a scalar node is equal to an enum value.
+func enumMatches(e any, node *yaml.Node) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+
+	var val any
+	if err := node.Decode(&val); err != nil {
+		return false
+	}
+
+	// JSON numbers are float64, YAML integers are int.
+	if n, ok := e.(float64); ok {
+		switch v := val.(type) {
+		case int:
+			return float64(v) == n
+		case float64:
+			return v == n
+		}
+	}
+
+	return reflect.DeepEqual(e, val)
+}
+
+// formatEnum formats the enum values for an error message.
+func formatEnum(enum []any) string {
+	values := make([]string, len(enum))
+	for i, e := range enum {
+		if s, ok := e.(string); ok {
+			values[i] = strconv.Quote(s)
+		} else {
+			values[i] = fmt.Sprint(e)
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// joinPath appends `key` to `path`, quoting keys containing spaces or dots.
+func joinPath(path, key string) string {
+	if strings.ContainsAny(key, " .[]\"") {
+		key = strconv.Quote(key)
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
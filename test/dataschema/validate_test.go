@@ -0,0 +1,140 @@
+package dataschema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSchema = `{
+  "type": "object",
+  "required": ["cases"],
+  "properties": {
+    "cases": {
+      "type": "object",
+      "minProperties": 1,
+      "additionalProperties": { "$ref": "#/$defs/case" }
+    }
+  },
+  "$defs": {
+    "case": {
+      "type": "object",
+      "required": ["request"],
+      "properties": {
+        "request": {
+          "type": "object",
+          "properties": {
+            "name": { "type": "string", "minLength": 1 },
+            "age": { "type": ["integer", "null"] }
+          },
+          "additionalProperties": false
+        },
+        "tags": { "type": "array", "minItems": 1, "items": { "type": "string", "pattern": "^[a-z]+$" } },
+        "status": { "enum": [200, "pending"] }
+      },
+      "additionalProperties": false
+    }
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := ParseSchema([]byte(sampleSchema))
+	require.NoError(t, err)
+
+	t.Run("should accept a valid document", func(t *testing.T) {
+		err := schema.Validate("valid.yaml", []byte(`
+base: &base
+  name: "John"
+cases:
+  "when merged":
+    request:
+      <<: *base
+      age: 42
+    tags: ["a", "b"]
+    status: 200
+  "when null":
+    request: { name: "Mary", age: ~ }
+    status: pending
+`))
+		require.NoError(t, err)
+	})
+
+	t.Run("should report every violation with its position", func(t *testing.T) {
+		err := schema.Validate("invalid.yaml", []byte(`base: &base
+  nmae: "John"
+cases:
+  "when merged":
+    request:
+      <<: *base
+      age: "42"
+    tags: []
+    status: 201
+  "when empty":
+    tags: ["UPPER"]
+`))
+
+		var verr *ValidationError
+		require.True(t, errors.As(err, &verr))
+		require.Equal(t, []string{
+			`invalid.yaml:7:12: cases."when merged".request.age: should be integer or null, got string`,
+			`invalid.yaml:2:3: cases."when merged".request.nmae: unknown key 'nmae'`,
+			`invalid.yaml:8:11: cases."when merged".tags: should have at least 1 items`,
+			`invalid.yaml:9:13: cases."when merged".status: should be one of 200, "pending", got 201`,
+			`invalid.yaml:11:12: cases."when empty".tags[0]: should match the pattern '^[a-z]+$'`,
+			`invalid.yaml:11:5: cases."when empty": missing required key 'request'`,
+		}, violations(verr))
+	})
+
+	t.Run("should report a map with too few entries", func(t *testing.T) {
+		err := schema.Validate("empty.yaml", []byte("cases: {}\n"))
+		require.EqualError(t, err, "empty.yaml:1:8: cases: should have at least 1 entries")
+	})
+}
+
+func TestParseSchema(t *testing.T) {
+	for title, tc := range map[string]struct {
+		schema string
+		err    string
+	}{
+		"when a type is unknown": {
+			schema: `{"type": "text"}`,
+			err:    "invalid schema: unknown type 'text'",
+		},
+		"when a reference is unknown": {
+			schema: `{"properties": {"a": {"$ref": "#/$defs/missing"}}}`,
+			err:    "invalid schema: unknown reference '#/$defs/missing'",
+		},
+		"when a reference is not local": {
+			schema: `{"$ref": "https://example.com/schema.json"}`,
+			err:    "invalid schema: unsupported reference 'https://example.com/schema.json': only '#/$defs/...' is supported",
+		},
+		"when a keyword is misspelled": {
+			schema: `{"type": "object", "requried": ["name"]}`,
+			err:    "invalid schema: unsupported keywords 'requried'",
+		},
+		"when nested keywords are outside the subset": {
+			schema: `{"$defs": {"name": {"type": "string", "maxLength": 10, "format": "email"}}}`,
+			err:    "invalid schema: unsupported keywords 'format', 'maxLength'",
+		},
+		"when a pattern is invalid": {
+			schema: `{"pattern": "("}`,
+			err:    "invalid schema: invalid pattern '(': error parsing regexp: missing closing ): `(`",
+		},
+	} {
+		t.Run(title, func(t *testing.T) {
+			_, err := ParseSchema([]byte(tc.schema))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+// violations formats the violations of a validation error.
+func violations(verr *ValidationError) []string {
+	lines := make([]string, len(verr.Violations))
+	for i, v := range verr.Violations {
+		lines[i] = v.String()
+	}
+
+	return lines
+}
@@ -0,0 +1,205 @@
+// Package differential feeds the same stream of operations to several
+// customer.CustomerRepository implementations, and reports where they diverge
+// from the first one, the baseline: in the category of the returned errors, in
+// their messages, or in the resulting state.
+//
+// The state of a repository is only compared when it implements Lister.
+package differential
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+)
+
+// Lister is implemented by the repositories able to list their customers.
+type Lister interface {
+	// List returns all the customers of the repository, ordered by ID.
+	List() ([]*customer.Customer, error)
+}
+
+// Target is a repository under comparison.
+type Target struct {
+	Name       string
+	Repository customer.CustomerRepository
+}
+
+// Aspects of the results in which the targets may diverge.
+const (
+	AspectCategory = "category"
+	AspectMessage  = "message"
+	AspectState    = "state"
+)
+
+// Categories of the errors returned by the repositories.
+const (
+	CategoryOK          = "ok"
+	CategoryDuplication = "duplication"
+	CategorySystem      = "system"
+	CategoryUnknown     = "unknown"
+)
+
+// Divergence is a difference between the result of an operation on a target
+// and on the baseline.
+type Divergence struct {
+	// Step is the index of the operation in the stream, starting at zero.
+	Step      int
+	Operation Operation
+
+	Target string
+	Aspect string
+
+	// Expected is what the baseline got, and Actual what the target got. For
+	// the state, they are the customers found only in the baseline and only
+	// in the target, respectively.
+	Expected string
+	Actual   string
+}
+
+func (d Divergence) String() string {
+	if d.Aspect == AspectState {
+		return fmt.Sprintf("#%d %s: %s state lacks %s and has %s", d.Step, d.Operation, d.Target, d.Expected, d.Actual)
+	}
+
+	return fmt.Sprintf("#%d %s: %s %s %q instead of %q", d.Step, d.Operation, d.Target, d.Aspect, d.Actual, d.Expected)
+}
+
+// Report is the result of a run.
+type Report struct {
+	Baseline    string
+	Steps       int
+	Divergences []Divergence
+}
+
+// Err returns an error listing the divergences, or nil when there is none.
+func (r *Report) Err() error {
+	if len(r.Divergences) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(r.Divergences))
+	for i, d := range r.Divergences {
+		lines[i] = d.String()
+	}
+
+	return fmt.Errorf("%d divergences from %s:\n%s", len(r.Divergences), r.Baseline, strings.Join(lines, "\n"))
+}
+
+// Run applies the operations in order to every target, the targets of an
+// operation simultaneously, and compares their results with the ones of the
+// first target, the baseline. A state divergence is reported at the step it
+// appears, not again at the following steps while it remains the same.
+func Run(targets []Target, ops []Operation) (*Report, error) {
+	if len(targets) < 2 {
+		return nil, errors.New("at least two targets are needed")
+	}
+
+	report := &Report{Baseline: targets[0].Name}
+
+	lastState := make([]Divergence, len(targets))
+	for i := range lastState {
+		lastState[i].Expected, lastState[i].Actual = sameState, sameState
+	}
+
+	for step, op := range ops {
+		outcomes := make([]error, len(targets))
+
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				outcomes[i] = op.apply(target.Repository)
+			}()
+		}
+		wg.Wait()
+
+		states := make([][]*customer.Customer, len(targets))
+		listed := make([]bool, len(targets))
+		for i, target := range targets {
+			lister, ok := target.Repository.(Lister)
+			if !ok {
+				continue
+			}
+
+			state, err := lister.List()
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the customers of %s at step #%d: %w", target.Name, step, err)
+			}
+			states[i], listed[i] = state, true
+		}
+
+		for i := 1; i < len(targets); i++ {
+			divergence := Divergence{Step: step, Operation: op, Target: targets[i].Name}
+
+			expected, actual := Category(outcomes[0]), Category(outcomes[i])
+			switch {
+			case expected != actual:
+				divergence.Aspect, divergence.Expected, divergence.Actual = AspectCategory, expected, actual
+				report.Divergences = append(report.Divergences, divergence)
+
+			case outcomes[0] != nil && outcomes[0].Error() != outcomes[i].Error():
+				divergence.Aspect, divergence.Expected, divergence.Actual = AspectMessage, outcomes[0].Error(), outcomes[i].Error()
+				report.Divergences = append(report.Divergences, divergence)
+			}
+
+			if !listed[0] || !listed[i] {
+				continue
+			}
+
+			missing, unexpected := diffStates(states[0], states[i])
+			if missing == lastState[i].Expected && unexpected == lastState[i].Actual {
+				continue
+			}
+
+			divergence.Aspect, divergence.Expected, divergence.Actual = AspectState, missing, unexpected
+			lastState[i] = divergence
+			if missing != sameState || unexpected != sameState {
+				report.Divergences = append(report.Divergences, divergence)
+			}
+		}
+
+		report.Steps++
+	}
+
+	return report, nil
+}
+
+// Category returns the category of an error returned by a repository.
+func Category(err error) string {
+	switch {
+	case err == nil:
+		return CategoryOK
+	case errors.Is(err, customer.ErrDuplication):
+		return CategoryDuplication
+	case errors.Is(err, customer.ErrSystem):
+		return CategorySystem
+	default:
+		return CategoryUnknown
+	}
+}
+
+// sameState is what diffStates finds when the states are the same.
+const sameState = "[]"
+
+// diffStates returns the customers of `expected` missing from `actual`, and
+// the ones of `actual` missing from `expected`, formatted as lists.
+func diffStates(expected, actual []*customer.Customer) (missing, unexpected string) {
+	return onlyIn(expected, actual), onlyIn(actual, expected)
+}
+
+// onlyIn formats the customers of `state` missing from `other`.
+func onlyIn(state, other []*customer.Customer) string {
+	var only []string
+	for _, c := range state {
+		if !slices.ContainsFunc(other, func(o *customer.Customer) bool { return *o == *c }) {
+			only = append(only, fmt.Sprintf("%+v", *c))
+		}
+	}
+
+	return "[" + strings.Join(only, " ") + "]"
+}
@@ -0,0 +1,177 @@
+package differential
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	badgerpoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/badger-poc"
+	"github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/reference"
+	sqlitepoc "github.com/maniosgrivei/go-test-drivers/customer/adapters/repository/sqlite-poc"
+	"github.com/stretchr/testify/require"
+)
+
+// faultyRepository alters the behaviour of a reference repository.
+type faultyRepository struct {
+	*reference.ReferenceCustomerRepository
+
+	// save replaces the Save of the reference repository.
+	save func(repo *reference.ReferenceCustomerRepository, c *customer.Customer) error
+}
+
+func (r *faultyRepository) Save(c *customer.Customer) error {
+	return r.save(r.ReferenceCustomerRepository, c)
+}
+
+func saveOf(c customer.Customer) Operation {
+	return Operation{Save: &c}
+}
+
+var (
+	john = customer.Customer{ID: "JHND-1", Name: "John Doe", Email: "john@doe.com", Phone: "+1 555 0001"}
+	jane = customer.Customer{ID: "JNDS-2", Name: "Jane Doe", Email: "jane@doe.com", Phone: "+1 555 0002"}
+)
+
+func TestRun(t *testing.T) {
+	t.Run("should report nothing when the targets agree", func(t *testing.T) {
+		report, err := Run([]Target{
+			{"reference", reference.NewReferenceCustomerRepository()},
+			{"other", reference.NewReferenceCustomerRepository()},
+		}, []Operation{saveOf(john), saveOf(jane), saveOf(john)})
+
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Steps)
+		require.Empty(t, report.Divergences)
+		require.NoError(t, report.Err())
+	})
+
+	t.Run("should report the divergences of category, message and state", func(t *testing.T) {
+		faulty := &faultyRepository{
+			ReferenceCustomerRepository: reference.NewReferenceCustomerRepository(),
+			save: func(repo *reference.ReferenceCustomerRepository, c *customer.Customer) error {
+				switch c.Name {
+				case jane.Name:
+					// Loses Jane, but reports a system error.
+					return customer.ErrSystem
+				default:
+					if err := repo.Save(c); err != nil {
+						return fmt.Errorf("%w: %s", customer.ErrDuplication, "duplicated customer")
+					}
+					return nil
+				}
+			},
+		}
+
+		report, err := Run([]Target{
+			{"reference", reference.NewReferenceCustomerRepository()},
+			{"faulty", faulty},
+		}, []Operation{saveOf(john), saveOf(jane), saveOf(john), saveOf(jane)})
+
+		require.NoError(t, err)
+		require.Equal(t, []Divergence{
+			{
+				Step: 1, Operation: saveOf(jane), Target: "faulty",
+				Aspect: AspectCategory, Expected: CategoryOK, Actual: CategorySystem,
+			},
+			{
+				Step: 1, Operation: saveOf(jane), Target: "faulty",
+				Aspect: AspectState, Expected: fmt.Sprintf("[%+v]", jane), Actual: "[]",
+			},
+			{
+				Step: 2, Operation: saveOf(john), Target: "faulty",
+				Aspect:   AspectMessage,
+				Expected: "duplication error: duplicated id: 'JHND-1'\nduplicated name: 'John Doe'\nduplicated email: 'john@doe.com'\nduplicated phone: '+1 555 0001'",
+				Actual:   "duplication error: duplicated customer",
+			},
+			{
+				Step: 3, Operation: saveOf(jane), Target: "faulty",
+				Aspect: AspectCategory, Expected: CategoryDuplication, Actual: CategorySystem,
+			},
+		}, report.Divergences)
+		require.ErrorContains(t, report.Err(), "4 divergences from reference:\n#1 save")
+	})
+
+	t.Run("should report a backend returning another error for the same duplication", func(t *testing.T) {
+		// Names the customer instead of its ID, as the SQLite repository of the
+		// baseline did.
+		misnaming := &faultyRepository{
+			ReferenceCustomerRepository: reference.NewReferenceCustomerRepository(),
+			save: func(repo *reference.ReferenceCustomerRepository, c *customer.Customer) error {
+				if err := repo.Save(c); err != nil {
+					return fmt.Errorf("%w: duplicated id: '%s'", customer.ErrDuplication, c.Name)
+				}
+				return nil
+			},
+		}
+
+		moved := jane
+		moved.ID = john.ID
+
+		report, err := Run([]Target{
+			{"reference", reference.NewReferenceCustomerRepository()},
+			{"misnaming", misnaming},
+		}, []Operation{saveOf(john), saveOf(moved)})
+
+		require.NoError(t, err)
+		require.Equal(t, []Divergence{
+			{
+				Step: 1, Operation: saveOf(moved), Target: "misnaming",
+				Aspect:   AspectMessage,
+				Expected: "duplication error: duplicated id: 'JHND-1'",
+				Actual:   "duplication error: duplicated id: 'Jane Doe'",
+			},
+		}, report.Divergences)
+	})
+
+	t.Run("should need two targets", func(t *testing.T) {
+		_, err := Run([]Target{{"reference", reference.NewReferenceCustomerRepository()}}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestRunBackends(t *testing.T) {
+	for seed := range uint64(20) {
+		t.Run(fmt.Sprintf("with seed %d", seed), func(t *testing.T) {
+			sqlite := sqlitepoc.NewSQLiteCustomerRepository()
+			t.Cleanup(func() { _ = sqlite.Close() })
+
+			badger := badgerpoc.NewBadgerCustomerRepository()
+			t.Cleanup(func() { _ = badger.Close() })
+
+			report, err := Run([]Target{
+				{"reference", reference.NewReferenceCustomerRepository()},
+				{"sqlite", sqlite},
+				{"badger", badger},
+			}, GenerateOperations(rand.New(rand.NewPCG(seed, 0)), 30))
+
+			require.NoError(t, err)
+			require.NoError(t, report.Err())
+		})
+	}
+}
+
+func TestCategory(t *testing.T) {
+	require.Equal(t, CategoryOK, Category(nil))
+	require.Equal(t, CategoryDuplication, Category(fmt.Errorf("%w: %w", customer.ErrDuplication, errors.New("x"))))
+	require.Equal(t, CategorySystem, Category(customer.ErrSystem))
+	require.Equal(t, CategoryUnknown, Category(errors.New("x")))
+}
+
+func TestLoadOperations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "operations.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(
+		"- save: {id: JHND-1, name: John Doe, email: john@doe.com, phone: \"+1 555 0001\"}\n"+
+			"- save: {\"id\": \"JNDS-2\", \"name\": \"Jane Doe\", \"email\": \"jane@doe.com\", \"phone\": \"+1 555 0002\"}\n",
+	), 0o600))
+
+	ops, err := LoadOperations(path)
+	require.NoError(t, err)
+	require.Equal(t, []Operation{saveOf(john), saveOf(jane)}, ops)
+
+	_, err = LoadOperations(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
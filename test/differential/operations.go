@@ -0,0 +1,71 @@
+package differential
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"gopkg.in/yaml.v3"
+)
+
+// Operation is an operation of a stream, applied to every target.
+type Operation struct {
+	// Save saves the customer to the repository.
+	Save *customer.Customer `yaml:"save"`
+}
+
+func (op Operation) String() string {
+	if op.Save == nil {
+		return "noop"
+	}
+
+	return fmt.Sprintf("save %+v", *op.Save)
+}
+
+// apply applies the operation to `repo`. Each repository gets its own copy of
+// the customer, since some of them keep the pointer they are given.
+func (op Operation) apply(repo customer.CustomerRepository) error {
+	if op.Save == nil {
+		return nil
+	}
+
+	c := *op.Save
+
+	return repo.Save(&c)
+}
+
+// LoadOperations reads a stream of operations from a YAML or JSON file: a
+// list of operations such as `{save: {id: JHND-1, name: John Doe, ...}}`.
+func LoadOperations(path string) ([]Operation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	if err := yaml.Unmarshal(data, &ops); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return ops, nil
+}
+
+// GenerateOperations draws a stream of `n` saves. Their fields are drawn from
+// small pools, so that the saves often collide on one or several fields, and
+// exercise the duplication checks of the repositories.
+func GenerateOperations(r *rand.Rand, n int) []Operation {
+	pool := max(2, n/2)
+
+	ops := make([]Operation, n)
+	for i := range ops {
+		ops[i].Save = &customer.Customer{
+			ID:    fmt.Sprintf("CSTM-%d", r.IntN(pool)),
+			Name:  fmt.Sprintf("Customer %d", r.IntN(pool)),
+			Email: fmt.Sprintf("customer%d@example.com", r.IntN(pool)),
+			Phone: fmt.Sprintf("+1 555 %04d", r.IntN(pool)),
+		}
+	}
+
+	return ops
+}
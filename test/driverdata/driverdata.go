@@ -0,0 +1,392 @@
+//go:build test
+
+// Package driverdata binds the `map[string]any` data exchanged with the test
+// drivers (requests, results and extra arguments, usually loaded from YAML) to
+// typed Go values.
+//
+// Struct fields are bound from the keys named by their `driver` tag:
+//
+//	type httpResponse struct {
+//		StatusCode int    `driver:"status_code,required"`
+//		Status     string `driver:"status"`
+//	}
+//
+// Fields without a tag, or tagged with `driver:"-"`, are ignored, as are the
+// keys of the data without a matching field. Missing keys leave the field
+// untouched, and null values zero it, unless the field is `required`.
+//
+// Besides structs, the supported types are strings, booleans, all integer and
+// floating point types, time.Duration (from strings such as `5m`), time.Time
+// (from RFC 3339 strings), slices, maps with string keys, pointers and
+// interfaces, nested at will.
+package driverdata
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TagName is the struct tag read by the binding functions.
+const TagName = "driver"
+
+// Error is a binding error. Its Path points to the offending key, using dots
+// for nested maps and brackets for list items, e.g. `http_response.status_code`
+// or `caller.roles[1]`.
+type Error struct {
+	Path    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Path == "" {
+		return "driverdata: " + e.Message
+	}
+
+	return fmt.Sprintf("driverdata: key '%s' %s", e.Path, e.Message)
+}
+
+// Bind binds `data` to a new value of type T, failing the test on error.
+func Bind[T any](t *testing.T, data map[string]any) T {
+	t.Helper()
+
+	var v T
+	require.NoError(t, Decode(data, &v))
+
+	return v
+}
+
+// BindKey binds the required `key` of `data` to a new value of type T, failing
+// the test when the key is missing, null or can't be bound.
+func BindKey[T any](t *testing.T, data map[string]any, key string) T {
+	t.Helper()
+
+	v, found := BindOptionalKey[T](t, data, key)
+	require.True(t, found, (&Error{Path: key, Message: "is required"}).Error())
+	require.NotNil(t, data[key], (&Error{Path: key, Message: "is required, got null"}).Error())
+
+	return v
+}
+
+// BindOptionalKey binds the optional `key` of `data` to a new value of type T,
+// failing the test when the key can't be bound. It reports whether the key is
+// present.
+func BindOptionalKey[T any](t *testing.T, data map[string]any, key string) (T, bool) {
+	t.Helper()
+
+	var v T
+
+	val, found := data[key]
+	if !found {
+		return v, false
+	}
+
+	require.NoError(t, decodeValue(key, val, reflect.ValueOf(&v).Elem()))
+
+	return v, true
+}
+
+// Decode binds `data` to the value pointed to by `out`.
+func Decode(data map[string]any, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &Error{Message: fmt.Sprintf("decode target should be a non-nil pointer, got %T", out)}
+	}
+
+	return decodeValue("", data, rv.Elem())
+}
+
+// DecodeValue binds the single value `val`, e.g. a string or a list, to the
+// value pointed to by `out`.
+func DecodeValue(val any, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return &Error{Message: fmt.Sprintf("decode target should be a non-nil pointer, got %T", out)}
+	}
+
+	return decodeValue("", val, rv.Elem())
+}
+
+//
+// Decoding
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// decodeValue binds `val` to `dst`. The `path` locates `val` in the data.
+func decodeValue(path string, val any, dst reflect.Value) error {
+	if val == nil {
+		dst.SetZero()
+		return nil
+	}
+
+	src := reflect.ValueOf(val)
+
+	switch dst.Type() {
+	case durationType:
+		return decodeDuration(path, val, dst)
+
+	case timeType:
+		return decodeTime(path, val, dst)
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if !src.Type().AssignableTo(dst.Type()) {
+			return mismatch(path, "a value implementing "+dst.Type().String(), val)
+		}
+		dst.Set(src)
+
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := decodeValue(path, val, elem.Elem()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+
+	case reflect.String:
+		s, ok := val.(string)
+		if !ok {
+			return mismatch(path, "a string", val)
+		}
+		dst.SetString(s)
+
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return mismatch(path, "a boolean", val)
+		}
+		dst.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := toInt64(src)
+		if !ok {
+			return mismatch(path, "an integer", val)
+		}
+		if dst.OverflowInt(n) {
+			return &Error{Path: path, Message: fmt.Sprintf("value %d overflows %s", n, dst.Type())}
+		}
+		dst.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := toInt64(src)
+		if !ok || n < 0 {
+			return mismatch(path, "a non-negative integer", val)
+		}
+		if dst.OverflowUint(uint64(n)) {
+			return &Error{Path: path, Message: fmt.Sprintf("value %d overflows %s", n, dst.Type())}
+		}
+		dst.SetUint(uint64(n))
+
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat64(src)
+		if !ok {
+			return mismatch(path, "a number", val)
+		}
+		dst.SetFloat(f)
+
+	case reflect.Slice:
+		return decodeSlice(path, src, dst)
+
+	case reflect.Map:
+		return decodeMap(path, src, dst)
+
+	case reflect.Struct:
+		m, ok := val.(map[string]any)
+		if !ok {
+			return mismatch(path, "a map", val)
+		}
+		return decodeStruct(path, m, dst)
+
+	default:
+		return &Error{Path: path, Message: fmt.Sprintf("can't be bound to unsupported type %s", dst.Type())}
+	}
+
+	return nil
+}
+
+// decodeDuration binds a duration string such as `1m30s`.
+func decodeDuration(path string, val any, dst reflect.Value) error {
+	if d, ok := val.(time.Duration); ok {
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	s, ok := val.(string)
+	if !ok {
+		return mismatch(path, "a duration such as '5m'", val)
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return &Error{Path: path, Message: fmt.Sprintf("should be a duration such as '5m', got '%s'", s)}
+	}
+
+	dst.SetInt(int64(d))
+
+	return nil
+}
+
+// decodeTime binds a time.Time or an RFC 3339 time string.
+func decodeTime(path string, val any, dst reflect.Value) error {
+	switch v := val.(type) {
+	case time.Time:
+		dst.Set(reflect.ValueOf(v))
+		return nil
+
+	case string:
+		ts, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return &Error{Path: path, Message: fmt.Sprintf("should be an RFC 3339 time, got '%s'", v)}
+		}
+		dst.Set(reflect.ValueOf(ts))
+		return nil
+	}
+
+	return mismatch(path, "an RFC 3339 time", val)
+}
+
+// decodeSlice binds a list item by item.
+func decodeSlice(path string, src, dst reflect.Value) error {
+	if src.Kind() != reflect.Slice && src.Kind() != reflect.Array {
+		return mismatch(path, "a list", src.Interface())
+	}
+
+	out := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+	for i := range src.Len() {
+		if err := decodeValue(fmt.Sprintf("%s[%d]", path, i), src.Index(i).Interface(), out.Index(i)); err != nil {
+			return err
+		}
+	}
+
+	dst.Set(out)
+
+	return nil
+}
+
+// decodeMap binds a map with string keys entry by entry.
+func decodeMap(path string, src, dst reflect.Value) error {
+	if dst.Type().Key().Kind() != reflect.String {
+		return &Error{Path: path, Message: fmt.Sprintf("can't be bound to %s: map keys should be strings", dst.Type())}
+	}
+
+	if src.Kind() != reflect.Map || src.Type().Key().Kind() != reflect.String {
+		return mismatch(path, "a map", src.Interface())
+	}
+
+	out := reflect.MakeMapWithSize(dst.Type(), src.Len())
+	iter := src.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+
+		elem := reflect.New(dst.Type().Elem()).Elem()
+		if err := decodeValue(join(path, key), iter.Value().Interface(), elem); err != nil {
+			return err
+		}
+
+		out.SetMapIndex(reflect.ValueOf(key).Convert(dst.Type().Key()), elem)
+	}
+
+	dst.Set(out)
+
+	return nil
+}
+
+// decodeStruct binds the tagged fields of a struct.
+func decodeStruct(path string, data map[string]any, dst reflect.Value) error {
+	typ := dst.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(field.Tag.Get(TagName), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		val, found := data[key]
+		if !found {
+			if opts == "required" {
+				return &Error{Path: join(path, key), Message: "is required"}
+			}
+			continue
+		}
+
+		if val == nil && opts == "required" {
+			return &Error{Path: join(path, key), Message: "is required, got null"}
+		}
+
+		if err := decodeValue(join(path, key), val, dst.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Helpers
+
+// toInt64 converts integers, and floats without a fractional part, to int64.
+func toInt64(v reflect.Value) (int64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v.Uint()), true
+
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
+	}
+
+	return 0, false
+}
+
+// toFloat64 converts any number to float64.
+func toFloat64(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	}
+
+	return 0, false
+}
+
+// mismatch builds the error of a value of the wrong type.
+func mismatch(path, expected string, val any) error {
+	return &Error{Path: path, Message: fmt.Sprintf("should be %s, got %T (%v)", expected, val, val)}
+}
+
+// join appends `key` to `path`.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,166 @@
+//go:build test
+
+package driverdata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type sampleResponse struct {
+	StatusCode int    `driver:"status_code,required"`
+	Status     string `driver:"status"`
+}
+
+type sample struct {
+	Name      string            `driver:"name,required"`
+	Anonymous bool              `driver:"anonymous"`
+	Retries   uint8             `driver:"retries"`
+	Ratio     float64           `driver:"ratio"`
+	Timeout   time.Duration     `driver:"timeout"`
+	At        time.Time         `driver:"at"`
+	Roles     []string          `driver:"roles"`
+	Headers   map[string]string `driver:"headers"`
+	Response  *sampleResponse   `driver:"http_response"`
+	Err       error             `driver:"err"`
+	Raw       any               `driver:"raw"`
+	Ignored   string
+	Skipped   string `driver:"-"`
+}
+
+func TestBind(t *testing.T) {
+	r := require.New(t)
+
+	var data map[string]any
+	r.NoError(yaml.Unmarshal([]byte(`
+name: "John Due"
+anonymous: true
+retries: 3
+ratio: 1
+timeout: "1m30s"
+at: 2025-01-02T03:04:05Z
+roles: ["crm:read", "crm:write"]
+headers:
+  X-Api-Key: "secret"
+http_response:
+  status_code: 201
+  status: "Created"
+raw: [1, "two"]
+Ignored: "not bound"
+unknown: "not bound either"
+`), &data))
+
+	data["err"] = errors.New("boom")
+
+	s := Bind[sample](t, data)
+
+	r.Equal("John Due", s.Name)
+	r.True(s.Anonymous)
+	r.Equal(uint8(3), s.Retries)
+	r.Equal(1.0, s.Ratio)
+	r.Equal(90*time.Second, s.Timeout)
+	r.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), s.At)
+	r.Equal([]string{"crm:read", "crm:write"}, s.Roles)
+	r.Equal(map[string]string{"X-Api-Key": "secret"}, s.Headers)
+	r.Equal(&sampleResponse{StatusCode: 201, Status: "Created"}, s.Response)
+	r.EqualError(s.Err, "boom")
+	r.Equal([]any{1, "two"}, s.Raw)
+	r.Empty(s.Ignored)
+	r.Empty(s.Skipped)
+}
+
+func TestBindNumericTypes(t *testing.T) {
+	r := require.New(t)
+
+	for _, statusCode := range []any{201, int64(201), uint16(201), 201.0, float32(201)} {
+		response := Bind[sampleResponse](t, map[string]any{"status_code": statusCode})
+		r.Equal(201, response.StatusCode, "from %T", statusCode)
+	}
+}
+
+func TestBindKey(t *testing.T) {
+	r := require.New(t)
+
+	data := map[string]any{
+		"http_response": map[string]any{"status_code": 409, "status": "Conflict"},
+	}
+
+	response := BindKey[sampleResponse](t, data, "http_response")
+	r.Equal(sampleResponse{StatusCode: 409, Status: "Conflict"}, response)
+
+	_, found := BindOptionalKey[sampleResponse](t, data, "http_request")
+	r.False(found)
+}
+
+func TestDecodeValue(t *testing.T) {
+	r := require.New(t)
+
+	var roles []string
+	r.NoError(DecodeValue([]any{"crm:read", "crm:write"}, &roles))
+	r.Equal([]string{"crm:read", "crm:write"}, roles)
+
+	var retries uint8
+	r.EqualError(DecodeValue(300, &retries), "driverdata: value 300 overflows uint8")
+	r.Error(DecodeValue("x", retries))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for title, tc := range map[string]struct {
+		data map[string]any
+		err  string
+	}{
+		"when a required key is missing": {
+			data: map[string]any{},
+			err:  "driverdata: key 'name' is required",
+		},
+		"when a required key is null": {
+			data: map[string]any{"name": nil},
+			err:  "driverdata: key 'name' is required, got null",
+		},
+		"when a nested required key is null": {
+			data: map[string]any{"name": "n", "http_response": map[string]any{"status_code": nil}},
+			err:  "driverdata: key 'http_response.status_code' is required, got null",
+		},
+		"when a nested required key is missing": {
+			data: map[string]any{"name": "n", "http_response": map[string]any{"status": "OK"}},
+			err:  "driverdata: key 'http_response.status_code' is required",
+		},
+		"when an integer is given as a string": {
+			data: map[string]any{"name": "n", "http_response": map[string]any{"status_code": "201"}},
+			err:  "driverdata: key 'http_response.status_code' should be an integer, got string (201)",
+		},
+		"when an integer has a fractional part": {
+			data: map[string]any{"name": "n", "http_response": map[string]any{"status_code": 20.1}},
+			err:  "driverdata: key 'http_response.status_code' should be an integer, got float64 (20.1)",
+		},
+		"when an integer overflows": {
+			data: map[string]any{"name": "n", "retries": 300},
+			err:  "driverdata: key 'retries' value 300 overflows uint8",
+		},
+		"when a list item has the wrong type": {
+			data: map[string]any{"name": "n", "roles": []any{"crm:read", 42}},
+			err:  "driverdata: key 'roles[1]' should be a string, got int (42)",
+		},
+		"when a duration is malformed": {
+			data: map[string]any{"name": "n", "timeout": "soon"},
+			err:  "driverdata: key 'timeout' should be a duration such as '5m', got 'soon'",
+		},
+		"when a map is expected": {
+			data: map[string]any{"name": "n", "headers": "X-Api-Key: secret"},
+			err:  "driverdata: key 'headers' should be a map, got string (X-Api-Key: secret)",
+		},
+		"when an interface is not implemented": {
+			data: map[string]any{"name": "n", "err": "boom"},
+			err:  "driverdata: key 'err' should be a value implementing error, got string (boom)",
+		},
+	} {
+		t.Run(title, func(t *testing.T) {
+			var s sample
+			require.EqualError(t, Decode(tc.data, &s), tc.err)
+		})
+	}
+}
@@ -0,0 +1,555 @@
+//go:build test
+
+// Package dsl provides a business-language layer on top of the customer test
+// drivers, so that acceptance scenarios read as a sequence of Given, When and
+// Then steps free of `result` and `extraArgs` maps.
+package dsl
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/report"
+	"github.com/stretchr/testify/require"
+)
+
+//
+// Vocabulary
+
+// Reason is the business reason why a registration is rejected. It is given
+// as is to the test drivers, in the `rejected_because` extra argument, for
+// the presentation layers to tell how they answer such a rejection.
+type Reason string
+
+// Rejection reasons understood by ExpectRejectedBecause.
+const (
+	InvalidData     Reason = "invalid data"
+	DuplicatedData  Reason = "duplicated data"
+	SystemFailure   Reason = "system failure"
+	Unauthenticated Reason = "unauthenticated"
+	Forbidden       Reason = "forbidden"
+)
+
+// reasons are the known rejection reasons.
+var reasons = []Reason{InvalidData, DuplicatedData, SystemFailure, Unauthenticated, Forbidden}
+
+// Known checks if the reason is one of the rejection reasons above.
+func (r Reason) Known() bool {
+	return slices.Contains(reasons, r)
+}
+
+// Caller describes who makes a request. The zero value is the default caller,
+// who is allowed to register customers. Callers only matter to presentation
+// layers that authenticate requests.
+type Caller struct {
+	Anonymous  bool          `driver:"anonymous"`
+	APIKey     string        `driver:"api_key"`
+	Token      string        `driver:"token"`
+	Subject    string        `driver:"subject"`
+	Roles      []string      `driver:"roles"`
+	ExpiresIn  time.Duration `driver:"expires_in"`
+	SigningKey string        `driver:"signing_key"`
+}
+
+// toMap converts the caller to the `caller` extra argument understood by the
+// presentation test drivers. It returns nil for the default caller.
+func (c Caller) toMap() map[string]any {
+	caller := map[string]any{}
+
+	if c.Anonymous {
+		caller["anonymous"] = true
+	}
+
+	if c.APIKey != "" {
+		caller["api_key"] = c.APIKey
+	}
+
+	if c.Token != "" {
+		caller["token"] = c.Token
+	}
+
+	if c.Subject != "" {
+		caller["subject"] = c.Subject
+	}
+
+	if c.Roles != nil {
+		roles := make([]any, len(c.Roles))
+		for i, role := range c.Roles {
+			roles[i] = role
+		}
+		caller["roles"] = roles
+	}
+
+	if c.ExpiresIn != 0 {
+		caller["expires_in"] = c.ExpiresIn.String()
+	}
+
+	if c.SigningKey != "" {
+		caller["signing_key"] = c.SigningKey
+	}
+
+	if len(caller) == 0 {
+		return nil
+	}
+
+	return caller
+}
+
+// Option customizes a single action of the DSL.
+type Option func(extraArgs map[string]any)
+
+// As makes the action on behalf of `caller`.
+func As(caller Caller) Option {
+	return func(extraArgs map[string]any) {
+		if c := caller.toMap(); c != nil {
+			extraArgs["caller"] = c
+		}
+	}
+}
+
+// WithExtraArgs gives `extraArgs` as is to the test drivers, along with the
+// ones of the other options, e.g. an `http_response` overriding the response
+// a REST presentation layer is expected to answer.
+func WithExtraArgs(extraArgs map[string]any) Option {
+	return func(args map[string]any) {
+		maps.Copy(args, extraArgs)
+	}
+}
+
+// WithFaults injects `faults` into the repository before the action. They
+// keep affecting the subsequent actions until other faults are injected; no
+// faults make the repository reliable again.
+func WithFaults(faults ...customer.Fault) Option {
+	return func(extraArgs map[string]any) {
+		list := make([]any, len(faults))
+		for i, f := range faults {
+			list[i] = faultToMap(f)
+		}
+		extraArgs["faults"] = list
+	}
+}
+
+// WithPolling makes the Eventually expectations following the action poll as
+// told by `polling`, instead of the polling of the test driver.
+func WithPolling(polling customer.Polling) Option {
+	return func(extraArgs map[string]any) {
+		eventually := map[string]any{}
+
+		if polling.Timeout != 0 {
+			eventually["timeout"] = polling.Timeout.String()
+		}
+
+		if polling.Interval != 0 {
+			eventually["interval"] = polling.Interval.String()
+		}
+
+		extraArgs["eventually"] = eventually
+	}
+}
+
+// faultToMap converts a fault to the map understood by the test drivers.
+func faultToMap(f customer.Fault) map[string]any {
+	fault := map[string]any{}
+
+	if f.OnCall != 0 {
+		fault["on_call"] = f.OnCall
+	}
+
+	if f.Times != 0 {
+		fault["times"] = f.Times
+	}
+
+	if f.Latency != 0 {
+		fault["latency"] = f.Latency.String()
+	}
+
+	if f.Error != customer.FaultNoError {
+		fault["error"] = string(f.Error)
+	}
+
+	if f.Field != "" {
+		fault["field"] = f.Field
+	}
+
+	if f.Deferred {
+		fault["deferred"] = true
+	}
+
+	return fault
+}
+
+//
+// DSL
+
+// CRMDSL drives the CRM through business-language verbs. It keeps the state of
+// the running scenario: the last registration attempt, its outcome, and the
+// customers known by alias.
+//
+// A CRMDSL is meant to be used by a single scenario.
+type CRMDSL struct {
+	customerTestDriver *customer.CustomerServiceTestDriver
+
+	// customers holds the data of the customers known by alias, including the
+	// IDs given to them by the system.
+	customers map[string]map[string]any
+
+	// arranged keeps the aliases of the customers that were arranged as
+	// already registered, in order.
+	arranged []string
+
+	lastRequest   map[string]any
+	lastExtraArgs map[string]any
+	lastResult    map[string]any
+
+	// report records the request and the expected outcome of the scenario,
+	// if any.
+	report *report.Entry
+}
+
+// NewCRMDSL creates a new CRMDSL on top of the given test driver.
+func NewCRMDSL(customerTestDriver *customer.CustomerServiceTestDriver) *CRMDSL {
+	return &CRMDSL{
+		customerTestDriver: customerTestDriver,
+		customers:          make(map[string]map[string]any),
+	}
+}
+
+// WithReport makes the CRMDSL record the last request and the expected
+// outcome of the scenario into the report `entry`.
+func (d *CRMDSL) WithReport(entry *report.Entry) *CRMDSL {
+	d.report = entry
+
+	return d
+}
+
+//
+// Given
+
+// GivenNoCustomers starts from a CRM without any customer, forgetting the
+// customers known by the scenario so far.
+func (d *CRMDSL) GivenNoCustomers(t *testing.T) {
+	t.Helper()
+
+	d.customers = make(map[string]map[string]any)
+	d.arranged = nil
+
+	d.customerTestDriver.ArrangeInternalsNoCustomerIsRegistered(t)
+}
+
+// GivenRegisteredCustomer makes the customer described by `data` already
+// registered in the CRM, along with the customers arranged before. The
+// customer is known as `alias` from then on.
+//
+// It looks for the following attributes in the `data` map:
+// - id: string (optional, generated when missing)
+// - name: string
+// - email: string
+// - phone: string
+func (d *CRMDSL) GivenRegisteredCustomer(t *testing.T, alias string, data map[string]any) {
+	t.Helper()
+
+	r := require.New(t)
+	r.NotContains(d.customers, alias, "customer alias '%s' is already in use", alias)
+
+	c := maps.Clone(data)
+	if customer.GetOptionalStringFromMap(t, c, "id") == "" {
+		id, err := customer.GenerateID(customer.GetStringFromMap(t, c, "name"), time.Now())
+		r.NoError(err)
+		c["id"] = id
+	}
+
+	d.customers[alias] = c
+	d.arranged = append(d.arranged, alias)
+
+	arranged := make([]map[string]any, len(d.arranged))
+	for i, a := range d.arranged {
+		arranged[i] = d.customers[a]
+	}
+
+	d.customerTestDriver.ArrangeInternalsSomeCustomersAreRegistered(t, arranged...)
+}
+
+// GivenFixture replaces the customers of the CRM with the ones of the fixture
+// file at `path`, forgetting the customers known by the scenario so far. The
+// fixture customers are known by their fixture aliases, and the data given to
+// the next steps may reference their fields, e.g. `@alice.email`.
+func (d *CRMDSL) GivenFixture(t *testing.T, path string) {
+	t.Helper()
+
+	fixture := d.customerTestDriver.ArrangeInternalsFixtureIsLoaded(t, customer.LoadFixture(t, path))
+
+	d.customers = make(map[string]map[string]any)
+	d.arranged = fixture.Aliases()
+
+	for _, alias := range d.arranged {
+		d.customers[alias] = fixture.Customer(t, alias)
+	}
+}
+
+// GivenTheSystemIsFailing makes every subsequent operation fail due to an
+// internal problem.
+func (d *CRMDSL) GivenTheSystemIsFailing(t *testing.T) {
+	t.Helper()
+
+	d.customerTestDriver.ArrangeInternalsSomethingCausingAProblem(t)
+}
+
+// GivenAVirtualClock makes the time of the CRM virtual: the latencies of the
+// injected faults and the polling of the Eventually expectations elapse on a
+// clock which does not wait, and only moves while they wait. The saves
+// deferred by faults hence happen while an Eventually expectation waits for
+// them, and never before.
+func (d *CRMDSL) GivenAVirtualClock(t *testing.T) {
+	t.Helper()
+
+	d.customerTestDriver.WithClock(customer.NewVirtualClock(time.Now()))
+}
+
+// GivenSnapshot brings the CRM back to the state captured by `snapshot`,
+// along with the customers known by the scenario at that time. The snapshot
+// may come from another CRMDSL on the same kind of SUT, so that a large
+// dataset can be seeded once and restored cheaply by each scenario.
+func (d *CRMDSL) GivenSnapshot(t *testing.T, snapshot *Snapshot) {
+	t.Helper()
+
+	d.customers = make(map[string]map[string]any, len(snapshot.customers))
+	for alias, c := range snapshot.customers {
+		d.customers[alias] = maps.Clone(c)
+	}
+	d.arranged = slices.Clone(snapshot.arranged)
+
+	d.customerTestDriver.Restore(t, snapshot.repository)
+}
+
+//
+// When
+
+// RegisterCustomer tries to register the customer described by `data`. When
+// the registration succeeds, the new customer is known as `alias`; an empty
+// alias leaves it anonymous.
+//
+// It looks for the following optional attributes in the `data` map:
+// - name: string
+// - email: string
+// - phone: string
+func (d *CRMDSL) RegisterCustomer(t *testing.T, alias string, data map[string]any, opts ...Option) {
+	t.Helper()
+
+	if alias != "" {
+		require.NotContains(t, d.customers, alias, "customer alias '%s' is already in use", alias)
+	}
+
+	d.lastRequest = maps.Clone(data)
+	delete(d.lastRequest, "id")
+
+	d.lastExtraArgs = map[string]any{}
+	for _, opt := range opts {
+		opt(d.lastExtraArgs)
+	}
+
+	d.lastResult = d.customerTestDriver.ActTryToRegisterACustomer(t, d.lastRequest, d.lastExtraArgs)
+
+	// The request as sent, without the ID given by the system.
+	sent := maps.Clone(d.lastRequest)
+	delete(sent, "id")
+	d.report.SetRequest(sent)
+
+	if id, _ := d.lastResult["id"].(string); id != "" && alias != "" {
+		d.customers[alias] = d.lastRequest
+	}
+}
+
+// RegisterCustomerRetrying tries to register the customer described by
+// `data` like RegisterCustomer, trying again up to `retries` times while the
+// registration fails. The options only apply to the first attempt.
+func (d *CRMDSL) RegisterCustomerRetrying(
+	t *testing.T,
+	alias string,
+	data map[string]any,
+	retries int,
+	opts ...Option,
+) {
+	t.Helper()
+
+	d.RegisterCustomer(t, alias, data, opts...)
+
+	for range retries {
+		if id, _ := d.lastResult["id"].(string); id != "" {
+			return
+		}
+
+		d.RegisterCustomer(t, alias, data)
+	}
+}
+
+//
+// Then
+
+// ExpectRegistered checks that the last registration succeeded and that the
+// customer was properly stored.
+func (d *CRMDSL) ExpectRegistered(t *testing.T) {
+	t.Helper()
+
+	d.requireLastRegistration(t)
+	d.report.SetOutcome("registered")
+
+	d.customerTestDriver.AssertRegistrationShouldSucceed(t, d.lastResult, d.expectationArgs(""))
+
+	d.customerTestDriver.AssertInternalsCustomerShouldBeProperlyRegistered(t, d.lastRequest)
+}
+
+// ExpectEventuallyRegistered checks that the last registration succeeded and
+// that the customer ends up properly stored, waiting for it as the side
+// effects of asynchronous registrations take time.
+func (d *CRMDSL) ExpectEventuallyRegistered(t *testing.T) {
+	t.Helper()
+
+	d.requireLastRegistration(t)
+	d.report.SetOutcome("registered")
+
+	extraArgs := d.expectationArgs("")
+
+	d.customerTestDriver.AssertRegistrationShouldSucceed(t, d.lastResult, extraArgs)
+
+	d.customerTestDriver.AssertInternalsCustomerShouldEventuallyBeProperlyRegistered(t, d.lastRequest, extraArgs)
+}
+
+// ExpectRejectedBecause checks that the last registration was rejected for
+// `reason`, with an error message containing all the given `details`.
+func (d *CRMDSL) ExpectRejectedBecause(t *testing.T, reason Reason, details ...string) {
+	t.Helper()
+
+	d.requireLastRegistration(t)
+	d.report.SetOutcome("rejected as "+string(reason), details...)
+
+	require.True(t, reason.Known(), "unknown rejection reason '%s'", reason)
+
+	d.customerTestDriver.AssertRegistrationShouldFailWithMessage(
+		t, d.lastResult, d.expectationArgs(reason), details...,
+	)
+}
+
+// ExpectNotRegistered checks that the customer of the last registration was
+// not stored.
+func (d *CRMDSL) ExpectNotRegistered(t *testing.T) {
+	t.Helper()
+
+	d.requireLastRegistration(t)
+
+	d.customerTestDriver.AssertInternalsCustomerShouldNotBeRegistered(t, d.lastRequest)
+}
+
+// ExpectRegisteredOnlyOnce checks that the customer known as `alias` is stored
+// a single time.
+func (d *CRMDSL) ExpectRegisteredOnlyOnce(t *testing.T, alias string) {
+	t.Helper()
+
+	d.customerTestDriver.AssertInternalsCustomerShouldNotBeDuplicated(t, d.Customer(t, alias))
+}
+
+// ExpectStored checks that the customer known as `alias` is properly stored.
+func (d *CRMDSL) ExpectStored(t *testing.T, alias string) {
+	t.Helper()
+
+	d.customerTestDriver.AssertInternalsCustomerShouldBeProperlyRegistered(t, d.Customer(t, alias))
+}
+
+//
+// Observe
+
+// Registered is the outcome of the successful registrations, see LastOutcome.
+const Registered = "registered"
+
+// LastOutcome describes the outcome of the last registration without
+// expecting it: Registered, the Reason of its rejection, or the error of the
+// rejections foreign to the CRM. The tests comparing SUTs use it where the
+// others use the Expect verbs.
+func (d *CRMDSL) LastOutcome(t *testing.T) string {
+	t.Helper()
+
+	d.requireLastRegistration(t)
+
+	err := d.customerTestDriver.ObserveRegistrationError(t, d.lastResult)
+	switch {
+	case err == nil:
+		return Registered
+	case errors.Is(err, customer.ErrValidation):
+		return string(InvalidData)
+	case errors.Is(err, customer.ErrDuplication):
+		return string(DuplicatedData)
+	case errors.Is(err, customer.ErrSystem):
+		return string(SystemFailure)
+	default:
+		return err.Error()
+	}
+}
+
+//
+// Scenario State
+
+// Snapshot is the state of the CRM and of the customers known by a scenario.
+type Snapshot struct {
+	repository customer.Snapshot
+	customers  map[string]map[string]any
+	arranged   []string
+}
+
+// Snapshot captures the state of the CRM and the customers known by the
+// scenario, to be restored with GivenSnapshot.
+func (d *CRMDSL) Snapshot(t *testing.T) *Snapshot {
+	t.Helper()
+
+	customers := make(map[string]map[string]any, len(d.customers))
+	for alias, c := range d.customers {
+		customers[alias] = maps.Clone(c)
+	}
+
+	return &Snapshot{
+		repository: d.customerTestDriver.Snapshot(t),
+		customers:  customers,
+		arranged:   slices.Clone(d.arranged),
+	}
+}
+
+// Customer returns a copy of the data of the customer known as `alias`.
+func (d *CRMDSL) Customer(t *testing.T, alias string) map[string]any {
+	t.Helper()
+
+	c, found := d.customers[alias]
+	require.True(t, found, "unknown customer alias '%s'", alias)
+
+	return maps.Clone(c)
+}
+
+// CustomerID returns the ID given to the customer known as `alias`.
+func (d *CRMDSL) CustomerID(t *testing.T, alias string) string {
+	t.Helper()
+
+	return customer.GetStringFromMap(t, d.Customer(t, alias), "id")
+}
+
+//
+// Internal Helpers
+
+// requireLastRegistration fails the test when no registration was attempted.
+func (d *CRMDSL) requireLastRegistration(t *testing.T) {
+	t.Helper()
+
+	require.NotNil(t, d.lastResult, "no registration was attempted")
+}
+
+// expectationArgs builds the extra arguments of the expectations on the last
+// registration, keeping the ones given to it, with the `reason` of its
+// rejection unless it should succeed.
+func (d *CRMDSL) expectationArgs(reason Reason) map[string]any {
+	extraArgs := maps.Clone(d.lastExtraArgs)
+	if reason != "" {
+		extraArgs["rejected_because"] = string(reason)
+	}
+
+	return extraArgs
+}
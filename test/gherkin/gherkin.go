@@ -0,0 +1,464 @@
+//go:build test
+
+// Package gherkin parses Gherkin `.feature` files and runs their scenarios as
+// `go test` subtests, binding each step to a Go function. It understands the
+// subset of Gherkin used by this repository: features, backgrounds, scenarios,
+// scenario outlines with examples tables, tags, data tables and doc strings.
+package gherkin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Feature is a parsed `.feature` file.
+type Feature struct {
+	Path        string
+	Name        string
+	Description string
+	Tags        []string
+	Background  []*Step
+	Scenarios   []*Scenario
+}
+
+// Scenario is a scenario or a scenario outline of a feature.
+type Scenario struct {
+	Name     string
+	Line     int
+	Tags     []string
+	Outline  bool
+	Steps    []*Step
+	Examples []*Examples
+}
+
+// Examples is an examples section of a scenario outline.
+type Examples struct {
+	Name  string
+	Line  int
+	Tags  []string
+	Table *Table
+}
+
+// Step is a single Given, When or Then step.
+type Step struct {
+	// Keyword is the keyword as written in the file, e.g. `And`.
+	Keyword string
+
+	// Type is the effective type of the step: `Given`, `When` or `Then`.
+	// `And`, `But` and `*` steps take the type of the step before them.
+	Type string
+
+	Text      string
+	Line      int
+	Table     *Table
+	DocString *string
+}
+
+// Table is a data table. The first row is the header.
+type Table struct {
+	Line int
+	Rows [][]string
+}
+
+// Header returns the first row of the table.
+func (tb *Table) Header() []string {
+	if tb == nil || len(tb.Rows) == 0 {
+		return nil
+	}
+
+	return tb.Rows[0]
+}
+
+// Maps returns the rows following the header as maps keyed by the header
+// cells.
+func (tb *Table) Maps() []map[string]string {
+	if tb == nil || len(tb.Rows) < 2 {
+		return nil
+	}
+
+	header := tb.Rows[0]
+
+	maps := make([]map[string]string, 0, len(tb.Rows)-1)
+	for _, row := range tb.Rows[1:] {
+		m := make(map[string]string, len(header))
+		for i, cell := range row {
+			m[header[i]] = cell
+		}
+		maps = append(maps, m)
+	}
+
+	return maps
+}
+
+// Step keywords.
+var stepKeywords = []string{"Given", "When", "Then", "And", "But", "*"}
+
+// ParseFile parses the feature file at `path`.
+func ParseFile(path string) (*Feature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(path, f)
+}
+
+// Parse parses a feature read from `r`. The `path` is only used to report
+// errors.
+func Parse(path string, r io.Reader) (*Feature, error) {
+	p := &parser{path: path, feature: &Feature{Path: path}}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.line++
+		if err := p.parseLine(scanner.Text()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if p.docString != nil {
+		return nil, p.errorf(p.docString.line, "unterminated doc string")
+	}
+
+	if p.feature.Name == "" && len(p.feature.Scenarios) == 0 {
+		return nil, p.errorf(p.line, "no feature found")
+	}
+
+	for _, sc := range p.feature.Scenarios {
+		if sc.Outline && len(sc.Examples) == 0 {
+			return nil, p.errorf(sc.Line, "scenario outline '%s' has no examples", sc.Name)
+		}
+
+		for _, ex := range sc.Examples {
+			if ex.Table == nil && !hasTag(ex.Tags, DataTag) {
+				return nil, p.errorf(ex.Line, "examples need a table or a %s tag", DataTag)
+			}
+		}
+	}
+
+	p.feature.Description = strings.TrimSpace(p.feature.Description)
+
+	return p.feature, nil
+}
+
+//
+// Parser
+
+// parser section states.
+const (
+	inNothing = iota
+	inFeature
+	inBackground
+	inScenario
+	inExamples
+)
+
+// openDocString is a doc string being read.
+type openDocString struct {
+	line      int
+	delimiter string
+	indent    int
+	lines     []string
+}
+
+// parser keeps the state of the parsing of a feature file.
+type parser struct {
+	path    string
+	line    int
+	feature *Feature
+
+	section   int
+	tags      []string
+	scenario  *Scenario
+	examples  *Examples
+	lastStep  *Step
+	lastType  string
+	docString *openDocString
+}
+
+// parseLine parses a single line of the feature file.
+func (p *parser) parseLine(raw string) error {
+	if p.docString != nil {
+		return p.parseDocStringLine(raw)
+	}
+
+	line := strings.TrimSpace(raw)
+
+	switch {
+	case line == "" || strings.HasPrefix(line, "#"):
+		if p.section == inFeature && line == "" {
+			p.feature.Description += "\n"
+		}
+		return nil
+
+	case strings.HasPrefix(line, "@"):
+		p.tags = append(p.tags, strings.Fields(line)...)
+		return nil
+
+	case strings.HasPrefix(line, "|"):
+		return p.parseTableRow(line)
+
+	case line == `"""` || line == "```" || strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "```"):
+		if p.lastStep == nil {
+			return p.errorf(p.line, "doc string outside of a step")
+		}
+
+		p.docString = &openDocString{
+			line:      p.line,
+			delimiter: line[:3],
+			indent:    len(raw) - len(strings.TrimLeft(raw, " \t")),
+		}
+		return nil
+	}
+
+	if keyword, rest, ok := cutKeyword(line); ok {
+		return p.parseSection(keyword, rest)
+	}
+
+	if keyword, rest, ok := cutStepKeyword(line); ok {
+		return p.parseStep(keyword, rest)
+	}
+
+	if p.section == inFeature {
+		p.feature.Description += line + "\n"
+		return nil
+	}
+
+	// Free text before the first step of a section is a description.
+	if p.section != inNothing && p.lastStep == nil {
+		return nil
+	}
+
+	return p.errorf(p.line, "unexpected line: %s", line)
+}
+
+// parseSection starts a new Feature, Background, Scenario or Examples section.
+func (p *parser) parseSection(keyword, name string) error {
+	tags := p.tags
+	p.tags = nil
+	p.lastStep = nil
+	p.lastType = ""
+
+	switch keyword {
+	case "Feature":
+		if p.section != inNothing {
+			return p.errorf(p.line, "only one feature is allowed per file")
+		}
+		p.feature.Name = name
+		p.feature.Tags = tags
+		p.section = inFeature
+
+	case "Background":
+		if p.section != inFeature || len(p.feature.Scenarios) > 0 {
+			return p.errorf(p.line, "background must come before the scenarios")
+		}
+		p.section = inBackground
+
+	case "Scenario", "Example", "Scenario Outline", "Scenario Template":
+		if p.section == inNothing {
+			return p.errorf(p.line, "scenario outside of a feature")
+		}
+		p.scenario = &Scenario{
+			Name:    name,
+			Line:    p.line,
+			Tags:    tags,
+			Outline: keyword == "Scenario Outline" || keyword == "Scenario Template",
+		}
+		p.feature.Scenarios = append(p.feature.Scenarios, p.scenario)
+		p.section = inScenario
+
+	case "Examples", "Scenarios":
+		if p.scenario == nil || !p.scenario.Outline {
+			return p.errorf(p.line, "examples outside of a scenario outline")
+		}
+		p.examples = &Examples{Name: name, Line: p.line, Tags: tags}
+		p.scenario.Examples = append(p.scenario.Examples, p.examples)
+		p.section = inExamples
+
+	case "Rule":
+		return p.errorf(p.line, "rules are not supported")
+	}
+
+	return nil
+}
+
+// parseStep adds a step to the current background or scenario.
+func (p *parser) parseStep(keyword, text string) error {
+	step := &Step{Keyword: keyword, Text: text, Line: p.line}
+
+	switch keyword {
+	case "Given", "When", "Then":
+		step.Type = keyword
+	default:
+		if p.lastType == "" {
+			return p.errorf(p.line, "'%s' step must follow a Given, When or Then step", keyword)
+		}
+		step.Type = p.lastType
+	}
+
+	switch p.section {
+	case inBackground:
+		p.feature.Background = append(p.feature.Background, step)
+	case inScenario:
+		p.scenario.Steps = append(p.scenario.Steps, step)
+	default:
+		return p.errorf(p.line, "step outside of a scenario or background")
+	}
+
+	p.lastStep = step
+	p.lastType = step.Type
+
+	return nil
+}
+
+// parseTableRow adds a row to the table of the current step or examples.
+func (p *parser) parseTableRow(line string) error {
+	var table **Table
+
+	switch {
+	case p.section == inExamples:
+		table = &p.examples.Table
+	case p.lastStep != nil:
+		table = &p.lastStep.Table
+	default:
+		return p.errorf(p.line, "table outside of a step or examples")
+	}
+
+	cells, err := splitTableRow(line)
+	if err != nil {
+		return p.errorf(p.line, "%v", err)
+	}
+
+	if *table == nil {
+		*table = &Table{Line: p.line}
+	} else if len(cells) != len((*table).Rows[0]) {
+		return p.errorf(p.line, "table row has %d cells, expected %d", len(cells), len((*table).Rows[0]))
+	}
+
+	(*table).Rows = append((*table).Rows, cells)
+
+	return nil
+}
+
+// parseDocStringLine adds a line to the open doc string or closes it.
+func (p *parser) parseDocStringLine(raw string) error {
+	ds := p.docString
+
+	if strings.TrimSpace(raw) == ds.delimiter {
+		content := strings.Join(ds.lines, "\n")
+		p.lastStep.DocString = &content
+		p.docString = nil
+		return nil
+	}
+
+	// Remove the indentation of the opening delimiter.
+	trimmed := raw
+	for i := 0; i < ds.indent && len(trimmed) > 0 && (trimmed[0] == ' ' || trimmed[0] == '\t'); i++ {
+		trimmed = trimmed[1:]
+	}
+
+	ds.lines = append(ds.lines, trimmed)
+
+	return nil
+}
+
+// errorf builds a parsing error pointing to `line`.
+func (p *parser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.path, line, fmt.Sprintf(format, args...))
+}
+
+//
+// Lexical Helpers
+
+// cutKeyword splits a section line such as `Scenario: name`.
+func cutKeyword(line string) (keyword, name string, ok bool) {
+	keyword, name, found := strings.Cut(line, ":")
+	if !found {
+		return "", "", false
+	}
+
+	switch keyword {
+	case "Feature", "Background", "Scenario", "Example", "Scenario Outline", "Scenario Template",
+		"Examples", "Scenarios", "Rule":
+		return keyword, strings.TrimSpace(name), true
+	}
+
+	return "", "", false
+}
+
+// cutStepKeyword splits a step line such as `Given something`.
+func cutStepKeyword(line string) (keyword, text string, ok bool) {
+	for _, kw := range stepKeywords {
+		if rest, found := strings.CutPrefix(line, kw+" "); found {
+			return kw, strings.TrimSpace(rest), true
+		}
+	}
+
+	return "", "", false
+}
+
+// splitTableRow splits a `| a | b |` row into its cells, handling the `\|`,
+// `\\` and `\n` escapes.
+func splitTableRow(line string) ([]string, error) {
+	if !strings.HasSuffix(line, "|") || len(line) < 2 {
+		return nil, fmt.Errorf("table row must end with '|'")
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+
+	body := line[1:]
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case c == '\\' && i+1 < len(body):
+			i++
+			switch body[i] {
+			case 'n':
+				cell.WriteByte('\n')
+			default:
+				cell.WriteByte(body[i])
+			}
+
+		case c == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+
+		default:
+			cell.WriteByte(c)
+		}
+	}
+
+	return cells, nil
+}
+
+// hasTag checks if `tags` contains a tag with the given name, with or without
+// arguments.
+func hasTag(tags []string, name string) bool {
+	for _, tag := range tags {
+		if n, _ := splitTag(tag); n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitTag splits a `@name(argument)` tag into its name and argument.
+func splitTag(tag string) (name, argument string) {
+	name, argument, found := strings.Cut(tag, "(")
+	if found {
+		argument = strings.TrimSuffix(argument, ")")
+	}
+
+	return name, argument
+}
@@ -0,0 +1,136 @@
+//go:build test
+
+package gherkin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleFeature = `# A comment
+@feature-tag
+Feature: Sample
+  Some description
+  spanning two lines
+
+  Background:
+    Given a background step
+
+  Scenario: plain
+    Given a table:
+      | name | note      |
+      | John | a \| pipe |
+    When a doc string:
+      """
+      first line
+        indented line
+      """
+    Then it works
+    And it still works
+    But not always
+
+  @outline-tag
+  Scenario Outline: outline
+    Given <count> items
+    Then they are <state>
+
+    Examples: some items
+      | count | state |
+      | 1     | one   |
+      | 2     | two   |
+
+    @data(./cases.yaml)
+    Examples: from a file
+`
+
+func TestParse(t *testing.T) {
+	r := require.New(t)
+
+	feature, err := Parse("sample.feature", strings.NewReader(sampleFeature))
+	r.NoError(err)
+
+	r.Equal("Sample", feature.Name)
+	r.Equal("Some description\nspanning two lines", feature.Description)
+	r.Equal([]string{"@feature-tag"}, feature.Tags)
+
+	r.Len(feature.Background, 1)
+	r.Equal("a background step", feature.Background[0].Text)
+
+	r.Len(feature.Scenarios, 2)
+
+	plain := feature.Scenarios[0]
+	r.False(plain.Outline)
+	r.Equal(10, plain.Line)
+	r.Len(plain.Steps, 5)
+	r.Equal([][]string{{"name", "note"}, {"John", "a | pipe"}}, plain.Steps[0].Table.Rows)
+	r.Equal("first line\n  indented line", *plain.Steps[1].DocString)
+	r.Equal("Then", plain.Steps[3].Type)
+	r.Equal("And", plain.Steps[3].Keyword)
+	r.Equal("Then", plain.Steps[4].Type)
+
+	outline := feature.Scenarios[1]
+	r.True(outline.Outline)
+	r.Equal([]string{"@outline-tag"}, outline.Tags)
+	r.Len(outline.Examples, 2)
+	r.Equal([]map[string]string{{"count": "1", "state": "one"}, {"count": "2", "state": "two"}},
+		outline.Examples[0].Table.Maps())
+	r.Nil(outline.Examples[1].Table)
+	r.True(hasTag(outline.Examples[1].Tags, DataTag))
+}
+
+func TestParseErrors(t *testing.T) {
+	for title, tc := range map[string]struct {
+		content string
+		err     string
+	}{
+		"when there is no feature": {
+			content: "# nothing here\n",
+			err:     "sample.feature:1: no feature found",
+		},
+		"when a step comes before any scenario": {
+			content: "Given a step\n",
+			err:     "sample.feature:1: step outside of a scenario or background",
+		},
+		"when an And step starts a scenario": {
+			content: "Feature: F\n  Scenario: S\n    And a step\n",
+			err:     "sample.feature:3: 'And' step must follow a Given, When or Then step",
+		},
+		"when a table row has missing cells": {
+			content: "Feature: F\n  Scenario: S\n    Given a table:\n      | a | b |\n      | 1 |\n",
+			err:     "sample.feature:5: table row has 1 cells, expected 2",
+		},
+		"when an outline has no examples": {
+			content: "Feature: F\n  Scenario Outline: S\n    Given <a>\n",
+			err:     "sample.feature:2: scenario outline 'S' has no examples",
+		},
+		"when examples have neither table nor data": {
+			content: "Feature: F\n  Scenario Outline: S\n    Given <a>\n    Examples:\n",
+			err:     "sample.feature:4: examples need a table or a @data tag",
+		},
+		"when a doc string is not terminated": {
+			content: "Feature: F\n  Scenario: S\n    Given a doc string:\n      \"\"\"\n      text\n",
+			err:     "sample.feature:4: unterminated doc string",
+		},
+	} {
+		t.Run(title, func(t *testing.T) {
+			_, err := Parse("sample.feature", strings.NewReader(tc.content))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	r := require.New(t)
+
+	example := map[string]any{
+		"count":   2,
+		"state":   "two",
+		"request": map[string]any{"name": "John"},
+	}
+
+	r.Equal("2 items are two", substitute("<count> items are <state>", example))
+	r.Equal("<request> and <unknown>", substitute("<request> and <unknown>", example))
+	r.Equal("<count>", substitute("<count>", nil))
+}
@@ -0,0 +1,326 @@
+//go:build test
+
+package gherkin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/test/report"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// DataTag feeds the examples of a scenario outline from a YAML test data file,
+// e.g. `@data(../data/valid-cases.yaml)`. The path is relative to the feature
+// file. Each entry of the `cases` attribute of the file becomes an example
+// named after its key.
+const DataTag = "@data"
+
+// StepContext carries what a step function needs to know about the step being
+// run.
+type StepContext struct {
+	// Step is the step as parsed, before the placeholders are replaced.
+	Step *Step
+
+	// Args are the groups captured by the pattern of the step definition.
+	Args []string
+
+	// Table and DocString are the step arguments, if any, with the
+	// placeholders replaced.
+	Table     *Table
+	DocString string
+
+	// Example is the current example of a scenario outline: either a row of an
+	// examples table or a case of a @data file. It is nil for plain scenarios.
+	Example map[string]any
+
+	// Data is the content of the @data file feeding the example, if any.
+	Data map[string]any
+
+	// Tags are the tags of the feature, scenario and examples.
+	Tags []string
+
+	// Report is the report entry of the scenario or example, set when the
+	// runner reports. Its methods can be called anyway.
+	Report *report.Entry
+}
+
+// StepFunc implements a step on the scenario world `W`.
+type StepFunc[W any] func(t *testing.T, world W, sc *StepContext)
+
+// stepDefinition binds a step pattern to its implementation.
+type stepDefinition[W any] struct {
+	pattern *regexp.Regexp
+	fn      StepFunc[W]
+}
+
+// Runner runs the scenarios of feature files as subtests. Every scenario, and
+// every example of a scenario outline, gets a new world `W` holding its state.
+type Runner[W any] struct {
+	newWorld       func(t *testing.T) W
+	steps          []stepDefinition[W]
+	beforeScenario []func(t *testing.T, tags []string)
+	parallel       bool
+
+	report  *report.Report
+	variant string
+}
+
+// NewRunner creates a Runner building the world of each scenario with
+// `newWorld`.
+func NewRunner[W any](newWorld func(t *testing.T) W) *Runner[W] {
+	return &Runner[W]{newWorld: newWorld}
+}
+
+// Step defines the implementation of the steps whose text fully matches the
+// regular expression `pattern`. The pattern groups are given to `fn` as
+// StepContext.Args.
+func (r *Runner[W]) Step(pattern string, fn StepFunc[W]) {
+	r.steps = append(r.steps, stepDefinition[W]{
+		pattern: regexp.MustCompile("^" + pattern + "$"),
+		fn:      fn,
+	})
+}
+
+// BeforeScenario registers a hook called before each scenario with its tags.
+// Hooks may skip the scenario with `t.Skip`.
+func (r *Runner[W]) BeforeScenario(fn func(t *testing.T, tags []string)) {
+	r.beforeScenario = append(r.beforeScenario, fn)
+}
+
+// Parallel makes the runner run the features, scenarios and examples in
+// parallel, with `t.Parallel`. The worlds built by `newWorld` must then be
+// independent of each other.
+func (r *Runner[W]) Parallel() {
+	r.parallel = true
+}
+
+// Report records every scenario, and every example of a scenario outline, into
+// `rep`, as run against the SUT `variant`. The features are the stories of the
+// report, and their steps describe the cases.
+func (r *Runner[W]) Report(rep *report.Report, variant string) {
+	r.report = rep
+	r.variant = variant
+}
+
+// Run parses the feature files at `paths` and runs each one of their scenarios
+// as a subtest.
+func (r *Runner[W]) Run(t *testing.T, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		feature, err := ParseFile(path)
+		require.NoError(t, err)
+
+		t.Run(feature.Name, func(t *testing.T) {
+			r.parallelize(t)
+
+			for _, scenario := range feature.Scenarios {
+				t.Run(scenario.Name, func(t *testing.T) {
+					r.parallelize(t)
+					r.runScenario(t, feature, scenario)
+				})
+			}
+		})
+	}
+}
+
+// parallelize marks the test as parallel if the runner is.
+func (r *Runner[W]) parallelize(t *testing.T) {
+	if r.parallel {
+		t.Parallel()
+	}
+}
+
+// runScenario runs a plain scenario, or every example of a scenario outline.
+func (r *Runner[W]) runScenario(t *testing.T, feature *Feature, scenario *Scenario) {
+	t.Helper()
+
+	tags := slices.Concat(feature.Tags, scenario.Tags)
+
+	if !scenario.Outline {
+		r.runSteps(t, feature, scenario, "", &StepContext{Tags: tags})
+		return
+	}
+
+	for _, examples := range scenario.Examples {
+		exampleTags := slices.Concat(tags, examples.Tags)
+
+		for i, row := range examples.Table.Maps() {
+			example := make(map[string]any, len(row))
+			for k, v := range row {
+				example[k] = v
+			}
+
+			name := strings.TrimSpace(fmt.Sprintf("%s #%d", examples.Name, i+1))
+			t.Run(name, func(t *testing.T) {
+				r.parallelize(t)
+				r.runSteps(t, feature, scenario, name, &StepContext{Example: example, Tags: exampleTags})
+			})
+		}
+
+		if !hasTag(examples.Tags, DataTag) {
+			continue
+		}
+
+		data := loadData(t, feature, examples)
+
+		cases, ok := data["cases"].(map[string]any)
+		require.True(t, ok, "%s:%d: the data file has no 'cases'", feature.Path, examples.Line)
+
+		titles := make([]string, 0, len(cases))
+		for title := range cases {
+			titles = append(titles, title)
+		}
+		slices.Sort(titles)
+
+		for _, title := range titles {
+			example, ok := cases[title].(map[string]any)
+			require.True(t, ok, "case '%s' should be a map", title)
+
+			t.Run(title, func(t *testing.T) {
+				r.parallelize(t)
+				r.runSteps(t, feature, scenario, title, &StepContext{Example: example, Data: data, Tags: exampleTags})
+			})
+		}
+	}
+}
+
+// runSteps runs the background and scenario steps against a new world. The
+// `example` names the example being run, if any.
+func (r *Runner[W]) runSteps(t *testing.T, feature *Feature, scenario *Scenario, example string, base *StepContext) {
+	t.Helper()
+
+	if r.report != nil {
+		base.Report = r.report.Record(t, report.Key{
+			Story:    feature.Name,
+			Scenario: scenario.Name,
+			Case:     example,
+			Variant:  r.variant,
+		})
+	}
+
+	for _, hook := range r.beforeScenario {
+		hook(t, base.Tags)
+	}
+
+	world := r.newWorld(t)
+
+	for _, step := range slices.Concat(feature.Background, scenario.Steps) {
+		r.runStep(t, feature, world, step, base)
+	}
+}
+
+// runStep finds the definition of a step and runs it.
+func (r *Runner[W]) runStep(t *testing.T, feature *Feature, world W, step *Step, base *StepContext) {
+	t.Helper()
+
+	text := substitute(step.Text, base.Example)
+
+	var (
+		def  *stepDefinition[W]
+		args []string
+	)
+
+	for i := range r.steps {
+		match := r.steps[i].pattern.FindStringSubmatch(text)
+		if match == nil {
+			continue
+		}
+
+		if def != nil {
+			t.Fatalf("%s:%d: ambiguous step '%s %s': matches /%s/ and /%s/",
+				feature.Path, step.Line, step.Keyword, text, def.pattern, r.steps[i].pattern)
+		}
+
+		def = &r.steps[i]
+		args = match[1:]
+	}
+
+	if def == nil {
+		t.Fatalf("%s:%d: undefined step '%s %s'", feature.Path, step.Line, step.Keyword, text)
+	}
+
+	sc := *base
+	sc.Step = step
+	sc.Args = args
+
+	if step.Table != nil {
+		sc.Table = &Table{Line: step.Table.Line}
+		for _, row := range step.Table.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = substitute(cell, base.Example)
+			}
+			sc.Table.Rows = append(sc.Table.Rows, cells)
+		}
+	}
+
+	if step.DocString != nil {
+		sc.DocString = substitute(*step.DocString, base.Example)
+	}
+
+	sc.Report.AddStep(step.Keyword + " " + text)
+
+	defer func() {
+		if t.Failed() {
+			t.Logf("%s:%d: failed at step '%s %s'", feature.Path, step.Line, step.Keyword, text)
+		}
+	}()
+
+	def.fn(t, world, &sc)
+}
+
+// placeholderRegexp matches the `<name>` placeholders of scenario outlines.
+var placeholderRegexp = regexp.MustCompile(`<([^<>]+)>`)
+
+// substitute replaces the placeholders in `s` with the scalar values of the
+// example. Unknown placeholders are left untouched.
+func substitute(s string, example map[string]any) string {
+	if example == nil {
+		return s
+	}
+
+	return placeholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		val, found := example[placeholder[1:len(placeholder)-1]]
+		if !found {
+			return placeholder
+		}
+
+		switch val.(type) {
+		case map[string]any, []any:
+			return placeholder
+		}
+
+		return fmt.Sprint(val)
+	})
+}
+
+// loadData loads the YAML file referenced by the @data tag of `examples`.
+func loadData(t *testing.T, feature *Feature, examples *Examples) map[string]any {
+	t.Helper()
+
+	r := require.New(t)
+
+	var path string
+	for _, tag := range examples.Tags {
+		if name, argument := splitTag(tag); name == DataTag {
+			path = argument
+		}
+	}
+	r.NotEmpty(path, "%s:%d: %s tag without a path", feature.Path, examples.Line, DataTag)
+
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(feature.Path), path))
+	r.NoError(err)
+
+	var data map[string]any
+	r.NoError(yaml.Unmarshal(content, &data))
+
+	return data
+}
@@ -0,0 +1,318 @@
+//go:build test
+
+// Package harness assembles the systems under test (SUTs) of the acceptance
+// suites from interchangeable components.
+//
+// A Harness is a pipeline of layers, such as the repository and the
+// presentation layers, and of fixed steps between them. Adapters register
+// their options for a layer, usually from an `init` function in a file built
+// with the `test` tag, and every combination of options becomes a variant of
+// the SUT. The variant names join the names of their options with hyphens,
+// e.g. `sqlite-rest`; options named "" add nothing to the name.
+//
+// The variants to run can be narrowed with the `-gtd.variants` flag or the
+// `GTD_VARIANTS` environment variable, holding a comma separated list of
+// `path.Match` patterns, e.g. `GTD_VARIANTS=sqlite,*-rest`. Patterns prefixed
+// with `!` exclude variants instead, e.g. `GTD_VARIANTS=!*-https`.
+//
+// Options registered with RegisterExclusive are only combined with one
+// another, e.g. the presentation of a client-only variant, which talks to an
+// external server, with the repository behind that server.
+package harness
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// VariantsEnv is the environment variable selecting the variants to run. The
+// `-gtd.variants` flag takes precedence over it.
+const VariantsEnv = "GTD_VARIANTS"
+
+var variantsFlag = flag.String("gtd.variants", "",
+	"comma separated patterns of the SUT variants to run, e.g. 'sqlite,*-rest' (env "+VariantsEnv+")")
+
+// SetupFunc sets up a part of the SUT `sut`.
+type SetupFunc[S any] func(t *testing.T, sut *S)
+
+// Harness builds the variants of a SUT of type S.
+type Harness[S any] struct {
+	mu     sync.Mutex
+	stages []*stage[S]
+}
+
+// stage is either a layer, with its registered options, or a fixed step.
+type stage[S any] struct {
+	layer   string
+	options []option[S]
+	step    SetupFunc[S]
+}
+
+// option is a registered component of a layer.
+type option[S any] struct {
+	name      string
+	exclusive bool
+	setup     SetupFunc[S]
+}
+
+// New creates an empty Harness.
+func New[S any]() *Harness[S] {
+	return &Harness[S]{}
+}
+
+// Layer appends a layer to the pipeline. Each variant uses exactly one of the
+// options registered for it.
+func (h *Harness[S]) Layer(name string) *Harness[S] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.findLayer(name) != nil {
+		panic(fmt.Sprintf("harness: duplicated layer '%s'", name))
+	}
+
+	h.stages = append(h.stages, &stage[S]{layer: name})
+
+	return h
+}
+
+// Step appends a fixed step to the pipeline, run by every variant.
+func (h *Harness[S]) Step(fn SetupFunc[S]) *Harness[S] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stages = append(h.stages, &stage[S]{step: fn})
+
+	return h
+}
+
+// Register adds the option `name` to `layer`. It panics when the layer is
+// unknown or the option is already registered, as both are programming errors.
+func (h *Harness[S]) Register(layer, name string, setup SetupFunc[S]) {
+	h.register(layer, option[S]{name: name, setup: setup})
+}
+
+// RegisterExclusive adds the option `name` to `layer`, like Register, but the
+// option is only combined with the exclusive options of the other layers, and
+// they only with it.
+func (h *Harness[S]) RegisterExclusive(layer, name string, setup SetupFunc[S]) {
+	h.register(layer, option[S]{name: name, exclusive: true, setup: setup})
+}
+
+// register adds the option `o` to `layer`.
+func (h *Harness[S]) register(layer string, o option[S]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st := h.findLayer(layer)
+	if st == nil {
+		panic(fmt.Sprintf("harness: unknown layer '%s'", layer))
+	}
+
+	if slices.ContainsFunc(st.options, func(registered option[S]) bool { return registered.name == o.name }) {
+		panic(fmt.Sprintf("harness: option '%s' already registered for layer '%s'", o.name, layer))
+	}
+
+	st.options = append(st.options, o)
+}
+
+// findLayer returns the stage of `layer`, or nil.
+func (h *Harness[S]) findLayer(layer string) *stage[S] {
+	for _, st := range h.stages {
+		if st.step == nil && st.layer == layer {
+			return st
+		}
+	}
+
+	return nil
+}
+
+//
+// Variants
+
+// Variant is a combination of one option of each layer.
+type Variant[S any] struct {
+	// Name joins the names of the options, e.g. `sqlite-rest`.
+	Name string
+
+	// Options maps each layer to the name of the option used by the variant.
+	Options map[string]string
+
+	exclusive bool
+	setups    []SetupFunc[S]
+}
+
+// Setup builds a new SUT for the variant. It records `t` as a scenario when
+// asked to, see ScenariosEnv.
+func (v Variant[S]) Setup(t *testing.T) *S {
+	t.Helper()
+
+	recordScenario(t)
+
+	sut := new(S)
+	for _, setup := range v.setups {
+		setup(t, sut)
+	}
+
+	return sut
+}
+
+// Variants returns all the variants: the cartesian product of the options of
+// every layer, in registration order, the exclusive options apart.
+func (h *Harness[S]) Variants() []Variant[S] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	variants := []Variant[S]{{Options: map[string]string{}}}
+
+	for _, st := range h.stages {
+		if st.step != nil {
+			for i := range variants {
+				variants[i].setups = append(slices.Clip(variants[i].setups), st.step)
+			}
+			continue
+		}
+
+		var next []Variant[S]
+		for _, v := range variants {
+			for _, o := range st.options {
+				// The first layer decides whether the variant is exclusive.
+				if len(v.Options) > 0 && o.exclusive != v.exclusive {
+					continue
+				}
+
+				options := make(map[string]string, len(v.Options)+1)
+				for k, val := range v.Options {
+					options[k] = val
+				}
+				options[st.layer] = o.name
+
+				name := v.Name
+				if o.name != "" {
+					name = strings.TrimPrefix(name+"-"+o.name, "-")
+				}
+
+				next = append(next, Variant[S]{
+					Name:      name,
+					Options:   options,
+					exclusive: o.exclusive,
+					setups:    append(slices.Clip(v.setups), o.setup),
+				})
+			}
+		}
+		variants = next
+	}
+
+	return variants
+}
+
+// Select returns the variants matching the comma separated `patterns`. An
+// empty selection means all the variants. It fails when a pattern is malformed
+// or matches no variant, so that typos don't silently skip tests.
+func (h *Harness[S]) Select(patterns string) ([]Variant[S], error) {
+	variants := h.Variants()
+
+	if strings.TrimSpace(patterns) == "" {
+		return variants, nil
+	}
+
+	include, exclude := splitPatterns(patterns)
+
+	for _, p := range slices.Concat(include, exclude) {
+		if !slices.ContainsFunc(variants, func(v Variant[S]) bool { return matches(p, v.Name) }) {
+			return nil, fmt.Errorf("harness: pattern '%s' matches no variant; known variants: %s", p, names(variants))
+		}
+	}
+
+	var selected []Variant[S]
+	for _, v := range variants {
+		if selects(include, exclude, v.Name) {
+			selected = append(selected, v)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("harness: '%s' selects no variant", patterns)
+	}
+
+	return selected, nil
+}
+
+// SelectedVariants returns the variants selected by the `-gtd.variants` flag
+// or the GTD_VARIANTS environment variable, failing the test on invalid
+// selections.
+func (h *Harness[S]) SelectedVariants(t *testing.T) []Variant[S] {
+	t.Helper()
+
+	patterns := *variantsFlag
+	if patterns == "" {
+		patterns = os.Getenv(VariantsEnv)
+	}
+
+	variants, err := h.Select(patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return variants
+}
+
+// Matches checks if the variant is selected by the comma separated
+// `patterns`, with the syntax of Select. An empty selection matches every
+// variant. Unlike Select, patterns matching no variant are not an error.
+func (v Variant[S]) Matches(patterns string) bool {
+	include, exclude := splitPatterns(patterns)
+
+	return selects(include, exclude, v.Name)
+}
+
+// splitPatterns splits the comma separated `patterns` into the patterns of
+// the variants to include and the ones to exclude, without their `!`.
+func splitPatterns(patterns string) (include, exclude []string) {
+	for _, p := range strings.Split(patterns, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		if excluded, found := strings.CutPrefix(p, "!"); found {
+			exclude = append(exclude, excluded)
+		} else {
+			include = append(include, p)
+		}
+	}
+
+	return include, exclude
+}
+
+// selects checks if the variant `name` matches one of the `include` patterns,
+// if any, and none of the `exclude` ones.
+func selects(include, exclude []string, name string) bool {
+	if len(include) > 0 && !slices.ContainsFunc(include, func(p string) bool { return matches(p, name) }) {
+		return false
+	}
+
+	return !slices.ContainsFunc(exclude, func(p string) bool { return matches(p, name) })
+}
+
+// matches checks if the variant `name` matches the pattern `p`. Malformed
+// patterns match nothing.
+func matches(p, name string) bool {
+	ok, err := path.Match(p, name)
+	return err == nil && ok
+}
+
+// names lists the names of the variants.
+func names[S any](variants []Variant[S]) string {
+	ns := make([]string, len(variants))
+	for i, v := range variants {
+		ns[i] = v.Name
+	}
+
+	return strings.Join(ns, ", ")
+}
@@ -0,0 +1,137 @@
+//go:build test
+
+package harness
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sut records the steps run to set it up.
+type sut struct {
+	steps []string
+}
+
+// newTestHarness creates a harness with two storages and three presentations,
+// one of them unnamed.
+func newTestHarness() *Harness[sut] {
+	h := New[sut]().
+		Layer("storage").
+		Step(func(_ *testing.T, s *sut) { s.steps = append(s.steps, "service") }).
+		Layer("presentation")
+
+	for _, name := range []string{"memory", "sql"} {
+		h.Register("storage", name, func(_ *testing.T, s *sut) { s.steps = append(s.steps, name) })
+	}
+
+	for _, name := range []string{"", "rest", "grpc"} {
+		h.Register("presentation", name, func(_ *testing.T, s *sut) { s.steps = append(s.steps, "presentation:"+name) })
+	}
+
+	return h
+}
+
+func TestVariants(t *testing.T) {
+	r := require.New(t)
+
+	variants := newTestHarness().Variants()
+
+	r.Equal("memory, memory-rest, memory-grpc, sql, sql-rest, sql-grpc", names(variants))
+	r.Equal(map[string]string{"storage": "sql", "presentation": "rest"}, variants[4].Options)
+	r.Equal([]string{"sql", "service", "presentation:rest"}, variants[4].Setup(t).steps)
+	r.Equal([]string{"memory", "service", "presentation:"}, variants[0].Setup(t).steps)
+}
+
+func TestRegisterExclusive(t *testing.T) {
+	r := require.New(t)
+
+	h := newTestHarness()
+	h.RegisterExclusive("storage", "remote", func(_ *testing.T, s *sut) { s.steps = append(s.steps, "remote") })
+	h.RegisterExclusive("presentation", "client", func(_ *testing.T, s *sut) { s.steps = append(s.steps, "presentation:client") })
+
+	variants := h.Variants()
+
+	r.Equal("memory, memory-rest, memory-grpc, sql, sql-rest, sql-grpc, remote-client", names(variants))
+	r.Equal([]string{"remote", "service", "presentation:client"}, variants[6].Setup(t).steps)
+
+	require.PanicsWithValue(t, "harness: option 'rest' already registered for layer 'presentation'", func() {
+		h.RegisterExclusive("presentation", "rest", func(*testing.T, *sut) {})
+	})
+}
+
+func TestSelect(t *testing.T) {
+	h := newTestHarness()
+
+	for title, tc := range map[string]struct {
+		patterns string
+		selected string
+		err      string
+	}{
+		"when no pattern is given":       {patterns: " ", selected: "memory, memory-rest, memory-grpc, sql, sql-rest, sql-grpc"},
+		"when matching exact names":      {patterns: "sql,memory-grpc", selected: "memory-grpc, sql"},
+		"when matching globs":            {patterns: "*-rest, sql*", selected: "memory-rest, sql, sql-rest, sql-grpc"},
+		"when excluding variants":        {patterns: "!*-grpc", selected: "memory, memory-rest, sql, sql-rest"},
+		"when including and excluding":   {patterns: "sql*,!sql-grpc", selected: "sql, sql-rest"},
+		"when a pattern matches nothing": {patterns: "sql,mongo*", err: "harness: pattern 'mongo*' matches no variant"},
+		"when a pattern is malformed":    {patterns: "sql[", err: "harness: pattern 'sql[' matches no variant"},
+		"when everything is excluded":    {patterns: "!*", err: "harness: '!*' selects no variant"},
+	} {
+		t.Run(title, func(t *testing.T) {
+			variants, err := h.Select(tc.patterns)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.selected, names(variants))
+		})
+	}
+}
+
+func TestRegisterMisuse(t *testing.T) {
+	h := newTestHarness()
+
+	require.PanicsWithValue(t, "harness: unknown layer 'cache'", func() {
+		h.Register("cache", "redis", func(*testing.T, *sut) {})
+	})
+
+	require.PanicsWithValue(t, "harness: option 'sql' already registered for layer 'storage'", func() {
+		h.Register("storage", "sql", func(*testing.T, *sut) {})
+	})
+
+	require.PanicsWithValue(t, "harness: duplicated layer 'storage'", func() {
+		h.Layer("storage")
+	})
+}
+
+func TestRecordScenario(t *testing.T) {
+	r := require.New(t)
+
+	path := t.TempDir() + "/scenarios.txt"
+	t.Setenv(ScenariosEnv, path)
+
+	variant := newTestHarness().Variants()[0]
+
+	t.Run("first scenario", func(t *testing.T) { variant.Setup(t) })
+	t.Run("second scenario", func(t *testing.T) { variant.Setup(t) })
+
+	data, err := os.ReadFile(path)
+	r.NoError(err)
+	r.Equal("TestRecordScenario/first_scenario\nTestRecordScenario/second_scenario\n", string(data))
+}
+
+func TestVariantMatches(t *testing.T) {
+	r := require.New(t)
+
+	variant := newTestHarness().Variants()[4] // sql-rest
+
+	r.True(variant.Matches(""))
+	r.True(variant.Matches("*-rest"))
+	r.True(variant.Matches("sql*, !*-grpc"))
+	r.True(variant.Matches("mongo*, sql-rest"))
+	r.False(variant.Matches("!sql-*"))
+	r.False(variant.Matches("memory*"))
+}
@@ -0,0 +1,53 @@
+//go:build test
+
+package harness
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+// ScenariosEnv is the environment variable naming the file where Setup
+// records the tests setting up a SUT, the scenarios. The
+// `-gtd.scenarios-file` flag takes precedence over it.
+//
+// The scenarios are recorded one name per line, as reported by `t.Name()`,
+// so that each of them can be run on its own, e.g. to tell the code it covers.
+const ScenariosEnv = "GTD_SCENARIOS_FILE"
+
+var scenariosFlag = flag.String("gtd.scenarios-file", "",
+	"file where the tests setting up a SUT are recorded (env "+ScenariosEnv+")")
+
+// scenariosMu serializes the writes to the scenarios file.
+var scenariosMu sync.Mutex
+
+// recordScenario appends the name of `t` to the scenarios file, if any. It
+// fails the test when the file can't be written.
+func recordScenario(t *testing.T) {
+	t.Helper()
+
+	path := *scenariosFlag
+	if path == "" {
+		path = os.Getenv(ScenariosEnv)
+	}
+
+	if path == "" {
+		return
+	}
+
+	scenariosMu.Lock()
+	defer scenariosMu.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("harness: failed to record the scenario: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintln(f, t.Name()); err != nil {
+		t.Fatalf("harness: failed to record the scenario: %v", err)
+	}
+}
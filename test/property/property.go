@@ -0,0 +1,248 @@
+//go:build test
+
+// Package property runs property-based tests: it checks that a property holds
+// for many random inputs and, when it does not, shrinks the failing input to a
+// minimal one before reporting it.
+//
+// The inputs are drawn by a Generator from a random source seeded for each
+// run, so that a failure can be reproduced with the seed it reports. The seed
+// and the number of runs can be set with the `-gtd.seed` and `-gtd.runs` flags
+// or the GTD_SEED and GTD_RUNS environment variables, e.g.
+// `GTD_SEED=42 GTD_RUNS=1000`.
+package property
+
+import (
+	"flag"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Environment variables setting the seed and the number of runs. The
+// `-gtd.seed` and `-gtd.runs` flags take precedence over them.
+const (
+	SeedEnv = "GTD_SEED"
+	RunsEnv = "GTD_RUNS"
+)
+
+// Defaults of the Config.
+const (
+	DefaultRuns       = 100
+	DefaultMaxShrinks = 200
+)
+
+var (
+	seedFlag = flag.Uint64("gtd.seed", 0, "seed of the property-based tests, random by default (env "+SeedEnv+")")
+	runsFlag = flag.Int("gtd.runs", 0, "number of runs of the property-based tests (env "+RunsEnv+")")
+)
+
+// Generator draws a random input from `r`.
+type Generator[T any] func(r *rand.Rand) T
+
+// Shrinker returns simpler versions of `v`, the simplest first. Shrinkers
+// should return nothing for the simplest inputs, so that shrinking ends.
+type Shrinker[T any] func(v T) []T
+
+// Property checks an input, returning an error describing why it does not
+// hold. The test `t` is only meant for the setup of the input, e.g. driving a
+// SUT: the property must not fail it, or the failure can't be shrunk.
+type Property[T any] func(t *testing.T, v T) error
+
+// Config tunes a check.
+type Config struct {
+	// Seed seeds the random source of the first run; the other runs derive
+	// their own from it.
+	Seed uint64
+
+	// Runs is the number of random inputs checked.
+	Runs int
+
+	// MaxShrinks limits the number of simpler inputs checked while shrinking
+	// a failure.
+	MaxShrinks int
+}
+
+// SelectedConfig returns the configuration selected by the flags or the
+// environment, with a random seed when none is selected. It fails the test on
+// malformed values.
+func SelectedConfig(t *testing.T) Config {
+	t.Helper()
+
+	cfg := Config{Seed: *seedFlag, Runs: *runsFlag, MaxShrinks: DefaultMaxShrinks}
+
+	if cfg.Seed == 0 {
+		if env := os.Getenv(SeedEnv); env != "" {
+			seed, err := strconv.ParseUint(env, 10, 64)
+			if err != nil {
+				t.Fatalf("property: malformed %s '%s': %v", SeedEnv, env, err)
+			}
+			cfg.Seed = seed
+		}
+	}
+
+	if cfg.Runs == 0 {
+		if env := os.Getenv(RunsEnv); env != "" {
+			runs, err := strconv.Atoi(env)
+			if err != nil || runs <= 0 {
+				t.Fatalf("property: malformed %s '%s': expected a positive number", RunsEnv, env)
+			}
+			cfg.Runs = runs
+		}
+	}
+
+	if cfg.Seed == 0 {
+		cfg.Seed = uint64(time.Now().UnixNano())
+	}
+
+	if cfg.Runs == 0 {
+		cfg.Runs = DefaultRuns
+	}
+
+	return cfg
+}
+
+// Failure is an input for which a property does not hold.
+type Failure[T any] struct {
+	// Run is the index of the failing run, starting at zero.
+	Run int
+
+	// Original is the input drawn by the generator, and Minimal the simplest
+	// input found to still fail, with the error it got.
+	Original T
+	Minimal  T
+	Err      error
+
+	// Shrinks is the number of simpler inputs which still failed.
+	Shrinks int
+}
+
+// Check checks `prop` against the inputs drawn by `gen`, with the
+// configuration selected by SelectedConfig. On failure, it shrinks the input
+// with `shrink`, which may be nil, then fails the test with the minimal input
+// and the seed reproducing it.
+func Check[T any](t *testing.T, gen Generator[T], shrink Shrinker[T], prop Property[T]) {
+	t.Helper()
+
+	cfg := SelectedConfig(t)
+
+	f := Find(t, cfg, gen, shrink, prop)
+	if f == nil {
+		return
+	}
+
+	t.Fatalf("property failed on run #%d (reproduce with -gtd.seed=%d or %s=%d), shrunk %d times:\n"+
+		"minimal input: %+v\nerror: %v\noriginal input: %+v",
+		f.Run, cfg.Seed, SeedEnv, cfg.Seed, f.Shrinks, f.Minimal, f.Err, f.Original)
+}
+
+// Find checks `prop` against `cfg.Runs` inputs drawn by `gen`, and returns the
+// first failure, shrunk, or nil when the property holds.
+func Find[T any](t *testing.T, cfg Config, gen Generator[T], shrink Shrinker[T], prop Property[T]) *Failure[T] {
+	t.Helper()
+
+	for run := range cfg.Runs {
+		v := gen(rand.New(rand.NewPCG(cfg.Seed, uint64(run))))
+
+		err := prop(t, v)
+		if err == nil {
+			continue
+		}
+
+		f := &Failure[T]{Run: run, Original: v, Minimal: v, Err: err}
+		if shrink != nil {
+			f.shrink(t, cfg.MaxShrinks, shrink, prop)
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+// shrink replaces the minimal input by the first of its simpler versions
+// which still fails, until none does or `budget` inputs were checked.
+func (f *Failure[T]) shrink(t *testing.T, budget int, shrink Shrinker[T], prop Property[T]) {
+	t.Helper()
+
+	for budget > 0 {
+		shrunk := false
+
+		for _, candidate := range shrink(f.Minimal) {
+			if budget == 0 {
+				break
+			}
+			budget--
+
+			if err := prop(t, candidate); err != nil {
+				f.Minimal, f.Err = candidate, err
+				f.Shrinks++
+				shrunk = true
+				break
+			}
+		}
+
+		if !shrunk {
+			return
+		}
+	}
+}
+
+//
+// Shrinkers
+
+// ShrinkSlice returns the simpler versions of `s`: without one of its halves,
+// then without one of its elements, then with one of its elements shrunk by
+// `shrinkElem`, which may be nil.
+func ShrinkSlice[T any](s []T, shrinkElem Shrinker[T]) [][]T {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var candidates [][]T
+	if half := len(s) / 2; half > 0 {
+		candidates = append(candidates, slices.Clone(s[half:]), slices.Clone(s[:half]))
+	}
+
+	for i := range s {
+		candidates = append(candidates, without(s, i))
+	}
+
+	if shrinkElem != nil {
+		for i, elem := range s {
+			for _, simpler := range shrinkElem(elem) {
+				candidate := slices.Clone(s)
+				candidate[i] = simpler
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	return candidates
+}
+
+// ShrinkString returns the simpler versions of `s`: without one of its halves,
+// then without one of its bytes.
+func ShrinkString(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	var candidates []string
+	if half := len(s) / 2; half > 0 {
+		candidates = append(candidates, s[half:], s[:half])
+	}
+
+	for i := range len(s) {
+		candidates = append(candidates, s[:i]+s[i+1:])
+	}
+
+	return candidates
+}
+
+// without copies `s` without its element `i`.
+func without[T any](s []T, i int) []T {
+	return slices.Concat(s[:i], s[i+1:])
+}
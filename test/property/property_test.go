@@ -0,0 +1,111 @@
+//go:build test
+
+package property
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// genInts draws up to 20 numbers between 0 and 99.
+func genInts(r *rand.Rand) []int {
+	s := make([]int, r.IntN(21))
+	for i := range s {
+		s[i] = r.IntN(100)
+	}
+
+	return s
+}
+
+// shrinkInt shrinks a number toward zero.
+func shrinkInt(n int) []int {
+	if n == 0 {
+		return nil
+	}
+
+	return []int{n - 1}
+}
+
+func TestFind(t *testing.T) {
+	cfg := Config{Seed: 1, Runs: 100, MaxShrinks: 1000}
+
+	t.Run("should find nothing when the property holds", func(t *testing.T) {
+		f := Find(t, cfg, genInts, nil, func(_ *testing.T, s []int) error {
+			if len(s) > 20 {
+				return fmt.Errorf("too long")
+			}
+			return nil
+		})
+
+		require.Nil(t, f)
+	})
+
+	t.Run("should shrink a failure to a minimal input", func(t *testing.T) {
+		// The property fails as soon as two numbers sum to more than 100.
+		sumsBelow100 := func(_ *testing.T, s []int) error {
+			for i := range s {
+				for j := i + 1; j < len(s); j++ {
+					if s[i]+s[j] > 100 {
+						return fmt.Errorf("%d + %d > 100", s[i], s[j])
+					}
+				}
+			}
+			return nil
+		}
+
+		f := Find(t, cfg, genInts, func(s []int) [][]int { return ShrinkSlice(s, shrinkInt) }, sumsBelow100)
+
+		require.NotNil(t, f)
+		require.Len(t, f.Minimal, 2)
+		require.Equal(t, 101, f.Minimal[0]+f.Minimal[1])
+		require.Error(t, f.Err)
+		require.Positive(t, f.Shrinks)
+		require.Error(t, sumsBelow100(t, f.Original))
+	})
+
+	t.Run("should draw the same inputs from the same seed", func(t *testing.T) {
+		var first, second [][]int
+		collect := func(into *[][]int) Property[[]int] {
+			return func(_ *testing.T, s []int) error {
+				*into = append(*into, s)
+				return nil
+			}
+		}
+
+		Find(t, cfg, genInts, nil, collect(&first))
+		Find(t, cfg, genInts, nil, collect(&second))
+
+		require.Equal(t, first, second)
+	})
+
+	t.Run("should stop shrinking when out of budget", func(t *testing.T) {
+		cfg := cfg
+		cfg.MaxShrinks = 3
+
+		f := Find(t, cfg, genInts, func(s []int) [][]int { return ShrinkSlice(s, shrinkInt) },
+			func(_ *testing.T, s []int) error {
+				if len(s) > 0 {
+					return fmt.Errorf("not empty")
+				}
+				return nil
+			})
+
+		require.NotNil(t, f)
+		require.LessOrEqual(t, f.Shrinks, 3)
+	})
+}
+
+func TestShrinkString(t *testing.T) {
+	require.Nil(t, ShrinkString(""))
+	require.Equal(t, []string{""}, ShrinkString("a"))
+	require.Equal(t, []string{"bc", "a", "bc", "ac", "ab"}, ShrinkString("abc"))
+}
+
+func TestShrinkSlice(t *testing.T) {
+	require.Nil(t, ShrinkSlice([]int{}, shrinkInt))
+	require.Equal(t, [][]int{nil, {1}}, ShrinkSlice([]int{2}, shrinkInt))
+	require.Equal(t, [][]int{{2}, {1}, {2}, {1}, {0, 2}, {1, 1}}, ShrinkSlice([]int{1, 2}, shrinkInt))
+}
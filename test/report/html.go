@@ -0,0 +1,87 @@
+//go:build test
+
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// htmlTemplate renders the report as a standalone HTML page.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"symbol": func(r result, found bool) string {
+		if !found {
+			return ""
+		}
+		return statusSymbols[r.Status]
+	},
+	"result": func(c *caseSummary, variant string) []any {
+		r, found := c.Results[variant]
+		return []any{r, found}
+	},
+	"yaml": func(v map[string]any) (string, error) {
+		data, err := yaml.Marshal(v)
+		return string(data), err
+	},
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: center; }
+td:first-child { text-align: left; }
+pre { background: #f6f6f6; padding: 0.6em; }
+.suite { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Counts}}</p>
+{{- range $suite := .Suites}}{{range $story := .Stories}}
+<h2>{{$story.Name}}</h2>
+<p class="suite">Suite: <code>{{$suite.Name}}</code></p>
+{{- range $scenario := .Scenarios}}
+<h3>{{$scenario.Name}}</h3>
+<table>
+<tr><th>Case</th>{{range .Variants}}<th>{{.}}</th>{{end}}</tr>
+{{- range $c := .Cases}}
+<tr><td>{{$c.Title}}</td>{{range $scenario.Variants}}{{$r := result $c .}}<td>{{symbol (index $r 0) (index $r 1)}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- range $c := .Cases}}{{if $c.Described}}
+{{- if $c.Name}}
+<h4>{{$c.Name}}</h4>
+{{- end}}
+{{- if $c.Steps}}
+<ul>{{range $c.Steps}}<li>{{.}}</li>{{end}}</ul>
+{{- end}}
+{{- if $c.Request}}
+<p>Request:</p>
+<pre>{{yaml $c.Request}}</pre>
+{{- end}}
+{{- if $c.Outcome}}
+<p>Expected: {{$c.Outcome}}{{if $c.Details}}, mentioning {{range $i, $d := $c.Details}}{{if $i}}, {{end}}<code>{{$d}}</code>{{end}}{{end}}.</p>
+{{- end}}
+{{- end}}{{end}}
+{{- end}}
+{{- end}}{{end}}
+</body>
+</html>
+`))
+
+// renderHTML renders the report as HTML.
+func renderHTML(s *summary) ([]byte, error) {
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, s); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,163 @@
+//go:build test
+
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JUnit XML elements, as understood by most CI tools.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Name     string           `xml:"name,attr"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name     string          `xml:"name,attr"`
+		Tests    int             `xml:"tests,attr"`
+		Failures int             `xml:"failures,attr"`
+		Skipped  int             `xml:"skipped,attr"`
+		Time     string          `xml:"time,attr"`
+		Cases    []junitTestCase `xml:"testcase"`
+	}
+
+	junitTestCase struct {
+		Name      string        `xml:"name,attr"`
+		ClassName string        `xml:"classname,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitMessage `xml:"failure"`
+		Skipped   *junitMessage `xml:"skipped"`
+		SystemOut string        `xml:"system-out,omitempty"`
+	}
+
+	junitMessage struct {
+		Message string `xml:"message,attr"`
+	}
+)
+
+// junit renders the report as JUnit XML, with a test suite per story and a
+// test case per case and variant.
+func junit(s *summary) ([]byte, error) {
+	root := junitTestSuites{Name: s.Title}
+
+	var total time.Duration
+
+	for _, suite := range s.Suites {
+		for _, story := range suite.Stories {
+			ts := junitTestSuite{Name: story.Name}
+
+			var elapsed time.Duration
+
+			for _, scenario := range story.Scenarios {
+				for _, c := range scenario.Cases {
+					out, err := junitSystemOut(c)
+					if err != nil {
+						return nil, err
+					}
+
+					for _, variant := range scenario.Variants {
+						r, found := c.Results[variant]
+						if !found {
+							continue
+						}
+
+						name := scenario.Name
+						if c.Name != "" {
+							name += " / " + c.Name
+						}
+
+						tc := junitTestCase{
+							Name:      fmt.Sprintf("%s [%s]", name, variant),
+							ClassName: suite.Name + "." + story.Name,
+							Time:      seconds(r.Duration),
+							SystemOut: out,
+						}
+
+						ts.Tests++
+						elapsed += r.Duration
+
+						switch r.Status {
+						case StatusFailed:
+							tc.Failure = &junitMessage{Message: "the case failed; see the test output"}
+							ts.Failures++
+						case StatusSkipped:
+							tc.Skipped = &junitMessage{Message: "the case was skipped"}
+							ts.Skipped++
+						}
+
+						ts.Cases = append(ts.Cases, tc)
+					}
+				}
+			}
+
+			ts.Time = seconds(elapsed)
+			total += elapsed
+
+			root.Tests += ts.Tests
+			root.Failures += ts.Failures
+			root.Skipped += ts.Skipped
+			root.Suites = append(root.Suites, ts)
+		}
+	}
+
+	root.Time = seconds(total)
+
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+
+	b.WriteString("\n")
+
+	return b.Bytes(), nil
+}
+
+// junitSystemOut describes a case for the CI tools.
+func junitSystemOut(c *caseSummary) (string, error) {
+	var b strings.Builder
+
+	for _, step := range c.Steps {
+		fmt.Fprintln(&b, step)
+	}
+
+	if c.Request != nil {
+		request, err := yaml.Marshal(c.Request)
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(&b, "request:\n%s", request)
+	}
+
+	if c.Outcome != "" {
+		fmt.Fprintf(&b, "expected: %s", c.Outcome)
+		if len(c.Details) > 0 {
+			fmt.Fprintf(&b, ", mentioning %s", quoteAll(c.Details, "'"))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	return b.String(), nil
+}
+
+// seconds formats a duration as JUnit times.
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
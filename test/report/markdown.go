@@ -0,0 +1,130 @@
+//go:build test
+
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// statusSymbols are the symbols of the statuses in the result tables.
+var statusSymbols = map[Status]string{
+	StatusPassed:  "✅",
+	StatusFailed:  "❌",
+	StatusSkipped: "⏭️",
+}
+
+// markdown renders the report as Markdown.
+func markdown(s *summary) ([]byte, error) {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	fmt.Fprintf(&b, "%s\n", s.Counts())
+
+	for _, suite := range s.Suites {
+		for _, story := range suite.Stories {
+			fmt.Fprintf(&b, "\n## %s\n\n", story.Name)
+			fmt.Fprintf(&b, "_Suite: `%s`_\n", suite.Name)
+
+			for _, scenario := range story.Scenarios {
+				fmt.Fprintf(&b, "\n### %s\n\n", scenario.Name)
+
+				if err := markdownScenario(&b, scenario); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+// markdownScenario renders the result table of a scenario, then its cases.
+func markdownScenario(b *bytes.Buffer, scenario *scenarioSummary) error {
+	fmt.Fprintf(b, "| Case | %s |\n", strings.Join(scenario.Variants, " | "))
+	fmt.Fprintf(b, "|---|%s\n", strings.Repeat("---|", len(scenario.Variants)))
+
+	for _, c := range scenario.Cases {
+		cells := make([]string, len(scenario.Variants))
+		for i, v := range scenario.Variants {
+			if r, found := c.Results[v]; found {
+				cells[i] = statusSymbols[r.Status]
+			}
+		}
+
+		fmt.Fprintf(b, "| %s | %s |\n", markdownCell(c.Title()), strings.Join(cells, " | "))
+	}
+
+	for _, c := range scenario.Cases {
+		if !c.Described() {
+			continue
+		}
+
+		if c.Name != "" {
+			fmt.Fprintf(b, "\n#### %s\n", c.Name)
+		}
+
+		if len(c.Steps) > 0 {
+			fmt.Fprintln(b)
+			for _, step := range c.Steps {
+				fmt.Fprintf(b, "- %s\n", step)
+			}
+		}
+
+		if c.Request != nil {
+			request, err := yaml.Marshal(c.Request)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(b, "\nRequest:\n\n```yaml\n%s```\n", request)
+		}
+
+		if c.Outcome != "" {
+			fmt.Fprintf(b, "\nExpected: %s", c.Outcome)
+			if len(c.Details) > 0 {
+				fmt.Fprintf(b, ", mentioning %s", quoteAll(c.Details, "`"))
+			}
+			fmt.Fprintln(b, ".")
+		}
+	}
+
+	return nil
+}
+
+// Counts summarizes the statuses of the report.
+func (s *summary) Counts() string {
+	return fmt.Sprintf("%d checks: %d passed, %d failed, %d skipped.", s.Total, s.Passed, s.Failed, s.Skipped)
+}
+
+// Title names the case in the result tables.
+func (c *caseSummary) Title() string {
+	if c.Name == "" {
+		return "(scenario)"
+	}
+
+	return c.Name
+}
+
+// Described checks if the case has a description to render.
+func (c *caseSummary) Described() bool {
+	return len(c.Steps) > 0 || c.Request != nil || c.Outcome != ""
+}
+
+// markdownCell escapes the pipes of a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// quoteAll quotes the strings with `quote` and joins them.
+func quoteAll(ss []string, quote string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = quote + s + quote
+	}
+
+	return strings.Join(quoted, ", ")
+}
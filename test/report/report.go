@@ -0,0 +1,352 @@
+//go:build test
+
+// Package report turns the outcomes of the acceptance tests into a living
+// documentation: Markdown and HTML pages telling what the system does, grouped
+// by user story, scenario, case and SUT variant, and a JUnit XML file for the
+// CI tools.
+//
+// The tests record an Entry per case with Report.Record, and fill it with the
+// request they send and the outcome they expect. The status and the duration
+// of the case are captured when its test ends. The reports are written once
+// all the tests have run, usually from `TestMain`:
+//
+//	code := m.Run()
+//	if dir := os.Getenv(report.DirEnv); dir != "" {
+//		err := acceptanceReport.WriteFiles(dir)
+//		...
+//	}
+package report
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// DirEnv is the environment variable naming the directory where the reports
+// are written.
+const DirEnv = "GTD_REPORT_DIR"
+
+// File names of the reports written by WriteFiles.
+const (
+	MarkdownFile = "report.md"
+	HTMLFile     = "report.html"
+	JUnitFile    = "junit.xml"
+)
+
+// Status is the outcome of the test of a case.
+type Status string
+
+// Statuses of the cases.
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+//
+// Entry
+
+// Key names a case run against a SUT variant.
+type Key struct {
+	Story    string
+	Scenario string
+
+	// Case is empty for scenarios without cases.
+	Case    string
+	Variant string
+}
+
+// Entry is the record of a case run against a SUT variant.
+//
+// The methods of Entry do nothing on a nil Entry, so that the tests can report
+// unconditionally.
+type Entry struct {
+	Key
+
+	// Suite is the top-level test of the case, set by Report.Record.
+	Suite string
+
+	mu sync.Mutex
+
+	// steps are the steps of the scenario, for the ones read from feature
+	// files.
+	steps []string
+
+	request  map[string]any
+	outcome  string
+	details  []string
+	status   Status
+	duration time.Duration
+}
+
+// AddStep records a step of the scenario, e.g. `Given that no customer is
+// registered`.
+func (e *Entry) AddStep(step string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.steps = append(e.steps, step)
+}
+
+// SetRequest records a copy of the request sent by the case. The last request
+// wins.
+func (e *Entry) SetRequest(request map[string]any) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.request = maps.Clone(request)
+}
+
+// SetOutcome records the outcome expected by the case, e.g. `rejected as
+// invalid data`, and the details the response must mention, e.g. the
+// `find_on_error` strings.
+func (e *Entry) SetOutcome(outcome string, details ...string) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.outcome = outcome
+	e.details = slices.Clone(details)
+}
+
+// finish records the result of the test of the case.
+func (e *Entry) finish(status Status, duration time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.status = status
+	e.duration = duration
+}
+
+//
+// Report
+
+// Report collects the entries of the acceptance tests. It is safe for
+// concurrent use by parallel tests.
+type Report struct {
+	Title string
+
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+// New creates an empty report.
+func New(title string) *Report {
+	return &Report{Title: title}
+}
+
+// Record adds an entry for the case `key` tested by `t`, and returns it to be
+// filled by the test. The status and duration of the case are captured when
+// `t` ends.
+func (r *Report) Record(t *testing.T, key Key) *Entry {
+	t.Helper()
+
+	e := &Entry{Key: key}
+	e.Suite, _, _ = strings.Cut(t.Name(), "/")
+
+	start := time.Now()
+
+	t.Cleanup(func() {
+		status := StatusPassed
+		switch {
+		case t.Failed():
+			status = StatusFailed
+		case t.Skipped():
+			status = StatusSkipped
+		}
+
+		e.finish(status, time.Since(start))
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, e)
+
+	return e
+}
+
+// WriteFiles writes the Markdown, HTML and JUnit reports to `dir`, creating
+// it if needed.
+func (r *Report) WriteFiles(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	s := r.summarize()
+
+	for name, write := range map[string]func(*summary) ([]byte, error){
+		MarkdownFile: markdown,
+		HTMLFile:     renderHTML,
+		JUnitFile:    junit,
+	} {
+		data, err := write(s)
+		if err != nil {
+			return fmt.Errorf("report: %s: %w", name, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// Summary
+
+// summary is the report grouped by suite, story, scenario and case, in
+// alphabetical order.
+type summary struct {
+	Title                          string
+	Total, Passed, Failed, Skipped int
+	Suites                         []*suiteSummary
+}
+
+type suiteSummary struct {
+	Name    string
+	Stories []*storySummary
+}
+
+type storySummary struct {
+	Name      string
+	Scenarios []*scenarioSummary
+}
+
+type scenarioSummary struct {
+	Name string
+
+	// Variants are the variants the scenario was run against.
+	Variants []string
+	Cases    []*caseSummary
+}
+
+type caseSummary struct {
+	Name    string
+	Steps   []string
+	Request map[string]any
+	Outcome string
+	Details []string
+
+	// Results maps the variants to the results of the case.
+	Results map[string]result
+}
+
+type result struct {
+	Status   Status
+	Duration time.Duration
+}
+
+// summarize groups the entries of the report.
+func (r *Report) summarize() *summary {
+	r.mu.Lock()
+	entries := slices.Clone(r.entries)
+	r.mu.Unlock()
+
+	slices.SortFunc(entries, func(a, b *Entry) int {
+		return cmp.Or(
+			cmp.Compare(a.Suite, b.Suite),
+			cmp.Compare(a.Story, b.Story),
+			cmp.Compare(a.Scenario, b.Scenario),
+			cmp.Compare(a.Case, b.Case),
+			cmp.Compare(a.Variant, b.Variant),
+		)
+	})
+
+	s := &summary{Title: r.Title}
+
+	var (
+		suite    *suiteSummary
+		story    *storySummary
+		scenario *scenarioSummary
+		c        *caseSummary
+	)
+
+	for _, e := range entries {
+		e.mu.Lock()
+
+		if suite == nil || suite.Name != e.Suite {
+			suite = &suiteSummary{Name: e.Suite}
+			s.Suites = append(s.Suites, suite)
+			story = nil
+		}
+
+		if story == nil || story.Name != e.Story {
+			story = &storySummary{Name: e.Story}
+			suite.Stories = append(suite.Stories, story)
+			scenario = nil
+		}
+
+		if scenario == nil || scenario.Name != e.Scenario {
+			scenario = &scenarioSummary{Name: e.Scenario}
+			story.Scenarios = append(story.Scenarios, scenario)
+			c = nil
+		}
+
+		if c == nil || c.Name != e.Case {
+			c = &caseSummary{Name: e.Case, Results: map[string]result{}}
+			scenario.Cases = append(scenario.Cases, c)
+		}
+
+		if !slices.Contains(scenario.Variants, e.Variant) {
+			scenario.Variants = append(scenario.Variants, e.Variant)
+		}
+
+		// The variants run the same case: the first description wins.
+		if c.Steps == nil {
+			c.Steps = e.steps
+		}
+		if c.Request == nil {
+			c.Request = e.request
+		}
+		if c.Outcome == "" {
+			c.Outcome, c.Details = e.outcome, e.details
+		}
+
+		// The entries of the tests still running, if any, count as failed.
+		status := cmp.Or(e.status, StatusFailed)
+		c.Results[e.Variant] = result{Status: status, Duration: e.duration}
+
+		s.Total++
+		switch status {
+		case StatusPassed:
+			s.Passed++
+		case StatusFailed:
+			s.Failed++
+		case StatusSkipped:
+			s.Skipped++
+		}
+
+		e.mu.Unlock()
+	}
+
+	for _, suite := range s.Suites {
+		for _, story := range suite.Stories {
+			for _, scenario := range story.Scenarios {
+				slices.Sort(scenario.Variants)
+			}
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,89 @@
+//go:build test
+
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	r := New("Acceptance tests")
+
+	// The recorded subtests must end before the report is written.
+	t.Run("cases", func(t *testing.T) {
+		for _, variant := range []string{"sqlite", "reference"} {
+			t.Run(variant+"/valid", func(t *testing.T) {
+				e := r.Record(t, Key{Story: "Register a customer", Scenario: "should register", Case: "when valid", Variant: variant})
+				e.SetRequest(map[string]any{"name": "John Due"})
+				e.SetOutcome("registered")
+			})
+
+			t.Run(variant+"/duplicated", func(t *testing.T) {
+				e := r.Record(t, Key{Story: "Register a customer", Scenario: "should reject", Case: "when | duplicated", Variant: variant})
+				e.SetOutcome("rejected as duplicated data", "duplicated name")
+			})
+		}
+
+		t.Run("skipped", func(t *testing.T) {
+			e := r.Record(t, Key{Story: "Register a customer", Scenario: "should authenticate", Variant: "sqlite"})
+			e.AddStep("Given an anonymous caller")
+			t.Skip("not applicable")
+		})
+	})
+
+	dir := t.TempDir()
+	require.NoError(t, r.WriteFiles(dir))
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("should write Markdown", func(t *testing.T) {
+		md := read(MarkdownFile)
+
+		require.Contains(t, md, "# Acceptance tests\n\n5 checks: 4 passed, 0 failed, 1 skipped.")
+		require.Contains(t, md, "## Register a customer\n\n_Suite: `TestReport`_")
+		require.Contains(t, md, "| Case | reference | sqlite |\n|---|---|---|\n| when valid | ✅ | ✅ |")
+		require.Contains(t, md, "| when \\| duplicated | ✅ | ✅ |")
+		require.Contains(t, md, "| (scenario) | ⏭️ |")
+		require.Contains(t, md, "Request:\n\n```yaml\nname: John Due\n```\n\nExpected: registered.")
+		require.Contains(t, md, "Expected: rejected as duplicated data, mentioning `duplicated name`.")
+		require.Contains(t, md, "- Given an anonymous caller")
+	})
+
+	t.Run("should write HTML", func(t *testing.T) {
+		html := read(HTMLFile)
+
+		require.Contains(t, html, "<h2>Register a customer</h2>")
+		require.Contains(t, html, "<tr><td>when | duplicated</td><td>✅</td><td>✅</td></tr>")
+		require.Contains(t, html, "mentioning <code>duplicated name</code>.")
+	})
+
+	t.Run("should write JUnit XML", func(t *testing.T) {
+		var suites junitTestSuites
+		require.NoError(t, xml.Unmarshal([]byte(read(JUnitFile)), &suites))
+
+		require.Equal(t, 5, suites.Tests)
+		require.Equal(t, 1, suites.Skipped)
+		require.Len(t, suites.Suites, 1)
+		require.Equal(t, "should register / when valid [reference]", suites.Suites[0].Cases[1].Name)
+		require.Contains(t, suites.Suites[0].Cases[1].SystemOut, "request:\nname: John Due\n")
+	})
+
+	t.Run("should report failed cases", func(t *testing.T) {
+		e := &Entry{Key: Key{Story: "s", Scenario: "sc", Variant: "v"}}
+		e.finish(StatusFailed, 0)
+
+		failing := &Report{Title: "t", entries: []*Entry{e}}
+		data, err := junit(failing.summarize())
+		require.NoError(t, err)
+		require.Contains(t, string(data), `<failure message="the case failed; see the test output"></failure>`)
+	})
+}
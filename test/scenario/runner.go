@@ -0,0 +1,196 @@
+//go:build test
+
+package scenario
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/maniosgrivei/go-test-drivers/customer"
+	"github.com/maniosgrivei/go-test-drivers/test/driverdata"
+	"github.com/maniosgrivei/go-test-drivers/test/harness"
+	"github.com/maniosgrivei/go-test-drivers/test/report"
+	"github.com/stretchr/testify/require"
+)
+
+// Runner runs the scenarios of scenario files against a SUT variant, each
+// scenario as a subtest with a new SUT.
+type Runner struct {
+	variant  harness.Variant[customer.SUT]
+	parallel bool
+
+	report *report.Report
+	story  string
+}
+
+// NewRunner creates a Runner for the SUT `variant`.
+func NewRunner(variant harness.Variant[customer.SUT]) *Runner {
+	return &Runner{variant: variant}
+}
+
+// Parallel makes the runner run the scenarios in parallel, with `t.Parallel`.
+func (r *Runner) Parallel() {
+	r.parallel = true
+}
+
+// Report records every scenario into `rep`, as a case of `story`, with its
+// steps.
+func (r *Runner) Report(rep *report.Report, story string) {
+	r.report = rep
+	r.story = story
+}
+
+// Run reads the scenario files at `paths` and runs their scenarios, in the
+// order of their titles.
+func (r *Runner) Run(t *testing.T, paths ...string) {
+	t.Helper()
+
+	for _, path := range paths {
+		f, err := LoadFile(path)
+		require.NoError(t, err)
+
+		for _, title := range slices.Sorted(maps.Keys(f.Scenarios)) {
+			sc := f.Scenarios[title]
+
+			t.Run(title, func(t *testing.T) {
+				if r.parallel {
+					t.Parallel()
+				}
+
+				var entry *report.Entry
+				if r.report != nil {
+					entry = r.report.Record(t, report.Key{Story: r.story, Scenario: title, Variant: r.variant.Name})
+				}
+
+				if !r.variant.Matches(sc.Variants) {
+					t.Skipf("the scenario is not run by the variant %s", r.variant.Name)
+				}
+
+				td := r.variant.Setup(t).TestDriver
+				r.runSteps(t, td, sc.Steps, entry)
+			})
+		}
+	}
+}
+
+// runSteps runs the steps in order on the test driver `td`.
+func (r *Runner) runSteps(t *testing.T, td *customer.CustomerServiceTestDriver, steps []Step, entry *report.Entry) {
+	t.Helper()
+
+	results := map[string]any{}
+
+	for i, step := range steps {
+		if !r.variant.Matches(step.Variants) {
+			t.Logf("step #%d, %s, is not run by the variant %s", i+1, step, r.variant.Name)
+			continue
+		}
+
+		entry.AddStep(step.String())
+
+		result, err := call(t, td, step, results)
+		if err != nil {
+			t.Fatalf("scenario: step #%d: %v", i+1, err)
+		}
+
+		if step.Name != "" {
+			results[step.Name] = result
+		}
+	}
+}
+
+// call calls the method of `step` on the test driver `td`, with the arguments
+// of the step resolved against `results`, and returns the first value it
+// returns, if any.
+func call(t *testing.T, td any, step Step, results map[string]any) (any, error) {
+	t.Helper()
+
+	_, name, err := step.Method()
+	if err != nil {
+		return nil, err
+	}
+
+	method := reflect.ValueOf(td).MethodByName(name)
+	if !method.IsValid() {
+		return nil, fmt.Errorf("the test driver has no method '%s'", name)
+	}
+
+	resolved, err := resolve(step.Args, results)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := bindArgs(t, method.Type(), resolved.([]any))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var out []reflect.Value
+	if method.Type().IsVariadic() {
+		out = method.CallSlice(args)
+	} else {
+		out = method.Call(args)
+	}
+
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	return out[0].Interface(), nil
+}
+
+// testingTType is the type of the first parameter of the methods.
+var testingTType = reflect.TypeOf((*testing.T)(nil))
+
+// bindArgs binds the `args` of a step to the parameters of a method of type
+// `mt`, which must take a `*testing.T` first. The variadic parameter, if any,
+// takes the remaining arguments.
+func bindArgs(t *testing.T, mt reflect.Type, args []any) ([]reflect.Value, error) {
+	if mt.NumIn() == 0 || mt.In(0) != testingTType {
+		return nil, fmt.Errorf("the method does not take a *testing.T first")
+	}
+
+	params := mt.NumIn() - 1
+	if mt.IsVariadic() {
+		if len(args) < params-1 {
+			return nil, fmt.Errorf("expected at least %d arguments, got %d", params-1, len(args))
+		}
+	} else if len(args) != params {
+		return nil, fmt.Errorf("expected %d arguments, got %d", params, len(args))
+	}
+
+	values := []reflect.Value{reflect.ValueOf(t)}
+
+	for i := 1; i <= params; i++ {
+		if mt.IsVariadic() && i == params {
+			rest := reflect.New(mt.In(i))
+			if err := driverdata.DecodeValue(args[i-1:], rest.Interface()); err != nil {
+				return nil, fmt.Errorf("argument #%d: %w", i, err)
+			}
+			values = append(values, rest.Elem())
+			break
+		}
+
+		value := reflect.New(mt.In(i))
+		if err := bindArg(args[i-1], value); err != nil {
+			return nil, fmt.Errorf("argument #%d: %w", i, err)
+		}
+		values = append(values, value.Elem())
+	}
+
+	return values, nil
+}
+
+// bindArg binds the argument `arg` to the value pointed to by `ptr`. The
+// results of the steps, such as snapshots, are given as is to the parameters
+// of their type.
+func bindArg(arg any, ptr reflect.Value) error {
+	if arg != nil && reflect.TypeOf(arg).AssignableTo(ptr.Elem().Type()) {
+		ptr.Elem().Set(reflect.ValueOf(arg))
+		return nil
+	}
+
+	return driverdata.DecodeValue(arg, ptr.Interface())
+}
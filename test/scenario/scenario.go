@@ -0,0 +1,205 @@
+//go:build test
+
+// Package scenario runs multi-step scenarios written in YAML against the
+// customer.CustomerServiceTestDriver. Where the case files hold a single
+// request per case, a scenario is an ordered list of steps, each calling a
+// method of the test driver with its arguments:
+//
+//	scenarios:
+//	  "should reject a second registration with the same email":
+//	    steps:
+//	      - arrange: ArrangeInternalsNoCustomerIsRegistered
+//	      - name: first
+//	        act: ActTryToRegisterACustomer
+//	        args: [{name: John Doe, email: john@doe.com, phone: "+1 234 567 890"}, {}]
+//	      - assert: AssertRegistrationShouldSucceed
+//	        args: [$steps.first, {}]
+//	      - assert: AssertInternalsCustomerShouldBeProperlyRegistered
+//	        args: [{id: $steps.first.id, name: John Doe, email: john@doe.com, phone: "+1 234 567 890"}]
+//
+// The `*testing.T` of the methods is given by the runner, the other
+// parameters are bound from the `args` with the driverdata package.
+//
+// The result of a named step can be used by the following ones: the string
+// `$steps.<name>` is replaced by the value returned by the method of the step,
+// and `$steps.<name>.<key>...` by the value found at the keys of the returned
+// maps. A leading `$$` stands for a literal `$`.
+//
+// Scenarios and steps can be restricted to some SUT variants with `variants`,
+// holding comma separated patterns, as the `-gtd.variants` flag, e.g.
+// `*-rest*,!*-https`. The scenarios run by no selected variant are skipped,
+// and so are the steps, whose results can't be used then.
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a file of scenarios.
+type File struct {
+	// Scenarios maps the titles of the scenarios to the scenarios.
+	Scenarios map[string]Scenario `yaml:"scenarios"`
+}
+
+// Scenario is an ordered list of steps.
+type Scenario struct {
+	// Variants selects the variants running the scenario, all when empty.
+	Variants string `yaml:"variants"`
+
+	Steps []Step `yaml:"steps"`
+}
+
+// Phases of the steps.
+const (
+	Arrange = "arrange"
+	Act     = "act"
+	Assert  = "assert"
+)
+
+// Step calls a method of the test driver. Exactly one of Arrange, Act or
+// Assert names the method; the methods named after a phase, such as
+// ArrangeInternalsNoCustomerIsRegistered, must be called in that phase.
+type Step struct {
+	// Name names the result of the step, for the following steps to use it.
+	Name string `yaml:"name"`
+
+	Arrange string `yaml:"arrange"`
+	Act     string `yaml:"act"`
+	Assert  string `yaml:"assert"`
+
+	// Args are the arguments of the method, after the `*testing.T`.
+	Args []any `yaml:"args"`
+
+	// Variants selects the variants running the step, all when empty.
+	Variants string `yaml:"variants"`
+}
+
+// Method returns the phase of the step and the name of the method it calls.
+func (s Step) Method() (phase, method string, err error) {
+	var set []string
+	for _, p := range []struct{ phase, method string }{{Arrange, s.Arrange}, {Act, s.Act}, {Assert, s.Assert}} {
+		if p.method != "" {
+			phase, method = p.phase, p.method
+			set = append(set, p.phase)
+		}
+	}
+
+	if len(set) != 1 {
+		return "", "", fmt.Errorf("expected one of arrange, act or assert, got %d", len(set))
+	}
+
+	for _, other := range []string{Arrange, Act, Assert} {
+		prefix := strings.ToUpper(other[:1]) + other[1:]
+		if other != phase && strings.HasPrefix(method, prefix) {
+			return "", "", fmt.Errorf("method '%s' should be called in the %s phase, not in %s", method, other, phase)
+		}
+	}
+
+	return phase, method, nil
+}
+
+// String describes the step, e.g. `act ActTryToRegisterACustomer as first`.
+func (s Step) String() string {
+	phase, method, err := s.Method()
+	if err != nil {
+		return "invalid step"
+	}
+
+	if s.Name != "" {
+		return fmt.Sprintf("%s %s as %s", phase, method, s.Name)
+	}
+
+	return phase + " " + method
+}
+
+// LoadFile reads a file of scenarios.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &f, nil
+}
+
+//
+// Variables
+
+// stepsPrefix starts the references to the results of the steps.
+const stepsPrefix = "$steps."
+
+// resolve returns a copy of `val` where the references to the results of the
+// steps, given by `results`, are replaced by their values. Maps and lists are
+// resolved recursively.
+func resolve(val any, results map[string]any) (any, error) {
+	switch v := val.(type) {
+	case string:
+		return resolveString(v, results)
+
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, item := range v {
+			var err error
+			if resolved[key], err = resolve(item, results); err != nil {
+				return nil, err
+			}
+		}
+
+		return resolved, nil
+
+	case []any:
+		resolved := make([]any, len(v))
+		for i, item := range v {
+			var err error
+			if resolved[i], err = resolve(item, results); err != nil {
+				return nil, err
+			}
+		}
+
+		return resolved, nil
+
+	default:
+		return val, nil
+	}
+}
+
+// resolveString resolves a string holding a reference, or an escaped `$`.
+func resolveString(s string, results map[string]any) (any, error) {
+	if escaped, found := strings.CutPrefix(s, "$$"); found {
+		return "$" + escaped, nil
+	}
+
+	ref, found := strings.CutPrefix(s, stepsPrefix)
+	if !found {
+		return s, nil
+	}
+
+	keys := strings.Split(ref, ".")
+
+	val, found := results[keys[0]]
+	if !found {
+		return nil, fmt.Errorf("'%s' references step '%s', which has not run", s, keys[0])
+	}
+
+	for i, key := range keys[1:] {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("'%s': '%s' is a %T, not a map", s, strings.Join(keys[:i+1], "."), val)
+		}
+
+		if val, found = m[key]; !found {
+			return nil, fmt.Errorf("'%s': '%s' has no key '%s'", s, strings.Join(keys[:i+1], "."), key)
+		}
+	}
+
+	return val, nil
+}
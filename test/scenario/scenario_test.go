@@ -0,0 +1,156 @@
+//go:build test
+
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepMethod(t *testing.T) {
+	r := require.New(t)
+
+	phase, method, err := Step{Name: "first", Act: "ActTryToRegisterACustomer"}.Method()
+	r.NoError(err)
+	r.Equal(Act, phase)
+	r.Equal("ActTryToRegisterACustomer", method)
+
+	phase, method, err = Step{Arrange: "Snapshot"}.Method()
+	r.NoError(err)
+	r.Equal(Arrange, phase)
+	r.Equal("Snapshot", method)
+
+	r.Equal("act ActTryToRegisterACustomer as first", Step{Name: "first", Act: "ActTryToRegisterACustomer"}.String())
+	r.Equal("arrange Snapshot", Step{Arrange: "Snapshot"}.String())
+
+	t.Run("should require exactly one phase", func(t *testing.T) {
+		_, _, err := Step{}.Method()
+		require.EqualError(t, err, "expected one of arrange, act or assert, got 0")
+
+		_, _, err = Step{Act: "ActTryToRegisterACustomer", Assert: "AssertRegistrationShouldSucceed"}.Method()
+		require.EqualError(t, err, "expected one of arrange, act or assert, got 2")
+	})
+
+	t.Run("should call the methods of a phase in that phase", func(t *testing.T) {
+		_, _, err := Step{Arrange: "AssertRegistrationShouldSucceed"}.Method()
+		require.EqualError(t, err, "method 'AssertRegistrationShouldSucceed' should be called in the assert phase, not in arrange")
+	})
+}
+
+func TestResolve(t *testing.T) {
+	r := require.New(t)
+
+	results := map[string]any{
+		"first":    map[string]any{"id": "AB12-CD34-EF56", "customer": map[string]any{"name": "John"}},
+		"snapshot": 42,
+	}
+
+	resolved, err := resolve([]any{
+		"$steps.first.id",
+		map[string]any{"name": "$steps.first.customer.name", "tags": []any{"$$steps.first", "plain"}},
+		"$steps.snapshot",
+		7,
+	}, results)
+	r.NoError(err)
+	r.Equal([]any{
+		"AB12-CD34-EF56",
+		map[string]any{"name": "John", "tags": []any{"$steps.first", "plain"}},
+		42,
+		7,
+	}, resolved)
+
+	t.Run("should reject the references to steps not run", func(t *testing.T) {
+		_, err := resolve("$steps.second.id", results)
+		require.EqualError(t, err, "'$steps.second.id' references step 'second', which has not run")
+	})
+
+	t.Run("should reject the keys of values not being maps", func(t *testing.T) {
+		_, err := resolve("$steps.snapshot.id", results)
+		require.EqualError(t, err, "'$steps.snapshot.id': 'snapshot' is a int, not a map")
+	})
+
+	t.Run("should reject missing keys", func(t *testing.T) {
+		_, err := resolve("$steps.first.email", results)
+		require.EqualError(t, err, "'$steps.first.email': 'first' has no key 'email'")
+	})
+}
+
+// driver is a test driver of the tests of the runner.
+type driver struct {
+	called []any
+}
+
+type snapshot struct{ n int }
+
+func (d *driver) Snapshot(_ *testing.T) snapshot {
+	return snapshot{n: len(d.called)}
+}
+
+func (d *driver) Restore(_ *testing.T, s snapshot) {
+	d.called = append(d.called, s)
+}
+
+func (d *driver) ActRegister(_ *testing.T, data map[string]any, extraArgs ...map[string]any) map[string]any {
+	d.called = append(d.called, data, extraArgs)
+	return map[string]any{"id": "AB12-CD34-EF56"}
+}
+
+func (d *driver) AssertCount(_ *testing.T, count int) {
+	d.called = append(d.called, count)
+}
+
+func (d *driver) AssertNoT(count int) {}
+
+func TestCall(t *testing.T) {
+	r := require.New(t)
+
+	d := &driver{}
+	results := map[string]any{}
+
+	for _, step := range []Step{
+		{Name: "empty", Arrange: "Snapshot"},
+		{Name: "first", Act: "ActRegister", Args: []any{map[string]any{"name": "John"}, map[string]any{"caller": "admin"}}},
+		{Act: "ActRegister", Args: []any{map[string]any{"name": "$steps.first.id"}}},
+		{Arrange: "Restore", Args: []any{"$steps.empty"}},
+		{Assert: "AssertCount", Args: []any{2}},
+	} {
+		result, err := call(t, d, step, results)
+		r.NoError(err, step.String())
+
+		if step.Name != "" {
+			results[step.Name] = result
+		}
+	}
+
+	r.Equal([]any{
+		map[string]any{"name": "John"}, []map[string]any{{"caller": "admin"}},
+		map[string]any{"name": "AB12-CD34-EF56"}, []map[string]any{},
+		snapshot{n: 0},
+		2,
+	}, d.called)
+
+	t.Run("should reject unknown methods", func(t *testing.T) {
+		_, err := call(t, d, Step{Act: "ActUnknown"}, results)
+		require.EqualError(t, err, "the test driver has no method 'ActUnknown'")
+	})
+
+	t.Run("should reject the wrong number of arguments", func(t *testing.T) {
+		_, err := call(t, d, Step{Assert: "AssertCount"}, results)
+		require.EqualError(t, err, "AssertCount: expected 1 arguments, got 0")
+
+		_, err = call(t, d, Step{Act: "ActRegister"}, results)
+		require.EqualError(t, err, "ActRegister: expected at least 1 arguments, got 0")
+	})
+
+	t.Run("should reject methods not taking a *testing.T", func(t *testing.T) {
+		_, err := bindArgs(t, reflect.TypeOf(d.AssertNoT), []any{1})
+		require.EqualError(t, err, "the method does not take a *testing.T first")
+	})
+
+	t.Run("should reject arguments of the wrong type", func(t *testing.T) {
+		_, err := call(t, d, Step{Assert: "AssertCount", Args: []any{"two"}}, results)
+		require.ErrorContains(t, err, "AssertCount: argument #1")
+	})
+}